@@ -0,0 +1,303 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hrms-backend/database"
+	"hrms-backend/models"
+	"hrms-backend/storage"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// Format identifies HRMS backup archives
+	Format = "hrms-backup"
+	// FormatVersion is bumped whenever the archive layout changes. Version 2
+	// added the document files; version 1 archives hold only the database.
+	FormatVersion = 2
+
+	manifestName = "manifest.json"
+	filesDir     = "files/"
+	batchSize    = 1000
+)
+
+// Manifest describes the contents of a backup archive. It is the first entry
+// of the archive so that restores can validate it before loading any data.
+type Manifest struct {
+	Format        string          `json:"format"`
+	FormatVersion int             `json:"formatVersion"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	SchemaVersion uint            `json:"schemaVersion"`
+	Driver        string          `json:"driver"`
+	Tables        []TableManifest `json:"tables"`
+	Files         FileManifest    `json:"files"`
+}
+
+// TableManifest describes one table's JSON-lines file
+type TableManifest struct {
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+	SHA256  string   `json:"sha256"`
+}
+
+// FileManifest counts the document files stored under files/, each named by
+// its storage key. Every file is checked against the size and checksum its
+// documents row records, so they need no checksums of their own.
+type FileManifest struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// table is an entity included in backups
+type table struct {
+	name  string
+	model interface{}
+	// deferred are nullable foreign keys that may point at rows loaded later,
+	// such as a department's manager or an employee's manager. Restore writes
+	// them in a second pass once every row exists.
+	deferred []string
+}
+
+// tables lists every backed-up entity in restore order: each table only
+// references tables before it, apart from its deferred columns.
+// Idempotency keys are short-lived request state and are left out.
+var tables = []table{
+	{name: "custom_fields", model: &models.CustomField{}},
+	{name: "departments", model: &models.Department{}, deferred: []string{"manager_id"}},
+	{name: "employees", model: &models.Employee{}, deferred: []string{"manager_id"}},
+	{name: "users", model: &models.User{}},
+	{name: "leave_requests", model: &models.LeaveRequest{}},
+	{name: "attendances", model: &models.Attendance{}},
+	{name: "payroll_records", model: &models.PayrollRecord{}},
+	{name: "feature_flags", model: &models.FeatureFlag{}},
+	{name: "employment_changes", model: &models.EmploymentChange{}},
+	{name: "checklist_templates", model: &models.ChecklistTemplate{}},
+	{name: "checklist_template_tasks", model: &models.ChecklistTemplateTask{}},
+	{name: "checklist_tasks", model: &models.ChecklistTask{}},
+	{name: "documents", model: &models.Document{}},
+	{name: "emergency_contacts", model: &models.EmergencyContact{}},
+	{name: "dependents", model: &models.Dependent{}},
+	{name: "code_sequences", model: &models.CodeSequence{}},
+	{name: "skills", model: &models.Skill{}},
+	{name: "certifications", model: &models.Certification{}},
+	{name: "notifications", model: &models.Notification{}},
+}
+
+// Write dumps every HRMS table to w as a gzip-compressed tar archive, followed
+// by the contents of every document read from store. All tables are read in
+// one read-only transaction, so the archive is a consistent snapshot even
+// while the API keeps serving writes. Document files are copied after that
+// transaction, so one deleted in the meantime fails the backup.
+func Write(db *gorm.DB, store storage.Store, w io.Writer) (*Manifest, error) {
+	manifest := &Manifest{
+		Format:        Format,
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Driver:        db.Dialector.Name(),
+	}
+
+	// Spool each table to a temporary file so the manifest, which needs the
+	// row counts and checksums, can be written first
+	spools := make([]*os.File, 0, len(tables))
+	var documents []models.Document
+	defer func() {
+		for _, spool := range spools {
+			spool.Close()
+			os.Remove(spool.Name())
+		}
+	}()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		version, err := database.NewMigrator(tx).Version()
+		if err != nil {
+			return err
+		}
+		manifest.SchemaVersion = version
+
+		for _, t := range tables {
+			spool, err := os.CreateTemp("", "hrms-backup-*.jsonl")
+			if err != nil {
+				return err
+			}
+			spools = append(spools, spool)
+
+			entry, err := dumpTable(tx, t, spool)
+			if err != nil {
+				return fmt.Errorf("failed to dump %s: %w", t.name, err)
+			}
+			manifest.Tables = append(manifest.Tables, entry)
+		}
+
+		if err := tx.Select("storage_key", "size", "checksum").Order("id").Find(&documents).Error; err != nil {
+			return fmt.Errorf("failed to list document files: %w", err)
+		}
+		for _, document := range documents {
+			manifest.Files.Count++
+			manifest.Files.Bytes += document.Size
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(archive, manifestName, manifestJSON); err != nil {
+		return nil, err
+	}
+	for i, entry := range manifest.Tables {
+		if err := copyEntry(archive, entry.File, spools[i]); err != nil {
+			return nil, err
+		}
+	}
+	ctx := context.Background()
+	for _, document := range documents {
+		if err := copyFile(ctx, archive, store, document); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// dumpTable writes every row of t, soft-deleted ones included, as one JSON
+// object per line keyed by column name
+func dumpTable(tx *gorm.DB, t table, out io.Writer) (TableManifest, error) {
+	s, err := parseSchema(tx, t.model)
+	if err != nil {
+		return TableManifest{}, err
+	}
+	fields := columnFields(s)
+
+	entry := TableManifest{Name: t.name, File: "data/" + t.name + ".jsonl"}
+	for _, field := range fields {
+		entry.Columns = append(entry.Columns, field.DBName)
+	}
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(io.MultiWriter(out, hash))
+	ctx := context.Background()
+
+	rows := reflect.New(reflect.SliceOf(s.ModelType))
+	result := tx.Unscoped().FindInBatches(rows.Interface(), batchSize, func(batch *gorm.DB, _ int) error {
+		slice := rows.Elem()
+		for i := 0; i < slice.Len(); i++ {
+			record := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				// The Go value, not ValueOf, which wraps serialized fields
+				record[field.DBName] = field.ReflectValueOf(ctx, slice.Index(i)).Interface()
+			}
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+			entry.Rows++
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return TableManifest{}, result.Error
+	}
+
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return entry, nil
+}
+
+func parseSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// serialID reports whether s is keyed by an auto-incremented id column, as
+// every table but natural-key ones such as code_sequences is
+func serialID(s *schema.Schema) bool {
+	key := s.PrioritizedPrimaryField
+	return key != nil && key.DBName == "id" && key.AutoIncrement
+}
+
+// columnFields returns the fields of s stored in its own table, skipping associations
+func columnFields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if field.DBName != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func writeEntry(archive *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
+
+func copyEntry(archive *tar.Writer, name string, spool *os.File) error {
+	info, err := spool.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: 0o644, Size: info.Size(), ModTime: time.Now()}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(archive, spool)
+	return err
+}
+
+// copyFile streams one document's contents from the store into the archive,
+// checking them against the size and checksum in its row
+func copyFile(ctx context.Context, archive *tar.Writer, store storage.Store, document models.Document) error {
+	contents, err := store.Get(ctx, document.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to read document file %s: %w", document.StorageKey, err)
+	}
+	defer contents.Close()
+
+	header := &tar.Header{Name: filesDir + document.StorageKey, Mode: 0o644, Size: document.Size, ModTime: time.Now()}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.CopyN(archive, io.TeeReader(contents, hash), document.Size); err != nil {
+		return fmt.Errorf("failed to read document file %s: %w", document.StorageKey, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != document.Checksum {
+		return fmt.Errorf("document file %s does not match its checksum", document.StorageKey)
+	}
+	return nil
+}
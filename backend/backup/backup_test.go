@@ -0,0 +1,280 @@
+//go:build cgo
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hrms-backend/config"
+	"hrms-backend/database"
+	"hrms-backend/models"
+	"hrms-backend/seeds"
+	"hrms-backend/storage"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.InitDB(&config.Config{DBDriver: "sqlite", DBPath: filepath.Join(t.TempDir(), "hrms.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// documentFiles are the contents of the documents seededArchive uploads, by storage key
+var documentFiles = map[string]string{
+	"employees/1/contract": "%PDF-1.4 employment contract",
+	"employees/2/passport": "\x89PNG passport scan",
+}
+
+// addDocument stores contents under key and records it as a document of employee 1
+func addDocument(t *testing.T, db *gorm.DB, store storage.Store, key, contents string) {
+	t.Helper()
+	if err := store.Put(context.Background(), key, strings.NewReader(contents), int64(len(contents)), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(contents))
+	document := models.Document{EmployeeID: 1, Category: "other", Name: key, ContentType: "application/octet-stream",
+		Size: int64(len(contents)), Checksum: hex.EncodeToString(sum[:]), StorageKey: key, UploadedBy: 1}
+	if err := db.Create(&document).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func seededArchive(t *testing.T) []byte {
+	t.Helper()
+	db := openSQLite(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	opts := seeds.Options{Employees: 40, Months: 2, Seed: 1, Until: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), Password: "loadtest123"}
+	if err := seeds.Run(db, "load", opts); err != nil {
+		t.Fatal(err)
+	}
+	flag := models.FeatureFlag{Key: "manager_leave_approval", Enabled: true, Roles: []string{"manager"}, DepartmentIDs: []uint{1, 2}}
+	if err := db.Create(&flag).Error; err != nil {
+		t.Fatal(err)
+	}
+	// code_sequences is keyed by scope rather than a serial id
+	if err := db.Create(&models.CodeSequence{Scope: "ENG-2024", Value: 7}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	store := storage.NewLocalStore(t.TempDir())
+	for key, contents := range documentFiles {
+		addDocument(t, db, store, key, contents)
+	}
+
+	var archive bytes.Buffer
+	if _, err := Write(db, store, &archive); err != nil {
+		t.Fatal(err)
+	}
+	return archive.Bytes()
+}
+
+func TestRestorePreservesIDsAndRelationships(t *testing.T) {
+	archive := seededArchive(t)
+
+	db := openSQLite(t)
+	store := storage.NewLocalStore(t.TempDir())
+	manifest, err := Restore(db, store, bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range manifest.Tables {
+		var count int64
+		if err := db.Table(entry.Name).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != entry.Rows {
+			t.Errorf("%s: restored %d rows, manifest lists %d", entry.Name, count, entry.Rows)
+		}
+	}
+
+	var departments []models.Department
+	if err := db.Find(&departments).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, department := range departments {
+		var manager models.Employee
+		if department.ManagerID == nil || db.First(&manager, *department.ManagerID).Error != nil || manager.DepartmentID != department.ID {
+			t.Errorf("department %d lost its manager", department.ID)
+		}
+	}
+
+	var flag models.FeatureFlag
+	if err := db.Where("flag_key = ?", "manager_leave_approval").First(&flag).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !flag.Enabled || len(flag.Roles) != 1 || len(flag.DepartmentIDs) != 2 {
+		t.Errorf("feature flag restored as %+v", flag)
+	}
+
+	var sequence models.CodeSequence
+	if err := db.Where("scope = ?", "ENG-2024").First(&sequence).Error; err != nil || sequence.Value != 7 {
+		t.Errorf("code sequence restored as %+v, %v", sequence, err)
+	}
+
+	if manifest.Files.Count != int64(len(documentFiles)) {
+		t.Errorf("manifest lists %d document files, want %d", manifest.Files.Count, len(documentFiles))
+	}
+	for key, want := range documentFiles {
+		contents, err := store.Get(context.Background(), key)
+		if err != nil {
+			t.Errorf("document file %s was not restored: %v", key, err)
+			continue
+		}
+		got, _ := io.ReadAll(contents)
+		contents.Close()
+		if string(got) != want {
+			t.Errorf("document file %s restored as %q, want %q", key, got, want)
+		}
+	}
+
+	// A second restore into the same database is refused
+	if _, err := Restore(db, store, bytes.NewReader(archive)); err == nil {
+		t.Error("restore into a non-empty database succeeded")
+	}
+}
+
+func TestSerialID(t *testing.T) {
+	db := openSQLite(t)
+	for _, table := range tables {
+		s, err := parseSchema(db, table.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := serialID(s), table.name != "code_sequences"; got != want {
+			t.Errorf("serialID(%s) = %v, want %v", table.name, got, want)
+		}
+	}
+}
+
+func TestRestoreRejectsCorruptArchive(t *testing.T) {
+	var buf bytes.Buffer
+	db := openSQLite(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&models.Department{Name: "Finance"})
+	if _, err := Write(db, storage.NewLocalStore(t.TempDir()), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewrite the archive with a tampered department name but the original manifest
+	tampered := rewriteArchive(t, buf.Bytes(), func(name string, data []byte) []byte {
+		return bytes.Replace(data, []byte("Finance"), []byte("Fynance"), 1)
+	})
+
+	target := openSQLite(t)
+	if _, err := Restore(target, storage.NewLocalStore(t.TempDir()), bytes.NewReader(tampered)); err == nil {
+		t.Fatal("restore of a tampered archive succeeded")
+	}
+	var count int64
+	target.Model(&models.Department{}).Count(&count)
+	if count != 0 {
+		t.Errorf("failed restore left %d departments behind", count)
+	}
+}
+
+func TestRestoreRejectsCorruptDocumentFile(t *testing.T) {
+	// Same length, so only the checksum gives it away
+	tampered := rewriteArchive(t, seededArchive(t), func(name string, data []byte) []byte {
+		if name == filesDir+"employees/1/contract" {
+			return bytes.Replace(data, []byte("contract"), []byte("forgery!"), 1)
+		}
+		return data
+	})
+
+	target := openSQLite(t)
+	if _, err := Restore(target, storage.NewLocalStore(t.TempDir()), bytes.NewReader(tampered)); err == nil {
+		t.Fatal("restore of a tampered document file succeeded")
+	}
+	var count int64
+	target.Model(&models.Document{}).Count(&count)
+	if count != 0 {
+		t.Errorf("failed restore left %d documents behind", count)
+	}
+}
+
+func TestRestoreRejectsMissingDocumentFile(t *testing.T) {
+	truncated := rewriteArchive(t, seededArchive(t), func(name string, data []byte) []byte {
+		if strings.HasPrefix(name, filesDir) {
+			return nil
+		}
+		return data
+	})
+	if _, err := Restore(openSQLite(t), storage.NewLocalStore(t.TempDir()), bytes.NewReader(truncated)); err == nil {
+		t.Fatal("restore without the document files succeeded")
+	}
+}
+
+func TestBackupFailsWithoutDocumentFile(t *testing.T) {
+	db := openSQLite(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&models.Department{Name: "Finance"})
+	db.Create(&models.Employee{EmployeeCode: "EMP001", FirstName: "Ada", LastName: "Byron", Email: "ada@example.com",
+		HireDate: time.Now(), Position: "Analyst", Status: "active", DepartmentID: 1})
+
+	store := storage.NewLocalStore(t.TempDir())
+	addDocument(t, db, store, "employees/1/contract", "%PDF-1.4")
+	if err := store.Delete(context.Background(), "employees/1/contract"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Write(db, store, io.Discard); err == nil {
+		t.Fatal("backup succeeded without the document's file")
+	}
+}
+
+// rewriteArchive copies a backup archive, passing each entry through edit.
+// Entries edit returns nil for are dropped.
+func rewriteArchive(t *testing.T, archive []byte, edit func(name string, data []byte) []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := tar.NewReader(gz)
+
+	var out bytes.Buffer
+	outGz := gzip.NewWriter(&out)
+	outTar := tar.NewWriter(outGz)
+	for {
+		header, err := in.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		edited := edit(header.Name, data)
+		if edited == nil {
+			continue
+		}
+		if err := writeEntry(outTar, header.Name, edited); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outTar.Close()
+	outGz.Close()
+	return out.Bytes()
+}
@@ -0,0 +1,336 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms-backend/database"
+	"hrms-backend/models"
+	"hrms-backend/storage"
+	"io"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// deferredValue is a foreign key written after all rows are loaded
+type deferredValue struct {
+	table  string
+	id     interface{}
+	column string
+	value  interface{}
+}
+
+// Restore loads an archive written by Write into an empty database, which may
+// use a different driver than the one the backup was taken from. IDs are
+// preserved. The schema is migrated to the latest version first; archives from
+// an older schema load into it, with columns they lack taking their defaults.
+// Everything happens in one transaction, so a corrupt archive loads nothing.
+// Document files are written to store as they are read; files written before
+// a restore fails are left behind and replaced by the next attempt.
+func Restore(db *gorm.DB, store storage.Store, r io.Reader) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator := database.NewMigrator(db)
+	if manifest.SchemaVersion > migrator.Latest() {
+		return nil, fmt.Errorf("archive is at schema version %d, newer than this build's %d", manifest.SchemaVersion, migrator.Latest())
+	}
+	if err := migrator.Up(); err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureEmpty(tx); err != nil {
+			return err
+		}
+
+		var deferred []deferredValue
+		loaded := map[string]bool{}
+		var files int64
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read archive: %w", err)
+			}
+
+			// Files follow the tables, so their documents rows are loaded by now
+			if key, ok := strings.CutPrefix(header.Name, filesDir); ok {
+				if err := restoreFile(tx, store, key, header.Size, archive); err != nil {
+					return err
+				}
+				files++
+				continue
+			}
+
+			entry, t, err := lookupEntry(manifest, header.Name)
+			if err != nil {
+				return err
+			}
+			values, err := loadTable(tx, t, entry, archive)
+			if err != nil {
+				return fmt.Errorf("failed to restore %s: %w", entry.Name, err)
+			}
+			deferred = append(deferred, values...)
+			loaded[entry.Name] = true
+		}
+
+		for _, entry := range manifest.Tables {
+			if !loaded[entry.Name] {
+				return fmt.Errorf("archive is missing %s", entry.File)
+			}
+		}
+		if files != manifest.Files.Count {
+			return fmt.Errorf("archive holds %d document files, manifest lists %d", files, manifest.Files.Count)
+		}
+
+		for _, value := range deferred {
+			err := tx.Table(value.table).Where("id = ?", value.id).UpdateColumn(value.column, value.value).Error
+			if err != nil {
+				return fmt.Errorf("failed to restore %s.%s: %w", value.table, value.column, err)
+			}
+		}
+
+		return resetSequences(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func readManifest(archive *tar.Reader) (*Manifest, error) {
+	header, err := archive.Next()
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	if header.Name != manifestName {
+		return nil, fmt.Errorf("not a backup archive: first entry is %q, want %s", header.Name, manifestName)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Format != Format {
+		return nil, fmt.Errorf("not a backup archive: format %q", manifest.Format)
+	}
+	if manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("archive format version %d is newer than this build supports (%d)", manifest.FormatVersion, FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// restoreFile writes the contents of the document stored under key, checking
+// them against the size and checksum in its restored row
+func restoreFile(tx *gorm.DB, store storage.Store, key string, size int64, r io.Reader) error {
+	var document models.Document
+	if err := tx.Where("storage_key = ?", key).First(&document).Error; err != nil {
+		return fmt.Errorf("archive file %s%s belongs to no document", filesDir, key)
+	}
+	if size != document.Size {
+		return fmt.Errorf("document file %s is %d bytes, its document lists %d", key, size, document.Size)
+	}
+
+	hash := sha256.New()
+	if err := store.Put(context.Background(), key, io.TeeReader(r, hash), size, document.ContentType); err != nil {
+		return fmt.Errorf("failed to store document file %s: %w", key, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != document.Checksum {
+		return fmt.Errorf("document file %s does not match its checksum; the archive is corrupt", key)
+	}
+	return nil
+}
+
+// ensureEmpty refuses to restore over existing HR data, including soft-deleted rows
+func ensureEmpty(tx *gorm.DB) error {
+	for _, t := range tables {
+		var count int64
+		if err := tx.Unscoped().Model(t.model).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("restore needs an empty database, but %s has %d rows", t.name, count)
+		}
+	}
+	return nil
+}
+
+func lookupEntry(manifest *Manifest, file string) (TableManifest, table, error) {
+	for _, entry := range manifest.Tables {
+		if entry.File != file {
+			continue
+		}
+		for _, t := range tables {
+			if t.name == entry.Name {
+				return entry, t, nil
+			}
+		}
+		return TableManifest{}, table{}, fmt.Errorf("archive contains unknown table %q", entry.Name)
+	}
+	return TableManifest{}, table{}, fmt.Errorf("archive entry %q is not listed in the manifest", file)
+}
+
+// loadTable inserts the rows of one JSON-lines file, verifying its row count
+// and checksum. Deferred columns are inserted as NULL and returned for the
+// second pass.
+func loadTable(tx *gorm.DB, t table, entry TableManifest, r io.Reader) ([]deferredValue, error) {
+	s, err := parseSchema(tx, t.model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only write the columns the archive has; newer columns keep their defaults
+	archived := map[string]bool{}
+	for _, column := range entry.Columns {
+		archived[column] = true
+	}
+	var fields []*schema.Field
+	var columns []string
+	for _, field := range columnFields(s) {
+		if archived[field.DBName] {
+			fields = append(fields, field)
+			columns = append(columns, field.DBName)
+		}
+	}
+	isDeferred := map[string]bool{}
+	for _, column := range t.deferred {
+		isDeferred[column] = true
+	}
+
+	hash := sha256.New()
+	reader := bufio.NewReader(io.TeeReader(r, hash))
+	ctx := context.Background()
+	insert := tx.Select(columns).Omit(clause.Associations)
+
+	var deferred []deferredValue
+	var rows int64
+	batch := reflect.MakeSlice(reflect.SliceOf(s.ModelType), 0, batchSize)
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		rowsPtr := reflect.New(batch.Type())
+		rowsPtr.Elem().Set(batch)
+		err := insert.Create(rowsPtr.Interface()).Error
+		batch = batch.Slice(0, 0)
+		return err
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			row := reflect.New(s.ModelType).Elem()
+			if err := decodeRow(ctx, line, fields, row); err != nil {
+				return nil, fmt.Errorf("row %d: %w", rows+1, err)
+			}
+
+			id, _ := s.PrioritizedPrimaryField.ValueOf(ctx, row)
+			for _, field := range fields {
+				if !isDeferred[field.DBName] {
+					continue
+				}
+				if value, zero := field.ValueOf(ctx, row); !zero {
+					deferred = append(deferred, deferredValue{table: t.name, id: id, column: field.DBName, value: value})
+					if err := field.Set(ctx, row, nil); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			batch = reflect.Append(batch, row)
+			rows++
+			if batch.Len() == batchSize {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if rows != entry.Rows {
+		return nil, fmt.Errorf("found %d rows, manifest lists %d", rows, entry.Rows)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != entry.SHA256 {
+		return nil, errors.New("checksum mismatch; the archive is corrupt")
+	}
+	return deferred, nil
+}
+
+// decodeRow sets the fields of row from one JSON object keyed by column name
+func decodeRow(ctx context.Context, line []byte, fields []*schema.Field, row reflect.Value) error {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(line, &record); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		raw, ok := record[field.DBName]
+		if !ok {
+			continue
+		}
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return fmt.Errorf("column %s: %w", field.DBName, err)
+		}
+		if err := field.Set(ctx, row, value.Elem().Interface()); err != nil {
+			return fmt.Errorf("column %s: %w", field.DBName, err)
+		}
+	}
+	return nil
+}
+
+// resetSequences moves Postgres ID sequences past the restored IDs, skipping
+// tables without a serial id. MySQL and SQLite advance their counters on
+// explicit inserts by themselves.
+func resetSequences(tx *gorm.DB) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	for _, t := range tables {
+		s, err := parseSchema(tx, t.model)
+		if err != nil {
+			return err
+		}
+		if !serialID(s) {
+			continue
+		}
+		err = tx.Exec(fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE((SELECT MAX(id) FROM %[1]s), 0) + 1, false)",
+			t.name)).Error
+		if err != nil {
+			return fmt.Errorf("failed to reset the %s sequence: %w", t.name, err)
+		}
+	}
+	return nil
+}
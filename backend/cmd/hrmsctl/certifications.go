@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// certificationWarnings summarises a certifications warn run
+type certificationWarnings struct {
+	Date   time.Time `json:"date"`
+	Warned int       `json:"warned"`
+}
+
+func (a *app) warnCertifications(args []string) error {
+	cmd := newCommand("certifications warn")
+	date := cmd.String("date", "", "warn of certifications expiring as of this date, as YYYY-MM-DD (default today)")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	day := time.Now()
+	if *date != "" {
+		parsed, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			return fmt.Errorf("-date must be a YYYY-MM-DD date: %w", err)
+		}
+		day = parsed
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	summary := certificationWarnings{Date: day}
+	var err error
+	summary.Warned, err = a.certifications.WarnExpiring(day)
+	if printErr := a.print(cmd, []certificationWarnings{summary}); printErr != nil {
+		return printErr
+	}
+	return err
+}
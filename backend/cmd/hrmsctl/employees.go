@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hrms-backend/services"
+	"io"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importColumns are the CSV header names, matching the API's JSON fields.
+// department takes a department ID or name.
+var importColumns = []string{
+	"employeeCode", "firstName", "lastName", "email", "phone", "address", "dateOfBirth",
+	"hireDate", "salary", "position", "status", "department", "managerId",
+}
+
+// requiredImportColumns leaves out employeeCode, which is generated when
+// EMPLOYEE_CODE_PATTERN is set; without a pattern the import fails on
+// employees without one
+var requiredImportColumns = []string{"firstName", "lastName", "email", "hireDate", "position", "department"}
+
+// employeeRow is an imported employee as printed
+type employeeRow struct {
+	ID           uint      `json:"id"`
+	EmployeeCode string    `json:"employeeCode"`
+	FirstName    string    `json:"firstName"`
+	LastName     string    `json:"lastName"`
+	Email        string    `json:"email"`
+	Position     string    `json:"position"`
+	DepartmentID uint      `json:"departmentId"`
+	HireDate     time.Time `json:"hireDate"`
+	Status       string    `json:"status"`
+}
+
+func newEmployeeRow(id uint, input services.EmployeeInput) employeeRow {
+	return employeeRow{
+		ID:           id,
+		EmployeeCode: input.EmployeeCode,
+		FirstName:    input.FirstName,
+		LastName:     input.LastName,
+		Email:        input.Email,
+		Position:     input.Position,
+		DepartmentID: input.DepartmentID,
+		HireDate:     input.HireDate,
+		Status:       input.Status,
+	}
+}
+
+func (a *app) importEmployees(args []string) error {
+	cmd := newCommand("employees import")
+	dryRun := cmd.Bool("dry-run", false, "check the file without importing it")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if cmd.NArg() != 1 {
+		return errors.New("employees import requires a CSV file")
+	}
+
+	var in io.Reader = a.stdin
+	if name := cmd.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+
+	departments, err := a.departmentIDs()
+	if err != nil {
+		return err
+	}
+	inputs, err := readEmployees(in, departments)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]employeeRow, len(inputs))
+	if *dryRun {
+		for i, input := range inputs {
+			rows[i] = newEmployeeRow(0, input)
+		}
+		fmt.Fprintf(a.stderr, "%d employees are ready to import\n", len(inputs))
+		return a.print(cmd, rows)
+	}
+
+	created, err := a.employees.Import(inputs)
+	if err != nil {
+		return fmt.Errorf("nothing was imported: %w", err)
+	}
+	for i, employee := range created {
+		rows[i] = newEmployeeRow(employee.ID, inputs[i])
+		rows[i].EmployeeCode = employee.EmployeeCode
+		rows[i].Status = employee.Status
+	}
+	fmt.Fprintf(a.stderr, "Imported %d employees\n", len(created))
+	return a.print(cmd, rows)
+}
+
+// departmentIDs maps department IDs and lower-case names to IDs
+func (a *app) departmentIDs() (map[string]uint, error) {
+	departments, err := a.departments.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, 2*len(departments))
+	for _, department := range departments {
+		ids[strconv.FormatUint(uint64(department.ID), 10)] = department.ID
+		ids[strings.ToLower(department.Name)] = department.ID
+	}
+	return ids, nil
+}
+
+// readEmployees parses and checks every row before anything is written, so a
+// bad file reports all of its problems at once
+func readEmployees(in io.Reader, departments map[string]uint) ([]services.EmployeeInput, error) {
+	reader := csv.NewReader(in)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for name := range index {
+		if !containsString(importColumns, name) {
+			return nil, fmt.Errorf("unknown column %q; columns are %s", name, strings.Join(importColumns, ", "))
+		}
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("missing required column %q", name)
+		}
+	}
+
+	var inputs []services.EmployeeInput
+	var problems []error
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		input, rowProblems := parseEmployee(value, departments)
+		for _, problem := range rowProblems {
+			problems = append(problems, fmt.Errorf("line %d: %s", line, problem))
+		}
+		inputs = append(inputs, input)
+	}
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
+	}
+	if len(inputs) == 0 {
+		return nil, errors.New("the file has no employees")
+	}
+	return inputs, nil
+}
+
+// parseEmployee applies the API's rules for new employees to one row
+func parseEmployee(value func(name string) string, departments map[string]uint) (services.EmployeeInput, []string) {
+	var problems []string
+	input := services.EmployeeInput{
+		EmployeeCode: value("employeeCode"),
+		FirstName:    value("firstName"),
+		LastName:     value("lastName"),
+		Email:        value("email"),
+		Phone:        value("phone"),
+		Address:      value("address"),
+		Position:     value("position"),
+		Status:       value("status"),
+	}
+
+	for _, name := range requiredImportColumns {
+		if value(name) == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+	if input.Email != "" {
+		if _, err := mail.ParseAddress(input.Email); err != nil {
+			problems = append(problems, fmt.Sprintf("email %q is not valid", input.Email))
+		}
+	}
+	if input.Status != "" && !containsString([]string{"active", "inactive"}, input.Status) {
+		problems = append(problems, "status must be active or inactive")
+	}
+
+	if hireDate := value("hireDate"); hireDate != "" {
+		parsed, err := time.Parse("2006-01-02", hireDate)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("hireDate %q is not a YYYY-MM-DD date", hireDate))
+		}
+		input.HireDate = parsed
+	}
+	if dateOfBirth := value("dateOfBirth"); dateOfBirth != "" {
+		parsed, err := time.Parse("2006-01-02", dateOfBirth)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("dateOfBirth %q is not a YYYY-MM-DD date", dateOfBirth))
+		} else if !input.HireDate.IsZero() && !parsed.Before(input.HireDate) {
+			problems = append(problems, "dateOfBirth must be before hireDate")
+		}
+		input.DateOfBirth = &parsed
+	}
+	if salary := value("salary"); salary != "" {
+		parsed, err := strconv.ParseFloat(salary, 64)
+		if err != nil || parsed < 0 {
+			problems = append(problems, fmt.Sprintf("salary %q is not a non-negative number", salary))
+		}
+		input.Salary = parsed
+	}
+	if department := value("department"); department != "" {
+		id, ok := departments[strings.ToLower(department)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("department %q does not exist", department))
+		}
+		input.DepartmentID = id
+	}
+	if managerID := value("managerId"); managerID != "" {
+		parsed, err := strconv.ParseUint(managerID, 10, 0)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("managerId %q is not an employee ID", managerID))
+		}
+		id := uint(parsed)
+		input.ManagerID = &id
+	}
+	return input, problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// codeMismatchRow is an employee whose code does not fit the code pattern
+type codeMismatchRow struct {
+	ID           uint   `json:"id"`
+	EmployeeCode string `json:"employeeCode"`
+	Name         string `json:"name"`
+}
+
+// checkCodes lists the employees whose codes do not fit EMPLOYEE_CODE_PATTERN
+func (a *app) checkCodes(args []string) error {
+	cmd := newCommand("employees check-codes")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if a.employees.CodePattern() == "" {
+		return errors.New("EMPLOYEE_CODE_PATTERN is not set")
+	}
+
+	mismatches, err := a.employees.CodeMismatches()
+	if err != nil {
+		return err
+	}
+	rows := make([]codeMismatchRow, len(mismatches))
+	for i, mismatch := range mismatches {
+		rows[i] = codeMismatchRow{ID: mismatch.ID, EmployeeCode: mismatch.EmployeeCode, Name: mismatch.Name}
+	}
+	fmt.Fprintf(a.stderr, "%d employees have codes that do not fit %s\n", len(mismatches), a.employees.CodePattern())
+	return a.print(cmd, rows)
+}
+
+// appliedChanges summarises an employees apply-changes run
+type appliedChanges struct {
+	Date       time.Time `json:"date"`
+	Employees  int       `json:"employees"`
+	Terminated int       `json:"terminated"`
+}
+
+func (a *app) applyChanges(args []string) error {
+	cmd := newCommand("employees apply-changes")
+	date := cmd.String("date", "", "apply changes due by this date, as YYYY-MM-DD (default today)")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	due := time.Now()
+	if *date != "" {
+		parsed, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			return fmt.Errorf("-date must be a YYYY-MM-DD date: %w", err)
+		}
+		due = parsed
+	}
+	due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+
+	summary := appliedChanges{Date: due}
+	var err error
+	if summary.Employees, err = a.employment.ApplyDue(due); err == nil {
+		summary.Terminated, err = a.termination.CompleteDue(due)
+	}
+	if printErr := a.print(cmd, []appliedChanges{summary}); printErr != nil {
+		return printErr
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var testDepartments = map[string]uint{"2": 2, "engineering": 2}
+
+func TestReadEmployees(t *testing.T) {
+	inputs, err := readEmployees(strings.NewReader(`employeeCode,firstName,lastName,email,hireDate,salary,position,department,managerId
+E1,Ann,Lee,ann@example.com,2024-01-02,60000,Developer,Engineering,2
+E2,Bob,Ray,bob@example.com,2024-02-01,,Tester,2,
+`), testDepartments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("got %d employees, want 2", len(inputs))
+	}
+	ann := inputs[0]
+	if ann.DepartmentID != 2 || ann.Salary != 60000 || ann.ManagerID == nil || *ann.ManagerID != 2 {
+		t.Errorf("unexpected first employee %+v", ann)
+	}
+	if inputs[1].ManagerID != nil || inputs[1].HireDate.Format("2006-01-02") != "2024-02-01" {
+		t.Errorf("unexpected second employee %+v", inputs[1])
+	}
+}
+
+func TestReadEmployeesReportsEveryProblem(t *testing.T) {
+	_, err := readEmployees(strings.NewReader(`employeeCode,firstName,lastName,email,hireDate,position,department
+E1,Ann,Lee,not-an-email,2024-01-02,Developer,2
+E2,,Ray,bob@example.com,01/02/2024,Tester,Sales
+`), testDepartments)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`line 2: email "not-an-email" is not valid`,
+		"line 3: firstName is required",
+		`line 3: hireDate "01/02/2024" is not a YYYY-MM-DD date`,
+		`line 3: department "Sales" does not exist`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	_, err = readEmployees(strings.NewReader("employeeCode,nickname\n"), testDepartments)
+	if err == nil || !strings.Contains(err.Error(), `unknown column "nickname"`) {
+		t.Errorf("unknown column: got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Command hrmsctl performs administrative tasks against the HRMS database
+// through the same services as the API: managing accounts, running payroll,
+// importing employees and printing reports. It reads the server's
+// configuration, so it works wherever the server's config file, environment
+// or flags are available.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"hrms-backend/config"
+	"hrms-backend/database"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
+	"hrms-backend/utils"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `Usage: hrmsctl [config flags] COMMAND [flags] [arguments]
+
+Configuration is read like the server's: -config FILE or HRMS_CONFIG, then
+the environment and .env, then config flags given before the command.
+Every command accepts -o table (default) or -o json.
+
+Commands:
+  users list
+  users create -email E -first NAME -last NAME [-role R] [-employee ID] [-password-stdin]
+                                     create an account; without -password-stdin a
+                                     random password is generated and printed to stderr
+  users promote EMAIL ROLE           change an account's role (admin, hr, manager, employee)
+  users reset-password EMAIL [-password-stdin]
+  users deactivate EMAIL             block logins to an account
+  users activate EMAIL
+  payroll run -period YYYY-MM [-department ID]
+                                     create draft payroll records for every active
+                                     employee without one for the period
+  employees import FILE [-dry-run]   add employees from a CSV file ("-" reads stdin);
+                                     blank codes are generated from EMPLOYEE_CODE_PATTERN
+  employees apply-changes [-date YYYY-MM-DD]
+                                     apply scheduled employment changes and complete
+                                     terminations due by the date (default today); the
+                                     server also does this hourly
+  employees check-codes              employees whose codes do not fit EMPLOYEE_CODE_PATTERN
+  certifications warn [-date YYYY-MM-DD]
+                                     warn holders and managers of certifications expiring
+                                     within CERTIFICATION_WARNING_DAYS of the date (default
+                                     today); the server also does this hourly
+  reports headcount                  departments with their manager and headcount
+  reports payroll [-period YYYY-MM] [-department ID]
+  reports attendance -department ID
+`
+
+// operator is the actor hrmsctl acts as. Anyone who can run it already has
+// the database credentials, so it acts with admin rights.
+var operator = services.Actor{Role: "admin"}
+
+// app holds the services commands run against and where they write
+type app struct {
+	users          *services.UserService
+	employees      *services.EmployeeService
+	departments    *services.DepartmentService
+	attendance     *services.AttendanceService
+	payroll        *services.PayrollService
+	employment     *services.EmploymentService
+	termination    *services.TerminationService
+	certifications *services.CertificationService
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func newApp(db *gorm.DB, cfg *config.Config) *app {
+	repos := repositories.NewGormRepositories(db)
+	uow := repositories.NewGormUnitOfWork(db, database.Retryable(db))
+	return &app{
+		users:          services.NewUserService(repos),
+		employees:      services.NewEmployeeService(repos, uow, utils.MustParseCodePattern(cfg.EmployeeCodePattern)),
+		departments:    services.NewDepartmentService(repos, uow),
+		attendance:     services.NewAttendanceService(repos),
+		payroll:        services.NewPayrollService(repos, uow),
+		employment:     services.NewEmploymentService(repos, uow),
+		termination:    services.NewTerminationService(repos, uow, cfg.AnnualLeaveDays),
+		certifications: services.NewCertificationService(repos, uow, cfg.ExpiryWarningDays),
+		stdin:          os.Stdin,
+		stdout:         os.Stdout,
+		stderr:         os.Stderr,
+	}
+}
+
+func main() {
+	// A missing .env is fine; the environment may hold the configuration
+	_ = godotenv.Load()
+
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Print(usage)
+		return
+	}
+	if err != nil {
+		fail(fmt.Errorf("invalid configuration:\n%w", err))
+	}
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Print(usage)
+		return
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		fail(fmt.Errorf("failed to connect to database: %w", err))
+	}
+	// Errors are reported by the commands; GORM's log would corrupt JSON output
+	db.Logger = logger.Discard
+	if err := checkSchema(db); err != nil {
+		fail(err)
+	}
+
+	if err := newApp(db, cfg).run(args); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "hrmsctl:", err)
+	os.Exit(1)
+}
+
+// checkSchema refuses to work on a database the server has not migrated, since
+// the services expect the current schema
+func checkSchema(db *gorm.DB) error {
+	migrator := database.NewMigrator(db)
+	version, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if version != migrator.Latest() {
+		return fmt.Errorf("database schema is at version %d, want %d; run \"hrms-backend migrate up\" first",
+			version, migrator.Latest())
+	}
+	return nil
+}
+
+// commands maps "group action" to the command that handles it
+func (a *app) commands() map[string]func(args []string) error {
+	return map[string]func(args []string) error{
+		"users list":              a.listUsers,
+		"users create":            a.createUser,
+		"users promote":           a.promoteUser,
+		"users reset-password":    a.resetPassword,
+		"users deactivate":        func(args []string) error { return a.setActive(args, false) },
+		"users activate":          func(args []string) error { return a.setActive(args, true) },
+		"payroll run":             a.runPayroll,
+		"employees import":        a.importEmployees,
+		"employees apply-changes": a.applyChanges,
+		"employees check-codes":   a.checkCodes,
+		"certifications warn":     a.warnCertifications,
+		"reports headcount":       a.headcountReport,
+		"reports payroll":         a.payrollReport,
+		"reports attendance":      a.attendanceReport,
+	}
+}
+
+// run executes the command named by the first two arguments
+func (a *app) run(args []string) error {
+	commands := a.commands()
+	if len(args) >= 2 {
+		if command, ok := commands[args[0]+" "+args[1]]; ok {
+			return command(args[2:])
+		}
+	}
+
+	var names []string
+	for name := range commands {
+		if strings.HasPrefix(name, args[0]+" ") {
+			names = append(names, strings.TrimPrefix(name, args[0]+" "))
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("unknown command %q; run \"hrmsctl help\" for usage", args[0])
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%s requires one of: %s", args[0], strings.Join(names, ", "))
+}
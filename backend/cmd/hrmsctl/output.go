@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// command is the flag set of one command together with the shared -o flag
+type command struct {
+	*flag.FlagSet
+	format *string
+	args   []string
+}
+
+func newCommand(name string) *command {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := flags.String("o", "table", "output format: table or json")
+	return &command{FlagSet: flags, format: format}
+}
+
+// parse parses args and checks the output format before the command changes
+// anything. Flags may follow the arguments, as in "users promote EMAIL hr -o json".
+func (c *command) parse(args []string) error {
+	for {
+		if err := c.Parse(args); err != nil {
+			return err
+		}
+		args = c.FlagSet.Args()
+		if len(args) == 0 {
+			break
+		}
+		c.args = append(c.args, args[0])
+		args = args[1:]
+	}
+	if *c.format != "table" && *c.format != "json" {
+		return fmt.Errorf("unknown output format %q; use table or json", *c.format)
+	}
+	return nil
+}
+
+// NArg is the number of arguments left after the flags
+func (c *command) NArg() int { return len(c.args) }
+
+// Arg returns the i'th argument left after the flags, or "" if there is none
+func (c *command) Arg(i int) string {
+	if i < 0 || i >= len(c.args) {
+		return ""
+	}
+	return c.args[i]
+}
+
+// print writes records, a slice of structs, as JSON or as a table whose
+// columns are the structs' JSON field names
+func (a *app) print(c *command, records interface{}) error {
+	if *c.format == "json" {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+
+	rows := reflect.ValueOf(records)
+	rowType := rows.Type().Elem()
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+
+	headers := make([]string, rowType.NumField())
+	for i := range headers {
+		name, _, _ := strings.Cut(rowType.Field(i).Tag.Get("json"), ",")
+		headers[i] = strings.ToUpper(name)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		cells := make([]string, row.NumField())
+		for j := range cells {
+			cells[j] = cell(row.Field(j))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// cell formats one value for a table: dates without a time of day print as
+// dates, money with cents and missing values as blanks
+func cell(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch v := value.Interface().(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04")
+	case float64:
+		return fmt.Sprintf("%.2f", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
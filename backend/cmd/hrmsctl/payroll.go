@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"time"
+)
+
+// payrollRow is a payroll record as printed
+type payrollRow struct {
+	ID          uint      `json:"id"`
+	EmployeeID  uint      `json:"employeeId"`
+	Employee    string    `json:"employee"`
+	Department  string    `json:"department"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	BasicSalary float64   `json:"basicSalary"`
+	GrossPay    float64   `json:"grossPay"`
+	Tax         float64   `json:"tax"`
+	NetPay      float64   `json:"netPay"`
+	Status      string    `json:"status"`
+}
+
+func newPayrollRow(record models.PayrollRecord) payrollRow {
+	return payrollRow{
+		ID:          record.ID,
+		EmployeeID:  record.EmployeeID,
+		Employee:    record.Employee.FirstName + " " + record.Employee.LastName,
+		Department:  record.Employee.Department.Name,
+		PeriodStart: record.PayPeriodStart,
+		PeriodEnd:   record.PayPeriodEnd,
+		BasicSalary: record.BasicSalary,
+		GrossPay:    record.GrossPay,
+		Tax:         record.Tax,
+		NetPay:      record.NetPay,
+		Status:      record.Status,
+	}
+}
+
+// parsePeriod reads a YYYY-MM pay period
+func parsePeriod(period string) (year, month int, err error) {
+	parsed, err := time.Parse("2006-01", period)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid period %q; use YYYY-MM", period)
+	}
+	return parsed.Year(), int(parsed.Month()), nil
+}
+
+func (a *app) runPayroll(args []string) error {
+	cmd := newCommand("payroll run")
+	period := cmd.String("period", "", "pay period, YYYY-MM")
+	departmentID := cmd.Uint("department", 0, "only run for this department")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *period == "" {
+		return errors.New("payroll run requires -period YYYY-MM")
+	}
+	year, month, err := parsePeriod(*period)
+	if err != nil {
+		return err
+	}
+
+	run, err := a.payroll.RunPeriod(year, month, *departmentID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "Created %d draft payroll records for %s; skipped %d employees who already had one\n",
+		len(run.Created), *period, run.Skipped)
+
+	rows := make([]payrollRow, len(run.Created))
+	for i, record := range run.Created {
+		rows[i] = newPayrollRow(record)
+	}
+	return a.print(cmd, rows)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// headcountRow is a department as printed by the headcount report
+type headcountRow struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Manager   string `json:"manager"`
+	Employees int    `json:"employees"`
+}
+
+// attendanceRow is one day of attendance as printed
+type attendanceRow struct {
+	Employee     string     `json:"employee"`
+	Date         time.Time  `json:"date"`
+	CheckIn      *time.Time `json:"checkIn"`
+	CheckOut     *time.Time `json:"checkOut"`
+	WorkingHours float64    `json:"workingHours"`
+	Status       string     `json:"status"`
+}
+
+func (a *app) headcountReport(args []string) error {
+	cmd := newCommand("reports headcount")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	departments, err := a.departments.List(nil)
+	if err != nil {
+		return err
+	}
+	rows := make([]headcountRow, len(departments))
+	for i, department := range departments {
+		manager := ""
+		if department.ManagerFirstName != nil && department.ManagerLastName != nil {
+			manager = *department.ManagerFirstName + " " + *department.ManagerLastName
+		}
+		rows[i] = headcountRow{
+			ID:        department.ID,
+			Name:      department.Name,
+			Manager:   manager,
+			Employees: department.EmployeeCount,
+		}
+	}
+	return a.print(cmd, rows)
+}
+
+func (a *app) payrollReport(args []string) error {
+	cmd := newCommand("reports payroll")
+	period := cmd.String("period", "", "pay period, YYYY-MM (default every period)")
+	departmentID := cmd.Uint("department", 0, "only this department")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	var year, month int
+	if *period != "" {
+		var err error
+		if year, month, err = parsePeriod(*period); err != nil {
+			return err
+		}
+	}
+
+	records, err := a.payroll.Report(year, month, *departmentID)
+	if err != nil {
+		return err
+	}
+	rows := make([]payrollRow, len(records))
+	for i, record := range records {
+		rows[i] = newPayrollRow(record)
+	}
+	return a.print(cmd, rows)
+}
+
+func (a *app) attendanceReport(args []string) error {
+	cmd := newCommand("reports attendance")
+	departmentID := cmd.Uint("department", 0, "department to report on")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *departmentID == 0 {
+		return errors.New("reports attendance requires -department ID")
+	}
+
+	report, err := a.attendance.Report(*departmentID)
+	if err != nil {
+		return err
+	}
+	rows := make([]attendanceRow, len(report))
+	for i, row := range report {
+		rows[i] = attendanceRow{
+			Employee:     row.FirstName + " " + row.LastName,
+			Date:         row.Date,
+			CheckIn:      row.CheckIn,
+			CheckOut:     row.CheckOut,
+			WorkingHours: row.WorkingHours,
+			Status:       row.Status,
+		}
+	}
+	return a.print(cmd, rows)
+}
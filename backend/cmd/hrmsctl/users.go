@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"slices"
+	"strings"
+)
+
+var roles = []string{"admin", "hr", "manager", "employee"}
+
+// userRow is an account as printed
+type userRow struct {
+	ID         uint   `json:"id"`
+	Email      string `json:"email"`
+	FirstName  string `json:"firstName"`
+	LastName   string `json:"lastName"`
+	Role       string `json:"role"`
+	Active     bool   `json:"active"`
+	EmployeeID *uint  `json:"employeeId"`
+}
+
+func newUserRow(user *models.User) userRow {
+	return userRow{
+		ID:         user.ID,
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Role:       user.Role,
+		Active:     user.IsActive,
+		EmployeeID: user.EmployeeID,
+	}
+}
+
+func checkRole(role string) error {
+	if !slices.Contains(roles, role) {
+		return fmt.Errorf("unknown role %q; use one of %s", role, strings.Join(roles, ", "))
+	}
+	return nil
+}
+
+func (a *app) listUsers(args []string) error {
+	cmd := newCommand("users list")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	users, err := a.users.List()
+	if err != nil {
+		return err
+	}
+	rows := make([]userRow, len(users))
+	for i := range users {
+		rows[i] = newUserRow(&users[i])
+	}
+	return a.print(cmd, rows)
+}
+
+func (a *app) createUser(args []string) error {
+	cmd := newCommand("users create")
+	email := cmd.String("email", "", "login email")
+	firstName := cmd.String("first", "", "first name")
+	lastName := cmd.String("last", "", "last name")
+	role := cmd.String("role", "employee", "admin, hr, manager or employee")
+	employeeID := cmd.Uint("employee", 0, "ID of the employee record to link")
+	passwordStdin := cmd.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *firstName == "" || *lastName == "" {
+		return errors.New("users create requires -email, -first and -last")
+	}
+	if err := checkRole(*role); err != nil {
+		return err
+	}
+
+	if _, err := a.users.GetByEmail(*email); err == nil {
+		return fmt.Errorf("an account with email %s already exists", *email)
+	} else if !errors.Is(err, services.ErrNotFound) {
+		return err
+	}
+
+	password, generated, err := a.password(*passwordStdin)
+	if err != nil {
+		return err
+	}
+	input := services.NewUserInput{
+		Email:     *email,
+		Password:  password,
+		FirstName: *firstName,
+		LastName:  *lastName,
+		Role:      *role,
+	}
+	if *employeeID != 0 {
+		id := *employeeID
+		input.EmployeeID = &id
+	}
+
+	user, err := a.users.Create(operator, input)
+	if err != nil {
+		return err
+	}
+	if generated {
+		fmt.Fprintf(a.stderr, "Generated password for %s: %s\n", user.Email, password)
+	}
+	return a.print(cmd, []userRow{newUserRow(user)})
+}
+
+func (a *app) promoteUser(args []string) error {
+	cmd := newCommand("users promote")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if cmd.NArg() != 2 {
+		return errors.New("users promote requires EMAIL and ROLE")
+	}
+	role := cmd.Arg(1)
+	if err := checkRole(role); err != nil {
+		return err
+	}
+
+	user, err := a.updateUser(cmd.Arg(0), func(input *services.UserInput) { input.Role = role })
+	if err != nil {
+		return err
+	}
+	return a.print(cmd, []userRow{newUserRow(user)})
+}
+
+func (a *app) resetPassword(args []string) error {
+	cmd := newCommand("users reset-password")
+	passwordStdin := cmd.Bool("password-stdin", false, "read the password from the first line of stdin")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if cmd.NArg() != 1 {
+		return errors.New("users reset-password requires EMAIL")
+	}
+
+	password, generated, err := a.password(*passwordStdin)
+	if err != nil {
+		return err
+	}
+	user, err := a.updateUser(cmd.Arg(0), func(input *services.UserInput) { input.Password = password })
+	if err != nil {
+		return err
+	}
+	if generated {
+		fmt.Fprintf(a.stderr, "Generated password for %s: %s\n", user.Email, password)
+	}
+	return a.print(cmd, []userRow{newUserRow(user)})
+}
+
+func (a *app) setActive(args []string, active bool) error {
+	name := "users deactivate"
+	if active {
+		name = "users activate"
+	}
+	cmd := newCommand(name)
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if cmd.NArg() != 1 {
+		return fmt.Errorf("%s requires EMAIL", name)
+	}
+
+	user, err := a.updateUser(cmd.Arg(0), func(input *services.UserInput) { input.IsActive = active })
+	if err != nil {
+		return err
+	}
+	return a.print(cmd, []userRow{newUserRow(user)})
+}
+
+// updateUser applies change to the account's current values
+func (a *app) updateUser(email string, change func(input *services.UserInput)) (*models.User, error) {
+	user, err := a.users.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	input := services.UserInput{
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Role:       user.Role,
+		IsActive:   user.IsActive,
+		EmployeeID: user.EmployeeID,
+	}
+	change(&input)
+	if err := a.users.Update(operator, user, input); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// password reads a password from stdin, so it stays out of the shell history
+// and process list, or generates one. It applies the API's length rules.
+func (a *app) password(fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		random := make([]byte, 18)
+		if _, err := rand.Read(random); err != nil {
+			return "", false, err
+		}
+		return base64.RawURLEncoding.EncodeToString(random), true, nil
+	}
+
+	line, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", false, errors.New("no password on stdin")
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if len(password) < 8 || len(password) > 72 {
+		return "", false, errors.New("password must be 8 to 72 characters")
+	}
+	return password, false, nil
+}
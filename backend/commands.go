@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hrms-backend/backup"
+	"hrms-backend/config"
+	"hrms-backend/database"
+	"hrms-backend/seeds"
+	"hrms-backend/storage"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: hrms-backend [flags] [command]
+
+Without a command the API server is started. Settings are read from the
+config file named by -config or HRMS_CONFIG, then the environment, then
+flags such as -port or -db-host; run "hrms-backend -h" to list the flags.
+
+Commands:
+  migrate up              apply all pending migrations
+  migrate down [-steps N] roll back the last N migrations (default 1)
+  migrate to VERSION      migrate up or down to VERSION (0 rolls back everything)
+  migrate status          list migrations and when they were applied
+  seed minimal            create one admin from SEED_ADMIN_EMAIL and SEED_ADMIN_PASSWORD
+  seed demo               create the demo departments and accounts (well-known passwords)
+  seed load [flags]       generate a large data set, deterministic for a given seed:
+      -employees N        number of employees (default 2000)
+      -months N           months of attendance, leave and payroll history (default 12)
+      -seed N             random seed (default 1)
+      -until YYYY-MM-DD   last day of history (default today)
+      -password P         give every generated employee a login with this password
+  backup [-o FILE]        write a compressed archive of all HR data and document
+                          files (default hrms-backup-<timestamp>.tar.gz)
+  restore FILE            load a backup archive into an empty database and its
+                          document files into the document store
+`
+
+// runCommand runs the administrative subcommand named by args
+func runCommand(db *gorm.DB, cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(db, args[1:])
+	case "seed":
+		return runSeed(db, cfg, args[1:])
+	case "backup":
+		return runBackup(db, cfg, args[1:])
+	case "restore":
+		return runRestore(db, cfg, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(db *gorm.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate requires a subcommand\n\n%s", usage)
+	}
+	migrator := database.NewMigrator(db)
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to roll back")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		return migrator.Down(*steps)
+
+	case "to":
+		if len(args) != 2 {
+			return fmt.Errorf("migrate to requires a version\n\n%s", usage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.To(uint(version))
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q\n\n%s", args[0], usage)
+	}
+}
+
+func runSeed(db *gorm.DB, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("seed requires a profile (%s)\n\n%s", strings.Join(seeds.Profiles(), ", "), usage)
+	}
+	profile := args[0]
+	if profile == "demo" && cfg.GinMode == gin.ReleaseMode {
+		return fmt.Errorf("refusing to create demo accounts with well-known passwords in %s mode", gin.ReleaseMode)
+	}
+
+	opts := seeds.Options{
+		AdminEmail:    cfg.SeedAdminEmail,
+		AdminPassword: cfg.SeedAdminPassword,
+	}
+	flags := flag.NewFlagSet("seed "+profile, flag.ContinueOnError)
+	flags.IntVar(&opts.Employees, "employees", 2000, "number of employees")
+	flags.IntVar(&opts.Months, "months", 12, "months of history")
+	flags.Int64Var(&opts.Seed, "seed", 1, "random seed")
+	until := flags.String("until", "", "last day of history, YYYY-MM-DD")
+	flags.StringVar(&opts.Password, "password", "", "password for every generated employee's login")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *until != "" {
+		parsed, err := time.Parse("2006-01-02", *until)
+		if err != nil {
+			return fmt.Errorf("invalid -until date %q", *until)
+		}
+		opts.Until = parsed
+	}
+
+	// Seeding needs the current schema
+	if err := database.Migrate(db); err != nil {
+		return err
+	}
+	if err := seeds.Run(db, profile, opts); err != nil {
+		return fmt.Errorf("seeding %s failed: %w", profile, err)
+	}
+	log.Printf("Seeded the %s profile", profile)
+	return nil
+}
+
+func runBackup(db *gorm.DB, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	output := flags.String("o", "", "archive to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		*output = fmt.Sprintf("hrms-backup-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	// Write next to the destination and rename, so a failed backup never
+	// leaves a truncated archive behind
+	file, err := os.CreateTemp(filepath.Dir(*output), ".hrms-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	manifest, err := backup.Write(db, storage.New(cfg), file)
+	if err != nil {
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), *output); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote %s (schema version %d)", *output, manifest.SchemaVersion)
+	for _, table := range manifest.Tables {
+		log.Printf("  %-16s %d rows", table.Name, table.Rows)
+	}
+	log.Printf("  %d document files, %d bytes", manifest.Files.Count, manifest.Files.Bytes)
+	return nil
+}
+
+func runRestore(db *gorm.DB, cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("restore requires an archive\n\n%s", usage)
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	manifest, err := backup.Restore(db, storage.New(cfg), file)
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	log.Printf("Restored %s, taken %s from %s", args[0], manifest.CreatedAt.Format(time.RFC3339), manifest.Driver)
+	for _, table := range manifest.Tables {
+		log.Printf("  %-16s %d rows", table.Name, table.Rows)
+	}
+	if manifest.FormatVersion < 2 {
+		log.Printf("  The archive predates document files; restore the document store from its own backup")
+	} else {
+		log.Printf("  %d document files, %d bytes", manifest.Files.Count, manifest.Files.Bytes)
+	}
+	return nil
+}
@@ -1,45 +1,484 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"hrms-backend/utils"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in development secrets. Release mode refuses to start with them.
+const (
+	defaultJWTSecret  = "your-super-secret-jwt-key"
+	defaultDBPassword = "hrms_password"
+
+	// exampleJWTSecret is the placeholder in .env and docker-compose.yml
+	exampleJWTSecret = "your-super-secret-jwt-key-change-in-production"
+
+	// minReleaseSecretLength is the shortest JWT secret accepted in release mode
+	minReleaseSecretLength = 32
 )
 
 type Config struct {
-	Port           string
-	GinMode        string
-	DBHost         string
-	DBPort         string
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	DBSSLMode      string
-	JWTSecret      string
-	JWTExpiresIn   time.Duration
-	AllowedOrigins string
-}
-
-func Load() *Config {
-	jwtExpiresIn, _ := time.ParseDuration(getEnv("JWT_EXPIRES_IN", "24h"))
+	Port       string
+	GinMode    string
+	DBDriver   string
+	DBPath     string
+	DBHost     string
+	DBPort     string
+	DBUser     string
+	DBPassword string
+	DBName     string
+	DBSSLMode  string
+	// DBReplicas are read replicas for reports: host[:port] for postgres and
+	// mysql, a file path for sqlite. They share the primary's credentials.
+	DBReplicas        []string
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	JWTSecret         string
+	JWTExpiresIn      time.Duration
+	AllowedOrigins    string
+	IdempotencyTTL    time.Duration
+	// IdempotencyMaxBody is the largest request body, in bytes, read to check
+	// an Idempotency-Key. Multipart uploads are not read.
+	IdempotencyMaxBody int
+	// FeatureFlagRefresh is how long feature flags are cached before they are
+	// re-read, so changes made through another server instance apply
+	FeatureFlagRefresh time.Duration
+	// AnnualLeaveDays is the paid leave entitlement per year. Unused days are
+	// paid out in an employee's final settlement.
+	AnnualLeaveDays int
+	// StorageBackend is where uploaded documents are kept: "local" stores them
+	// under StoragePath, "s3" in an S3-compatible bucket
+	StorageBackend string
+	StoragePath    string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKeyID  string
+	S3SecretKey    string
+	// S3PathStyle addresses the bucket as endpoint/bucket rather than
+	// bucket.endpoint, as MinIO and most self-hosted stores expect
+	S3PathStyle bool
+	// DocumentMaxSize is the largest document upload accepted, in bytes
+	DocumentMaxSize int
+	// ExpiryWarningDays is how many days before a certification
+	// expires its holder and their manager are warned
+	ExpiryWarningDays int
+	// EmployeeCodePattern generates the codes of employees created without
+	// one, such as {DEPT}-{YYYY}-{SEQ:4}; empty means codes are always given
+	EmployeeCodePattern string
+	// SeedAdminEmail and SeedAdminPassword are used by "seed minimal"
+	SeedAdminEmail    string
+	SeedAdminPassword string
+}
 
+// Default returns the development configuration used before any file,
+// environment variable or flag is applied
+func Default() *Config {
 	return &Config{
-		Port:           getEnv("PORT", "8080"),
-		GinMode:        getEnv("GIN_MODE", "debug"),
-		DBHost:         getEnv("DB_HOST", "localhost"),
-		DBPort:         getEnv("DB_PORT", "5432"),
-		DBUser:         getEnv("DB_USER", "hrms_user"),
-		DBPassword:     getEnv("DB_PASSWORD", "hrms_password"),
-		DBName:         getEnv("DB_NAME", "hrms_db"),
-		DBSSLMode:      getEnv("DB_SSLMODE", "disable"),
-		JWTSecret:      getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
-		JWTExpiresIn:   jwtExpiresIn,
-		AllowedOrigins: getEnv("ALLOWED_ORIGINS", "http://localhost:3001"),
+		Port:               "8080",
+		GinMode:            "debug",
+		DBDriver:           "postgres",
+		DBPath:             "hrms.db",
+		DBHost:             "localhost",
+		DBPort:             "5432",
+		DBUser:             "hrms_user",
+		DBPassword:         defaultDBPassword,
+		DBName:             "hrms_db",
+		DBSSLMode:          "disable",
+		DBMaxOpenConns:     25,
+		DBMaxIdleConns:     10,
+		DBConnMaxLifetime:  30 * time.Minute,
+		DBConnMaxIdleTime:  5 * time.Minute,
+		JWTSecret:          defaultJWTSecret,
+		JWTExpiresIn:       24 * time.Hour,
+		AllowedOrigins:     "http://localhost:3001",
+		IdempotencyTTL:     24 * time.Hour,
+		IdempotencyMaxBody: 1 << 20,
+		FeatureFlagRefresh: 30 * time.Second,
+		AnnualLeaveDays:    20,
+		StorageBackend:     "local",
+		StoragePath:        "uploads",
+		S3Region:           "us-east-1",
+		S3PathStyle:        true,
+		DocumentMaxSize:    10 << 20,
+		ExpiryWarningDays:  30,
+		SeedAdminEmail:     "admin@hrms.com",
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence, the
+// defaults, a YAML or TOML file named by -config or HRMS_CONFIG, environment
+// variables and command line flags. Flags must come before any subcommand,
+// whose arguments are returned. The result is validated.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	flags := flag.NewFlagSet("hrms-backend", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("HRMS_CONFIG"), "YAML or TOML configuration file")
+	flagValues := map[string]*string{}
+	for _, s := range settings {
+		if !s.secret {
+			flagValues[s.flagName()] = flags.String(s.flagName(), "", s.usage)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := cfg.applyFile(*configFile); err != nil {
+			return nil, nil, fmt.Errorf("config file %s: %w", *configFile, err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, nil, err
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		if s, ok := settingByFlag(f.Name); ok && flagErr == nil {
+			flagErr = s.apply(cfg, "-"+f.Name, *flagValues[f.Name])
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, flags.Args(), nil
+}
+
+// Validate reports every invalid setting. In release mode it also rejects the
+// built-in development secrets.
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port < 65536, "PORT must be a port number, got %q", c.Port)
+	check(c.GinMode == "debug" || c.GinMode == "release" || c.GinMode == "test",
+		"GIN_MODE must be debug, release or test, got %q", c.GinMode)
+	check(c.DBDriver != "", "DB_DRIVER is required")
+	check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.DBMaxOpenConns == 0 || c.DBMaxIdleConns <= c.DBMaxOpenConns,
+		"DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DBMaxIdleConns, c.DBMaxOpenConns)
+	check(c.DBConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(c.DBConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME must not be negative")
+	check(c.JWTSecret != "", "JWT_SECRET is required")
+	check(c.JWTExpiresIn > 0, "JWT_EXPIRES_IN must be positive")
+	check(c.IdempotencyTTL > 0, "IDEMPOTENCY_TTL must be positive")
+	check(c.IdempotencyMaxBody > 0, "IDEMPOTENCY_MAX_BODY must be positive")
+	check(c.FeatureFlagRefresh > 0, "FEATURE_FLAG_REFRESH must be positive")
+	check(c.AnnualLeaveDays >= 0 && c.AnnualLeaveDays <= 366, "ANNUAL_LEAVE_DAYS must be between 0 and 366")
+	check(strings.TrimSpace(c.AllowedOrigins) != "", "ALLOWED_ORIGINS is required")
+	check(c.DocumentMaxSize > 0, "DOCUMENT_MAX_SIZE must be positive")
+	check(c.ExpiryWarningDays >= 1 && c.ExpiryWarningDays <= 365, "CERTIFICATION_WARNING_DAYS must be between 1 and 365")
+	if _, err := utils.ParseCodePattern(c.EmployeeCodePattern); err != nil {
+		check(false, "EMPLOYEE_CODE_PATTERN %v", err)
+	}
+	switch c.StorageBackend {
+	case "local":
+		check(c.StoragePath != "", "STORAGE_PATH is required for local storage")
+	case "s3":
+		check(c.S3Endpoint != "", "S3_ENDPOINT is required for s3 storage")
+		check(c.S3Bucket != "", "S3_BUCKET is required for s3 storage")
+		check(c.S3AccessKeyID != "" && c.S3SecretKey != "",
+			"S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for s3 storage")
+	default:
+		check(false, "STORAGE_BACKEND must be local or s3, got %q", c.StorageBackend)
+	}
+
+	if c.GinMode == "release" {
+		check(c.JWTSecret != defaultJWTSecret && c.JWTSecret != exampleJWTSecret,
+			"JWT_SECRET is a published development secret; set your own in release mode")
+		check(len(c.JWTSecret) >= minReleaseSecretLength,
+			"JWT_SECRET must be at least %d characters in release mode", minReleaseSecretLength)
+		check(c.DBDriver == "sqlite" || c.DBPassword != defaultDBPassword,
+			"DB_PASSWORD is the built-in development password; set your own in release mode")
+	}
+
+	return errors.Join(problems...)
+}
+
+// setting binds one configuration value to its config file key, environment
+// variable and command line flag
+type setting struct {
+	// key is the dotted path in the config file, e.g. database.host
+	key   string
+	env   string
+	usage string
+	// secret settings have no flag, so they never appear in process listings,
+	// and can be read from the file named by <env>_FILE or <key>File
+	secret bool
+	set    func(cfg *Config, value string) error
+}
+
+func (s setting) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(s.env, "_", "-"))
+}
+
+// apply sets the value given by source, naming source in any error
+func (s setting) apply(cfg *Config, source, value string) error {
+	if err := s.set(cfg, value); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	return nil
+}
+
+func stringSetting(key, env, usage string, field func(*Config) *string) setting {
+	return setting{key: key, env: env, usage: usage, set: func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}}
+}
+
+func secretSetting(key, env, usage string, field func(*Config) *string) setting {
+	s := stringSetting(key, env, usage, field)
+	s.secret = true
+	return s
+}
+
+func intSetting(key, env, usage string, field func(*Config) *int) setting {
+	return setting{key: key, env: env, usage: usage, set: func(cfg *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*field(cfg) = n
+		return nil
+	}}
+}
+
+func durationSetting(key, env, usage string, field func(*Config) *time.Duration) setting {
+	return setting{key: key, env: env, usage: usage, set: func(cfg *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30m or 24h", value)
+		}
+		*field(cfg) = d
+		return nil
+	}}
+}
+
+func boolSetting(key, env, usage string, field func(*Config) *bool) setting {
+	return setting{key: key, env: env, usage: usage, set: func(cfg *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*field(cfg) = b
+		return nil
+	}}
+}
+
+// listSetting takes a comma-separated list, dropping empty items
+func listSetting(key, env, usage string, field func(*Config) *[]string) setting {
+	return setting{key: key, env: env, usage: usage, set: func(cfg *Config, value string) error {
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		*field(cfg) = values
+		return nil
+	}}
+}
+
+var settings = []setting{
+	stringSetting("port", "PORT", "HTTP port", func(c *Config) *string { return &c.Port }),
+	stringSetting("ginMode", "GIN_MODE", "debug, release or test", func(c *Config) *string { return &c.GinMode }),
+	stringSetting("allowedOrigins", "ALLOWED_ORIGINS", "comma-separated CORS origins", func(c *Config) *string { return &c.AllowedOrigins }),
+	durationSetting("idempotencyTTL", "IDEMPOTENCY_TTL", "how long Idempotency-Key responses are replayed", func(c *Config) *time.Duration { return &c.IdempotencyTTL }),
+	intSetting("idempotencyMaxBody", "IDEMPOTENCY_MAX_BODY", "largest request body in bytes checked against an Idempotency-Key", func(c *Config) *int { return &c.IdempotencyMaxBody }),
+
+	durationSetting("featureFlagRefresh", "FEATURE_FLAG_REFRESH", "how long feature flags are cached", func(c *Config) *time.Duration { return &c.FeatureFlagRefresh }),
+	intSetting("leave.annualDays", "ANNUAL_LEAVE_DAYS", "paid leave days per year, for leave encashment", func(c *Config) *int { return &c.AnnualLeaveDays }),
+
+	stringSetting("storage.backend", "STORAGE_BACKEND", "where documents are stored: local or s3", func(c *Config) *string { return &c.StorageBackend }),
+	stringSetting("storage.path", "STORAGE_PATH", "document directory, local storage only", func(c *Config) *string { return &c.StoragePath }),
+	stringSetting("storage.s3.endpoint", "S3_ENDPOINT", "S3-compatible endpoint URL", func(c *Config) *string { return &c.S3Endpoint }),
+	stringSetting("storage.s3.region", "S3_REGION", "S3 region", func(c *Config) *string { return &c.S3Region }),
+	stringSetting("storage.s3.bucket", "S3_BUCKET", "S3 bucket for documents", func(c *Config) *string { return &c.S3Bucket }),
+	stringSetting("storage.s3.accessKeyId", "S3_ACCESS_KEY_ID", "S3 access key ID", func(c *Config) *string { return &c.S3AccessKeyID }),
+	secretSetting("storage.s3.secretAccessKey", "S3_SECRET_ACCESS_KEY", "S3 secret access key", func(c *Config) *string { return &c.S3SecretKey }),
+	boolSetting("storage.s3.pathStyle", "S3_PATH_STYLE", "address the bucket in the path rather than the host name", func(c *Config) *bool { return &c.S3PathStyle }),
+	intSetting("documents.maxSize", "DOCUMENT_MAX_SIZE", "largest document upload in bytes", func(c *Config) *int { return &c.DocumentMaxSize }),
+	intSetting("certifications.warningDays", "CERTIFICATION_WARNING_DAYS", "days before a certification expires to warn its holder and manager", func(c *Config) *int { return &c.ExpiryWarningDays }),
+	stringSetting("employees.codePattern", "EMPLOYEE_CODE_PATTERN", "pattern for generated employee codes, e.g. {DEPT}-{YYYY}-{SEQ:4}", func(c *Config) *string { return &c.EmployeeCodePattern }),
+
+	stringSetting("database.driver", "DB_DRIVER", "postgres, mysql or sqlite", func(c *Config) *string { return &c.DBDriver }),
+	stringSetting("database.path", "DB_PATH", "database file, sqlite only", func(c *Config) *string { return &c.DBPath }),
+	stringSetting("database.host", "DB_HOST", "database host", func(c *Config) *string { return &c.DBHost }),
+	stringSetting("database.port", "DB_PORT", "database port", func(c *Config) *string { return &c.DBPort }),
+	stringSetting("database.user", "DB_USER", "database user", func(c *Config) *string { return &c.DBUser }),
+	secretSetting("database.password", "DB_PASSWORD", "database password", func(c *Config) *string { return &c.DBPassword }),
+	stringSetting("database.name", "DB_NAME", "database name", func(c *Config) *string { return &c.DBName }),
+	stringSetting("database.sslMode", "DB_SSLMODE", "postgres sslmode", func(c *Config) *string { return &c.DBSSLMode }),
+	listSetting("database.replicas", "DB_REPLICAS", "comma-separated read replicas for reports", func(c *Config) *[]string { return &c.DBReplicas }),
+	intSetting("database.maxOpenConns", "DB_MAX_OPEN_CONNS", "maximum open connections (0 is unlimited)", func(c *Config) *int { return &c.DBMaxOpenConns }),
+	intSetting("database.maxIdleConns", "DB_MAX_IDLE_CONNS", "maximum idle connections", func(c *Config) *int { return &c.DBMaxIdleConns }),
+	durationSetting("database.connMaxLifetime", "DB_CONN_MAX_LIFETIME", "maximum connection age", func(c *Config) *time.Duration { return &c.DBConnMaxLifetime }),
+	durationSetting("database.connMaxIdleTime", "DB_CONN_MAX_IDLE_TIME", "maximum connection idle time", func(c *Config) *time.Duration { return &c.DBConnMaxIdleTime }),
+
+	secretSetting("jwt.secret", "JWT_SECRET", "token signing secret", func(c *Config) *string { return &c.JWTSecret }),
+	durationSetting("jwt.expiresIn", "JWT_EXPIRES_IN", "token lifetime", func(c *Config) *time.Duration { return &c.JWTExpiresIn }),
+
+	stringSetting("seed.adminEmail", "SEED_ADMIN_EMAIL", "admin created by \"seed minimal\"", func(c *Config) *string { return &c.SeedAdminEmail }),
+	secretSetting("seed.adminPassword", "SEED_ADMIN_PASSWORD", "password of the \"seed minimal\" admin", func(c *Config) *string { return &c.SeedAdminPassword }),
+}
+
+func settingByFlag(name string) (setting, bool) {
+	for _, s := range settings {
+		if !s.secret && s.flagName() == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// applyEnv applies every variable that is set and not empty. A secret may be
+// given as <env>_FILE instead, naming a file that holds it, as with Docker or
+// Kubernetes secrets.
+func (c *Config) applyEnv() error {
+	for _, s := range settings {
+		value := os.Getenv(s.env)
+		if s.secret {
+			if path := os.Getenv(s.env + "_FILE"); path != "" {
+				if value != "" {
+					return fmt.Errorf("set %s or %s_FILE, not both", s.env, s.env)
+				}
+				secret, err := readSecret(path)
+				if err != nil {
+					return fmt.Errorf("%s_FILE: %w", s.env, err)
+				}
+				value = secret
+			}
+		}
+		if value == "" {
+			continue
+		}
+		if err := s.apply(c, s.env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyFile applies a configuration file. Its format follows the extension;
+// keys are nested by section, such as database.host, and unknown keys are
+// rejected so typos do not silently fall back to defaults.
+func (c *Config) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	document := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return fmt.Errorf("unsupported format %q; use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	values := map[string]string{}
+	if err := flatten("", document, values); err != nil {
+		return err
+	}
+
+	known := map[string]setting{}
+	for _, s := range settings {
+		known[s.key] = s
+		if s.secret {
+			known[s.key+"File"] = s
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s, ok := known[key]
+		if !ok {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		value := values[key]
+		if key != s.key {
+			if _, both := values[s.key]; both {
+				return fmt.Errorf("set %s or %s, not both", s.key, key)
+			}
+			if value, err = readSecret(value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		if err := s.apply(c, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flatten turns nested sections into dotted keys with string values. Lists
+// become comma-separated.
+func flatten(prefix string, document map[string]interface{}, values map[string]string) error {
+	for key, value := range document {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			return fmt.Errorf("%s has no value", key)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
 	}
+	return nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// readSecret reads a secret from a file, dropping the trailing newline most
+// editors and secret stores add
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
 	}
-	return defaultValue
+	return strings.TrimRight(string(data), "\r\n"), nil
 }
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv hides any configuration set in the environment running the tests
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("HRMS_CONFIG", "")
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+		t.Setenv(s.env+"_FILE", "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayersFileEnvAndFlags(t *testing.T) {
+	clearEnv(t)
+	file := writeFile(t, "hrms.yaml", `
+port: 9000
+ginMode: test
+database:
+  driver: sqlite
+  path: file.db
+  replicas: [a.db, b.db]
+  connMaxLifetime: 1h
+jwt:
+  expiresIn: 2h
+`)
+	t.Setenv("DB_PATH", "env.db")
+	t.Setenv("JWT_EXPIRES_IN", "3h")
+
+	cfg, args, err := Load([]string{"-config", file, "-jwt-expires-in", "4h", "migrate", "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Errorf("args = %v, want the subcommand", args)
+	}
+	if cfg.Port != "9000" || cfg.DBDriver != "sqlite" || cfg.DBConnMaxLifetime != time.Hour {
+		t.Errorf("file settings not applied: %+v", cfg)
+	}
+	if strings.Join(cfg.DBReplicas, ",") != "a.db,b.db" {
+		t.Errorf("replicas = %v", cfg.DBReplicas)
+	}
+	if cfg.DBPath != "env.db" {
+		t.Errorf("DBPath = %q, want the environment to override the file", cfg.DBPath)
+	}
+	if cfg.JWTExpiresIn != 4*time.Hour {
+		t.Errorf("JWTExpiresIn = %v, want the flag to override the environment", cfg.JWTExpiresIn)
+	}
+	if cfg.DBMaxOpenConns != 25 {
+		t.Errorf("DBMaxOpenConns = %d, want the default", cfg.DBMaxOpenConns)
+	}
+}
+
+func TestLoadReadsSecretFiles(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", "from-env-file\n"))
+	file := writeFile(t, "hrms.toml", "[database]\npasswordFile = \""+writeFile(t, "db", "from-config-file")+"\"\n")
+
+	cfg, _, err := Load([]string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.JWTSecret != "from-env-file" {
+		t.Errorf("JWTSecret = %q", cfg.JWTSecret)
+	}
+	if cfg.DBPassword != "from-config-file" {
+		t.Errorf("DBPassword = %q", cfg.DBPassword)
+	}
+
+	t.Setenv("JWT_SECRET", "inline")
+	if _, _, err := Load(nil); err == nil {
+		t.Error("JWT_SECRET and JWT_SECRET_FILE together were accepted")
+	}
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	tests := map[string]struct {
+		env  map[string]string
+		file string
+		args []string
+	}{
+		"bad duration":  {env: map[string]string{"JWT_EXPIRES_IN": "1 day"}},
+		"bad number":    {args: []string{"-db-max-open-conns", "many"}},
+		"bad port":      {env: map[string]string{"PORT": "http"}},
+		"bad mode":      {env: map[string]string{"GIN_MODE": "production"}},
+		"unknown key":   {file: "database:\n  hots: db.internal\n"},
+		"secret flag":   {args: []string{"-jwt-secret", "visible-in-ps"}},
+		"default jwt":   {env: map[string]string{"GIN_MODE": "release", "DB_PASSWORD": "a-real-password"}},
+		"example jwt":   {env: map[string]string{"GIN_MODE": "release", "JWT_SECRET": "your-super-secret-jwt-key-change-in-production", "DB_PASSWORD": "a-real-password"}},
+		"short jwt":     {env: map[string]string{"GIN_MODE": "release", "JWT_SECRET": "short", "DB_PASSWORD": "a-real-password"}},
+		"default db pw": {env: map[string]string{"GIN_MODE": "release", "JWT_SECRET": strings.Repeat("s", 32)}},
+		"bad bool":      {env: map[string]string{"S3_PATH_STYLE": "sometimes"}},
+		"s3 no bucket":  {env: map[string]string{"STORAGE_BACKEND": "s3", "S3_ENDPOINT": "http://minio:9000"}},
+		"code no seq":   {env: map[string]string{"EMPLOYEE_CODE_PATTERN": "{DEPT}-{YYYY}"}},
+		"no warning":    {env: map[string]string{"CERTIFICATION_WARNING_DAYS": "0"}},
+		"no body limit": {env: map[string]string{"IDEMPOTENCY_MAX_BODY": "0"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			args := test.args
+			if test.file != "" {
+				args = append([]string{"-config", writeFile(t, "hrms.yaml", test.file)}, args...)
+			}
+			if _, _, err := Load(args); err == nil {
+				t.Error("configuration was accepted")
+			}
+		})
+	}
+}
+
+func TestReleaseModeAcceptsOwnSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("GIN_MODE", "release")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", 32))
+	t.Setenv("DB_PASSWORD", "a-real-password")
+
+	if _, _, err := Load(nil); err != nil {
+		t.Fatal(err)
+	}
+}
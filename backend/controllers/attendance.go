@@ -2,12 +2,13 @@ package controllers
 
 import (
 	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // AttendanceResponse represents the attendance data structure expected by frontend
@@ -20,15 +21,11 @@ type AttendanceResponse struct {
 	CheckOut     string `json:"checkOut,omitempty"`
 	Hours        int    `json:"hours,omitempty"`
 	Status       string `json:"status"`
+	Version      uint   `json:"version"`
 }
 
-// Helper function to convert model to response format
-func (ac *AttendanceController) transformAttendanceResponse(att models.Attendance) AttendanceResponse {
-	employeeName := ""
-	if att.Employee.FirstName != "" && att.Employee.LastName != "" {
-		employeeName = att.Employee.FirstName + " " + att.Employee.LastName
-	}
-
+// Helper function to convert a listed attendance record to response format
+func (ac *AttendanceController) transformAttendanceResponse(att repositories.AttendanceSummary) AttendanceResponse {
 	date := ""
 	if !att.Date.IsZero() {
 		date = att.Date.Format("2006-01-02")
@@ -47,82 +44,94 @@ func (ac *AttendanceController) transformAttendanceResponse(att models.Attendanc
 	hours := int(att.WorkingHours)
 
 	return AttendanceResponse{
-		ID:           strconv.Itoa(int(att.Model.ID)),
+		ID:           strconv.Itoa(int(att.ID)),
 		EmployeeID:   strconv.Itoa(int(att.EmployeeID)),
-		EmployeeName: employeeName,
+		EmployeeName: fullName(att.EmployeeFirstName, att.EmployeeLastName),
 		Date:         date,
 		CheckIn:      checkIn,
 		CheckOut:     checkOut,
 		Hours:        hours,
 		Status:       att.Status,
+		Version:      att.Version,
 	}
 }
 
-type AttendanceController struct {
-	db *gorm.DB
+// AttendanceReportEntry represents one row of the department attendance report
+type AttendanceReportEntry struct {
+	EmployeeName string     `json:"employeeName"`
+	Date         time.Time  `json:"date"`
+	CheckIn      *time.Time `json:"checkIn"`
+	CheckOut     *time.Time `json:"checkOut"`
+	WorkingHours float64    `json:"workingHours"`
+	Status       string     `json:"status"`
 }
 
-func NewAttendanceController(db *gorm.DB) *AttendanceController {
-	return &AttendanceController{db: db}
+// CreateAttendanceRequest represents the payload accepted when logging attendance.
+// EmployeeID is ignored for employees, who can only log their own attendance.
+type CreateAttendanceRequest struct {
+	EmployeeID uint       `json:"employeeId" binding:"omitempty,exists=employees"`
+	Date       time.Time  `json:"date" binding:"required"`
+	CheckIn    *time.Time `json:"checkIn"`
+	CheckOut   *time.Time `json:"checkOut" binding:"omitempty,gtfield=CheckIn"`
+	Status     string     `json:"status" binding:"omitempty,oneof=present absent late half-day"`
+	Comments   string     `json:"comments" binding:"max=500"`
 }
 
-// GetAttendance - HR can see all, Managers see their department, Employees see own
-func (ac *AttendanceController) GetAttendance(c *gin.Context) {
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
-
-	var attendance []models.Attendance
-	query := ac.db.Preload("Employee").Preload("Employee.Department")
-
-	switch userRole {
-	case "hr", "admin":
-		// HR can see all attendance records
-		if err := query.Find(&attendance).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance records"})
-			return
-		}
-
-	case "manager":
-		// Managers can only see their department's attendance
-		var user models.User
-		if err := ac.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
+// UpdateAttendanceRequest is the writable view of an attendance record. Updates
+// are sent as a JSON Merge Patch against this view, so check-in and check-out
+// times can be cleared with an explicit null.
+type UpdateAttendanceRequest struct {
+	Date     time.Time  `json:"date" binding:"required"`
+	CheckIn  *time.Time `json:"checkIn"`
+	CheckOut *time.Time `json:"checkOut" binding:"omitempty,gtfield=CheckIn"`
+	Status   string     `json:"status" binding:"required,oneof=present absent late half-day"`
+	Comments string     `json:"comments" binding:"max=500"`
+}
 
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Manager must be associated with an employee record"})
-			return
-		}
+func (r CreateAttendanceRequest) input() services.AttendanceInput {
+	return services.AttendanceInput{
+		EmployeeID: r.EmployeeID,
+		Date:       r.Date,
+		CheckIn:    r.CheckIn,
+		CheckOut:   r.CheckOut,
+		Status:     r.Status,
+		Comments:   r.Comments,
+	}
+}
 
-		// Find all employees in the same department
-		if err := query.Joins("JOIN employees ON attendance.employee_id = employees.id").
-			Where("employees.department_id = ?", user.Employee.DepartmentID).
-			Find(&attendance).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch department attendance"})
-			return
-		}
+func newUpdateAttendanceRequest(att models.Attendance) UpdateAttendanceRequest {
+	return UpdateAttendanceRequest{
+		Date:     att.Date,
+		CheckIn:  att.CheckIn,
+		CheckOut: att.CheckOut,
+		Status:   att.Status,
+		Comments: att.Comments,
+	}
+}
 
-	case "employee":
-		// Employees can only see their own attendance
-		var user models.User
-		if err := ac.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
+func (r UpdateAttendanceRequest) input() services.AttendanceInput {
+	return services.AttendanceInput{
+		Date:     r.Date,
+		CheckIn:  r.CheckIn,
+		CheckOut: r.CheckOut,
+		Status:   r.Status,
+		Comments: r.Comments,
+	}
+}
 
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User must be associated with an employee record"})
-			return
-		}
+type AttendanceController struct {
+	attendance *services.AttendanceService
+}
 
-		if err := query.Where("employee_id = ?", user.Employee.ID).Find(&attendance).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch personal attendance"})
-			return
-		}
+func NewAttendanceController(attendance *services.AttendanceService) *AttendanceController {
+	return &AttendanceController{attendance: attendance}
+}
 
-	default:
-		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user role"})
+// GetAttendance - HR can see all, Managers see their department, Employees see own
+func (ac *AttendanceController) GetAttendance(c *gin.Context) {
+	attendance, err := ac.attendance.List(currentActor(c))
+	if err != nil {
+		respondError(c, err, "Failed to fetch attendance records")
 		return
 	}
 
@@ -135,58 +144,46 @@ func (ac *AttendanceController) GetAttendance(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// CreateAttendance - Employees log their own attendance, HR can create for anyone
-func (ac *AttendanceController) CreateAttendance(c *gin.Context) {
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
-
-	var attendance models.Attendance
-	if err := c.ShouldBindJSON(&attendance); err != nil {
+// GetAttendanceRecord - HR sees any record, Managers their department's, Employees their own
+func (ac *AttendanceController) GetAttendanceRecord(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": err.Error(),
+			"message": "Invalid attendance ID",
 		})
 		return
 	}
 
-	// For employees, ensure they can only log their own attendance
-	if userRole == "employee" {
-		var user models.User
-		if err := ac.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
-
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "User must be associated with an employee record",
-			})
-			return
-		}
-
-		attendance.EmployeeID = user.Employee.ID
+	attendance, err := ac.attendance.View(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch attendance record")
+		return
 	}
+	setETag(c, attendance.Version)
 
-	// Calculate work hours if both check-in and check-out are provided
-	if attendance.CheckIn != nil && attendance.CheckOut != nil {
-		duration := attendance.CheckOut.Sub(*attendance.CheckIn)
-		attendance.WorkingHours = duration.Hours()
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    attendance,
+	})
+}
 
-	if err := ac.db.Create(&attendance).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+// CreateAttendance - Employees log their own attendance, HR can create for anyone
+func (ac *AttendanceController) CreateAttendance(c *gin.Context) {
+	var req CreateAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Failed to create attendance record",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Load employee data for response
-	ac.db.Preload("Employee").First(&attendance, attendance.ID)
+	attendance, err := ac.attendance.Log(currentActor(c), req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create attendance record")
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -197,7 +194,7 @@ func (ac *AttendanceController) CreateAttendance(c *gin.Context) {
 
 // UpdateAttendance - Update attendance record (HR only)
 func (ac *AttendanceController) UpdateAttendance(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -206,40 +203,34 @@ func (ac *AttendanceController) UpdateAttendance(c *gin.Context) {
 		return
 	}
 
-	var attendance models.Attendance
-	if err := ac.db.First(&attendance, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Attendance record not found",
-		})
+	attendance, err := ac.attendance.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch attendance record")
 		return
 	}
 
-	var updateData models.Attendance
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+	if !ifMatchSatisfied(c, attendance.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
 			"success": false,
-			"message": err.Error(),
+			"message": "Attendance record has been modified by another request",
 		})
 		return
 	}
 
-	// Calculate work hours if both times are provided
-	if updateData.CheckIn != nil && updateData.CheckOut != nil {
-		duration := updateData.CheckOut.Sub(*updateData.CheckIn)
-		updateData.WorkingHours = duration.Hours()
-	}
-
-	if err := ac.db.Model(&attendance).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	req := newUpdateAttendanceRequest(*attendance)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
 			"success": false,
-			"message": "Failed to update attendance record",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Load employee data for response
-	ac.db.Preload("Employee").First(&attendance, attendance.ID)
+	if err := ac.attendance.Update(attendance, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update attendance record")
+		return
+	}
+	setETag(c, attendance.Version)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -250,7 +241,7 @@ func (ac *AttendanceController) UpdateAttendance(c *gin.Context) {
 
 // DeleteAttendance - Delete attendance record (HR only)
 func (ac *AttendanceController) DeleteAttendance(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -259,11 +250,8 @@ func (ac *AttendanceController) DeleteAttendance(c *gin.Context) {
 		return
 	}
 
-	if err := ac.db.Delete(&models.Attendance{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to delete attendance record",
-		})
+	if err := ac.attendance.Delete(id); err != nil {
+		respondFailure(c, err, "Failed to delete attendance record")
 		return
 	}
 
@@ -275,46 +263,23 @@ func (ac *AttendanceController) DeleteAttendance(c *gin.Context) {
 
 // GetDepartmentAttendanceReport - For managers to get their department's attendance report
 func (ac *AttendanceController) GetDepartmentAttendanceReport(c *gin.Context) {
-	userID, _ := c.Get("userID")
-
-	var user models.User
-	if err := ac.db.Preload("Employee").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "User not found",
-		})
-		return
-	}
-
-	if user.Employee == nil {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "Manager must be associated with an employee record",
-		})
+	rows, err := ac.attendance.DepartmentReport(currentActor(c))
+	if err != nil {
+		respondFailure(c, err, "Failed to generate department attendance report")
 		return
 	}
 
-	// Get attendance report for the department
-	var attendanceReport []struct {
-		EmployeeName string     `json:"employeeName"`
-		Date         time.Time  `json:"date"`
-		CheckIn      *time.Time `json:"checkIn"`
-		CheckOut     *time.Time `json:"checkOut"`
-		WorkingHours float64    `json:"workingHours"`
-		Status       string     `json:"status"`
-	}
-
-	if err := ac.db.Table("attendance").
-		Select("CONCAT(employees.first_name, ' ', employees.last_name) as employee_name, attendance.date, attendance.check_in, attendance.check_out, attendance.working_hours, attendance.status").
-		Joins("JOIN employees ON attendance.employee_id = employees.id").
-		Where("employees.department_id = ?", user.Employee.DepartmentID).
-		Order("attendance.date DESC").
-		Scan(&attendanceReport).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to generate department attendance report",
-		})
-		return
+	// Names are joined in Go because string concatenation differs between SQL dialects
+	attendanceReport := make([]AttendanceReportEntry, len(rows))
+	for i, row := range rows {
+		attendanceReport[i] = AttendanceReportEntry{
+			EmployeeName: row.FirstName + " " + row.LastName,
+			Date:         row.Date,
+			CheckIn:      row.CheckIn,
+			CheckOut:     row.CheckOut,
+			WorkingHours: row.WorkingHours,
+			Status:       row.Status,
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -2,21 +2,19 @@ package controllers
 
 import (
 	"hrms-backend/models"
-	"hrms-backend/utils"
+	"hrms-backend/services"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 type AuthController struct {
-	db *gorm.DB
+	auth *services.AuthService
 }
 
-func NewAuthController(db *gorm.DB) *AuthController {
-	return &AuthController{db: db}
+func NewAuthController(auth *services.AuthService) *AuthController {
+	return &AuthController{auth: auth}
 }
 
 type LoginRequest struct {
@@ -43,29 +41,9 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	log.Printf("Login attempt for email: %s", req.Email)
 
-	// Find user by email
-	var user models.User
-	if err := ac.db.Preload("Employee").Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Check if user is active
-	if !user.IsActive {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is deactivated"})
-		return
-	}
-
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.Model.ID, user.Email, user.Role)
+	token, user, err := ac.auth.Login(req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		respondError(c, err, "Failed to generate token")
 		return
 	}
 
@@ -74,7 +52,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, LoginResponse{
 		Token: token,
-		User:  user,
+		User:  *user,
 	})
 }
 
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CertificationRequest represents an employee's certification. Leave
+// expiresAt out for certifications that never expire; documentId names one
+// of the employee's documents as evidence.
+type CertificationRequest struct {
+	SkillID    uint       `json:"skillId" binding:"required"`
+	Name       string     `json:"name" binding:"required,max=191"`
+	Issuer     string     `json:"issuer" binding:"required,max=191"`
+	IssuedAt   time.Time  `json:"issuedAt" binding:"required"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	DocumentID *uint      `json:"documentId"`
+}
+
+func newCertificationRequest(certification models.Certification) CertificationRequest {
+	return CertificationRequest{
+		SkillID:    certification.SkillID,
+		Name:       certification.Name,
+		Issuer:     certification.Issuer,
+		IssuedAt:   certification.IssuedAt,
+		ExpiresAt:  certification.ExpiresAt,
+		DocumentID: certification.DocumentID,
+	}
+}
+
+func (r CertificationRequest) input() services.CertificationInput {
+	return services.CertificationInput{
+		SkillID:    r.SkillID,
+		Name:       r.Name,
+		Issuer:     r.Issuer,
+		IssuedAt:   r.IssuedAt,
+		ExpiresAt:  r.ExpiresAt,
+		DocumentID: r.DocumentID,
+	}
+}
+
+// SkillMatrixResponse shows which skills a department's active employees are
+// certified in
+type SkillMatrixResponse struct {
+	DepartmentID uint                  `json:"departmentId"`
+	Skills       []models.Skill        `json:"skills"`
+	Employees    []SkillMatrixEmployee `json:"employees"`
+}
+
+// SkillMatrixEmployee is an employee's row of the skill matrix. Skills maps
+// skill IDs to the employee's certification; skills they are not certified
+// in are left out.
+type SkillMatrixEmployee struct {
+	EmployeeID uint                       `json:"employeeId"`
+	Name       string                     `json:"name"`
+	Position   string                     `json:"position"`
+	Skills     map[string]SkillMatrixCell `json:"skills"`
+}
+
+// SkillMatrixCell is a certification in the skill matrix. Status is valid,
+// expiring (within CERTIFICATION_WARNING_DAYS) or expired.
+type SkillMatrixCell struct {
+	CertificationID uint   `json:"certificationId"`
+	Status          string `json:"status"`
+	ExpiresAt       string `json:"expiresAt,omitempty"`
+}
+
+func newSkillMatrixResponse(matrix *services.SkillMatrix) SkillMatrixResponse {
+	response := SkillMatrixResponse{
+		DepartmentID: matrix.DepartmentID,
+		Skills:       matrix.Skills,
+		Employees:    make([]SkillMatrixEmployee, len(matrix.Employees)),
+	}
+	if response.Skills == nil {
+		response.Skills = []models.Skill{}
+	}
+	for i, row := range matrix.Employees {
+		cells := make(map[string]SkillMatrixCell, len(row.Skills))
+		for skillID, cell := range row.Skills {
+			expiresAt := ""
+			if cell.ExpiresAt != nil {
+				expiresAt = cell.ExpiresAt.Format("2006-01-02")
+			}
+			cells[strconv.FormatUint(uint64(skillID), 10)] = SkillMatrixCell{
+				CertificationID: cell.CertificationID,
+				Status:          cell.Status,
+				ExpiresAt:       expiresAt,
+			}
+		}
+		response.Employees[i] = SkillMatrixEmployee{
+			EmployeeID: row.EmployeeID,
+			Name:       row.FirstName + " " + row.LastName,
+			Position:   row.Position,
+			Skills:     cells,
+		}
+	}
+	return response
+}
+
+type CertificationController struct {
+	certifications *services.CertificationService
+}
+
+func NewCertificationController(certifications *services.CertificationService) *CertificationController {
+	return &CertificationController{certifications: certifications}
+}
+
+// GetCertifications - HR, the employee and managers the employee reports to,
+// directly or not, see the employee's certifications
+func (cc *CertificationController) GetCertifications(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	certifications, err := cc.certifications.List(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch certifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    certifications,
+	})
+}
+
+// CreateCertification - HR records a certification for an employee
+func (cc *CertificationController) CreateCertification(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	var req CertificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	certification, err := cc.certifications.Create(id, req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create certification")
+		return
+	}
+	setETag(c, certification.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    certification,
+		"message": "Certification created successfully",
+	})
+}
+
+// UpdateCertification - HR edits or renews a certification
+func (cc *CertificationController) UpdateCertification(c *gin.Context) {
+	id, certificationID, ok := parseChildID(c, "certificationId", "certification")
+	if !ok {
+		return
+	}
+
+	certification, err := cc.certifications.Get(id, certificationID)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch certification")
+		return
+	}
+
+	if !ifMatchSatisfied(c, certification.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Certification has been modified by another request",
+		})
+		return
+	}
+
+	req := newCertificationRequest(*certification)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := cc.certifications.Update(certification, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update certification")
+		return
+	}
+	setETag(c, certification.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    certification,
+		"message": "Certification updated successfully",
+	})
+}
+
+// DeleteCertification - HR removes a certification
+func (cc *CertificationController) DeleteCertification(c *gin.Context) {
+	id, certificationID, ok := parseChildID(c, "certificationId", "certification")
+	if !ok {
+		return
+	}
+
+	if err := cc.certifications.Delete(id, certificationID); err != nil {
+		respondFailure(c, err, "Failed to delete certification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Certification deleted successfully",
+	})
+}
+
+// GetSkillMatrix - HR sees any department's skill matrix, managers their own
+func (cc *CertificationController) GetSkillMatrix(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid department ID"})
+		return
+	}
+
+	matrix, err := cc.certifications.Matrix(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to build skill matrix")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newSkillMatrixResponse(matrix),
+	})
+}
@@ -0,0 +1,431 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChecklistTemplateRequest represents a checklist template with its tasks.
+// Leave departmentId and position empty to apply the template to everyone;
+// active defaults to true.
+type ChecklistTemplateRequest struct {
+	Name         string                         `json:"name" binding:"required,max=100"`
+	Kind         string                         `json:"kind" binding:"required,oneof=onboarding offboarding"`
+	DepartmentID *uint                          `json:"departmentId" binding:"omitempty,exists=departments"`
+	Position     string                         `json:"position" binding:"max=100"`
+	Active       *bool                          `json:"active"`
+	Tasks        []ChecklistTemplateTaskRequest `json:"tasks" binding:"required,min=1,dive"`
+}
+
+// ChecklistTemplateTaskRequest represents one task of a template. The task is
+// due dueOffsetDays after the hire date for onboarding, or after the
+// termination date for offboarding.
+type ChecklistTemplateTaskRequest struct {
+	Title         string `json:"title" binding:"required,max=200"`
+	Description   string `json:"description" binding:"max=1000"`
+	Assignee      string `json:"assignee" binding:"required,oneof=hr manager employee"`
+	DueOffsetDays int    `json:"dueOffsetDays" binding:"gte=-365,lte=365"`
+}
+
+func newChecklistTemplateRequest(template models.ChecklistTemplate) ChecklistTemplateRequest {
+	active := template.Active
+	req := ChecklistTemplateRequest{
+		Name:         template.Name,
+		Kind:         template.Kind,
+		DepartmentID: template.DepartmentID,
+		Position:     template.Position,
+		Active:       &active,
+		Tasks:        make([]ChecklistTemplateTaskRequest, len(template.Tasks)),
+	}
+	for i, task := range template.Tasks {
+		req.Tasks[i] = ChecklistTemplateTaskRequest{
+			Title:         task.Title,
+			Description:   task.Description,
+			Assignee:      task.Assignee,
+			DueOffsetDays: task.DueOffsetDays,
+		}
+	}
+	return req
+}
+
+func (r ChecklistTemplateRequest) input() services.ChecklistTemplateInput {
+	input := services.ChecklistTemplateInput{
+		Name:         r.Name,
+		Kind:         r.Kind,
+		DepartmentID: r.DepartmentID,
+		Position:     r.Position,
+		Active:       r.Active == nil || *r.Active,
+		Tasks:        make([]services.ChecklistTemplateTaskInput, len(r.Tasks)),
+	}
+	for i, task := range r.Tasks {
+		input.Tasks[i] = services.ChecklistTemplateTaskInput{
+			Title:         task.Title,
+			Description:   task.Description,
+			Assignee:      task.Assignee,
+			DueOffsetDays: task.DueOffsetDays,
+		}
+	}
+	return input
+}
+
+// StartChecklistRequest names the checklist to start for an employee
+type StartChecklistRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=onboarding offboarding"`
+}
+
+// UpdateChecklistTaskRequest represents the fields editable on a checklist
+// task. Only HR may change the due date.
+type UpdateChecklistTaskRequest struct {
+	Status  string    `json:"status" binding:"required,oneof=pending done skipped"`
+	Notes   string    `json:"notes" binding:"max=1000"`
+	DueDate time.Time `json:"dueDate" binding:"required"`
+}
+
+func newUpdateChecklistTaskRequest(task models.ChecklistTask) UpdateChecklistTaskRequest {
+	return UpdateChecklistTaskRequest{
+		Status:  task.Status,
+		Notes:   task.Notes,
+		DueDate: task.DueDate,
+	}
+}
+
+func (r UpdateChecklistTaskRequest) input() services.ChecklistTaskInput {
+	return services.ChecklistTaskInput{
+		Status:  r.Status,
+		Notes:   r.Notes,
+		DueDate: r.DueDate,
+	}
+}
+
+// ChecklistProgressResponse summarises one of an employee's checklists.
+// Percent counts skipped tasks as finished.
+type ChecklistProgressResponse struct {
+	Kind    string                 `json:"kind"`
+	Total   int                    `json:"total"`
+	Done    int                    `json:"done"`
+	Skipped int                    `json:"skipped"`
+	Overdue int                    `json:"overdue"`
+	Percent float64                `json:"percent"`
+	Tasks   []models.ChecklistTask `json:"tasks"`
+}
+
+// OverdueChecklistTaskResponse is a pending task past its due date
+type OverdueChecklistTaskResponse struct {
+	ID             uint   `json:"id"`
+	EmployeeID     uint   `json:"employeeId"`
+	EmployeeName   string `json:"employeeName"`
+	Department     string `json:"department"`
+	Kind           string `json:"kind"`
+	Title          string `json:"title"`
+	Assignee       string `json:"assignee"`
+	AssigneeUserID *uint  `json:"assigneeUserId"`
+	AssigneeName   string `json:"assigneeName"`
+	DueDate        string `json:"dueDate"`
+	DaysOverdue    int    `json:"daysOverdue"`
+}
+
+func newOverdueChecklistTaskResponses(tasks []repositories.OverdueChecklistTask, today time.Time) []OverdueChecklistTaskResponse {
+	response := make([]OverdueChecklistTaskResponse, len(tasks))
+	for i, task := range tasks {
+		response[i] = OverdueChecklistTaskResponse{
+			ID:             task.ID,
+			EmployeeID:     task.EmployeeID,
+			EmployeeName:   fullName(task.EmployeeFirstName, task.EmployeeLastName),
+			Department:     task.DepartmentName,
+			Kind:           task.Kind,
+			Title:          task.Title,
+			Assignee:       task.Assignee,
+			AssigneeUserID: task.AssigneeUserID,
+			AssigneeName:   fullName(task.AssigneeFirstName, task.AssigneeLastName),
+			DueDate:        task.DueDate.Format("2006-01-02"),
+			DaysOverdue:    int(today.Sub(task.DueDate).Hours() / 24),
+		}
+	}
+	return response
+}
+
+type ChecklistController struct {
+	checklists *services.ChecklistService
+}
+
+func NewChecklistController(checklists *services.ChecklistService) *ChecklistController {
+	return &ChecklistController{checklists: checklists}
+}
+
+// GetChecklistTemplates - HR lists templates, optionally of one kind
+func (cc *ChecklistController) GetChecklistTemplates(c *gin.Context) {
+	templates, err := cc.checklists.ListTemplates(c.Query("kind"))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch checklist templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+func (cc *ChecklistController) GetChecklistTemplate(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid template ID"})
+		return
+	}
+
+	template, err := cc.checklists.GetTemplate(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch checklist template")
+		return
+	}
+
+	setETag(c, template.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// CreateChecklistTemplate - HR adds a template. It applies to employees hired
+// or terminated from now on; use StartChecklist for existing employees.
+func (cc *ChecklistController) CreateChecklistTemplate(c *gin.Context) {
+	var req ChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	template, err := cc.checklists.CreateTemplate(req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create checklist template")
+		return
+	}
+	setETag(c, template.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+		"message": "Checklist template created successfully",
+	})
+}
+
+// UpdateChecklistTemplate - HR edits a template. Tasks already created from it
+// are not changed.
+func (cc *ChecklistController) UpdateChecklistTemplate(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid template ID"})
+		return
+	}
+
+	template, err := cc.checklists.GetTemplate(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch checklist template")
+		return
+	}
+
+	if !ifMatchSatisfied(c, template.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Checklist template has been modified by another request",
+		})
+		return
+	}
+
+	req := newChecklistTemplateRequest(*template)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := cc.checklists.UpdateTemplate(template, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update checklist template")
+		return
+	}
+	setETag(c, template.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+		"message": "Checklist template updated successfully",
+	})
+}
+
+func (cc *ChecklistController) DeleteChecklistTemplate(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid template ID"})
+		return
+	}
+
+	if err := cc.checklists.DeleteTemplate(id); err != nil {
+		respondFailure(c, err, "Failed to delete checklist template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Checklist template deleted successfully",
+	})
+}
+
+// GetEmployeeChecklists - HR, the employee's managers and the employee see
+// progress on the employee's checklists
+func (cc *ChecklistController) GetEmployeeChecklists(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	progress, err := cc.checklists.Progress(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch checklists")
+		return
+	}
+
+	response := make([]ChecklistProgressResponse, len(progress))
+	for i, checklist := range progress {
+		response[i] = ChecklistProgressResponse{
+			Kind:    checklist.Kind,
+			Total:   checklist.Total,
+			Done:    checklist.Done,
+			Skipped: checklist.Skipped,
+			Overdue: checklist.Overdue,
+			Percent: checklist.Percent,
+			Tasks:   checklist.Tasks,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// StartChecklist - HR creates an employee's tasks from templates that have not
+// been applied to them yet
+func (cc *ChecklistController) StartChecklist(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	var req StartChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	tasks, err := cc.checklists.Start(id, req.Kind)
+	if err != nil {
+		respondFailure(c, err, "Failed to start checklist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    tasks,
+		"message": strconv.Itoa(len(tasks)) + " checklist tasks created",
+	})
+}
+
+// GetChecklistTasks - HR lists any tasks; everyone else the tasks assigned to them
+func (cc *ChecklistController) GetChecklistTasks(c *gin.Context) {
+	filter := repositories.ChecklistTaskFilter{Status: c.Query("status")}
+	if value := c.Query("employeeId"); value != "" {
+		employeeID, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+			return
+		}
+		filter.EmployeeID = uint(employeeID)
+	}
+
+	tasks, err := cc.checklists.ListTasks(currentActor(c), filter)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch checklist tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tasks,
+	})
+}
+
+// UpdateChecklistTask - The assignee or HR completes, skips or reopens a task
+func (cc *ChecklistController) UpdateChecklistTask(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid task ID"})
+		return
+	}
+
+	actor := currentActor(c)
+	task, err := cc.checklists.GetTask(actor, id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch checklist task")
+		return
+	}
+
+	if !ifMatchSatisfied(c, task.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Checklist task has been modified by another request",
+		})
+		return
+	}
+
+	req := newUpdateChecklistTaskRequest(*task)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := cc.checklists.UpdateTask(actor, task, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update checklist task")
+		return
+	}
+	setETag(c, task.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+		"message": "Checklist task updated successfully",
+	})
+}
+
+// GetOverdueChecklistTasks - HR sees every overdue task, managers those of
+// their department's employees
+func (cc *ChecklistController) GetOverdueChecklistTasks(c *gin.Context) {
+	tasks, err := cc.checklists.Overdue(currentActor(c))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch overdue checklist tasks")
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newOverdueChecklistTaskResponses(tasks, today),
+	})
+}
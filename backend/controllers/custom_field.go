@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCustomFieldRequest represents the payload accepted when defining a
+// custom field. maxLength and pattern apply to text fields, min and max to
+// number fields and options to enum fields; a pattern must match the whole
+// value.
+type CreateCustomFieldRequest struct {
+	Entity    string   `json:"entity" binding:"required,oneof=employee department"`
+	Key       string   `json:"key" binding:"required,max=50"`
+	Label     string   `json:"label" binding:"required,max=100"`
+	Type      string   `json:"type" binding:"required,oneof=text number date enum"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options" binding:"omitempty,max=100,dive,required,max=100"`
+	MaxLength *int     `json:"maxLength" binding:"omitempty,gte=1,lte=10000"`
+	Pattern   string   `json:"pattern" binding:"max=255"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+}
+
+// UpdateCustomFieldRequest represents the fields editable on an existing
+// custom field; its entity, key and type are fixed
+type UpdateCustomFieldRequest struct {
+	Label     string   `json:"label" binding:"required,max=100"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options" binding:"omitempty,max=100,dive,required,max=100"`
+	MaxLength *int     `json:"maxLength" binding:"omitempty,gte=1,lte=10000"`
+	Pattern   string   `json:"pattern" binding:"max=255"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+}
+
+func (r CreateCustomFieldRequest) input() services.CustomFieldInput {
+	return services.CustomFieldInput{
+		Entity:    r.Entity,
+		Key:       r.Key,
+		Label:     r.Label,
+		Type:      r.Type,
+		Required:  r.Required,
+		Options:   r.Options,
+		MaxLength: r.MaxLength,
+		Pattern:   r.Pattern,
+		Min:       r.Min,
+		Max:       r.Max,
+	}
+}
+
+func newUpdateCustomFieldRequest(field models.CustomField) UpdateCustomFieldRequest {
+	return UpdateCustomFieldRequest{
+		Label:     field.Label,
+		Required:  field.Required,
+		Options:   field.Options,
+		MaxLength: field.MaxLength,
+		Pattern:   field.Pattern,
+		Min:       field.Min,
+		Max:       field.Max,
+	}
+}
+
+func (r UpdateCustomFieldRequest) input() services.CustomFieldInput {
+	return services.CustomFieldInput{
+		Label:     r.Label,
+		Required:  r.Required,
+		Options:   r.Options,
+		MaxLength: r.MaxLength,
+		Pattern:   r.Pattern,
+		Min:       r.Min,
+		Max:       r.Max,
+	}
+}
+
+type CustomFieldController struct {
+	fields *services.CustomFieldService
+}
+
+func NewCustomFieldController(fields *services.CustomFieldService) *CustomFieldController {
+	return &CustomFieldController{fields: fields}
+}
+
+// GetCustomFields - All roles list the custom fields, of one ?entity= or of
+// every entity, so clients can show and edit them
+func (cc *CustomFieldController) GetCustomFields(c *gin.Context) {
+	entity := c.Query("entity")
+	if entity != "" && entity != "employee" && entity != "department" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "entity must be employee or department"})
+		return
+	}
+
+	fields, err := cc.fields.List(entity)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch custom fields")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    fields,
+	})
+}
+
+func (cc *CustomFieldController) GetCustomField(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid custom field ID"})
+		return
+	}
+
+	field, err := cc.fields.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch custom field")
+		return
+	}
+	setETag(c, field.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    field,
+	})
+}
+
+// CreateCustomField - Admin defines a custom field
+func (cc *CustomFieldController) CreateCustomField(c *gin.Context) {
+	var req CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	field, err := cc.fields.Create(req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create custom field")
+		return
+	}
+	setETag(c, field.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    field,
+		"message": "Custom field created successfully",
+	})
+}
+
+// UpdateCustomField - Admin changes a field's label or rules. Stored values
+// are checked against the new rules when their record is next saved.
+func (cc *CustomFieldController) UpdateCustomField(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid custom field ID"})
+		return
+	}
+
+	field, err := cc.fields.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch custom field")
+		return
+	}
+
+	if !ifMatchSatisfied(c, field.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Custom field has been modified by another request",
+		})
+		return
+	}
+
+	req := newUpdateCustomFieldRequest(*field)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := cc.fields.Update(field, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update custom field")
+		return
+	}
+	setETag(c, field.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    field,
+		"message": "Custom field updated successfully",
+	})
+}
+
+// DeleteCustomField - Admin removes a field and every record's value for it
+func (cc *CustomFieldController) DeleteCustomField(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid custom field ID"})
+		return
+	}
+
+	if err := cc.fields.Delete(id); err != nil {
+		respondFailure(c, err, "Failed to delete custom field")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Custom field deleted successfully",
+	})
+}
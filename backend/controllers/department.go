@@ -2,11 +2,12 @@ package controllers
 
 import (
 	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // DepartmentResponse represents the department data structure expected by frontend
@@ -16,38 +17,85 @@ type DepartmentResponse struct {
 	Description      string `json:"description"`
 	HeadOfDepartment string `json:"headOfDepartment,omitempty"`
 	EmployeeCount    int    `json:"employeeCount,omitempty"`
+	Version          uint   `json:"version"`
+	// CustomFields holds the department's custom field values by key
+	CustomFields models.CustomValues `json:"customFields"`
 }
 
-// Helper function to convert model to response format
-func (dc *DepartmentController) transformDepartmentResponse(dept models.Department) DepartmentResponse {
-	headOfDepartment := ""
-	if dept.Manager != nil {
-		headOfDepartment = dept.Manager.FirstName + " " + dept.Manager.LastName
-	}
-
-	employeeCount := len(dept.Employees)
-
+// Helper function to convert a department summary to response format
+func (dc *DepartmentController) transformDepartmentResponse(dept repositories.DepartmentSummary) DepartmentResponse {
 	return DepartmentResponse{
-		ID:               strconv.Itoa(int(dept.Model.ID)),
+		ID:               strconv.Itoa(int(dept.ID)),
 		Name:             dept.Name,
 		Description:      dept.Description,
-		HeadOfDepartment: headOfDepartment,
-		EmployeeCount:    employeeCount,
+		HeadOfDepartment: fullName(dept.ManagerFirstName, dept.ManagerLastName),
+		EmployeeCount:    dept.EmployeeCount,
+		Version:          dept.Version,
+		CustomFields:     dept.CustomFields,
+	}
+}
+
+// CreateDepartmentRequest represents the payload accepted when creating a department
+type CreateDepartmentRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	ManagerID   *uint  `json:"managerId" binding:"omitempty,exists=employees"`
+	// CustomFields holds values for the custom fields defined for departments, by key
+	CustomFields models.CustomValues `json:"customFields"`
+}
+
+// UpdateDepartmentRequest is the writable view of a department. Updates are
+// sent as a JSON Merge Patch against this view, so a null managerId clears
+// the head of department.
+type UpdateDepartmentRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	ManagerID   *uint  `json:"managerId" binding:"omitempty,exists=employees"`
+	// CustomFields is merged key by key; set a key to null to clear it
+	CustomFields models.CustomValues `json:"customFields"`
+}
+
+func (r CreateDepartmentRequest) input() services.DepartmentInput {
+	return services.DepartmentInput{
+		Name:         r.Name,
+		Description:  r.Description,
+		ManagerID:    r.ManagerID,
+		CustomFields: r.CustomFields,
+	}
+}
+
+func newUpdateDepartmentRequest(dept models.Department) UpdateDepartmentRequest {
+	return UpdateDepartmentRequest{
+		Name:         dept.Name,
+		Description:  dept.Description,
+		ManagerID:    dept.ManagerID,
+		CustomFields: dept.CustomFields,
+	}
+}
+
+func (r UpdateDepartmentRequest) input() services.DepartmentInput {
+	return services.DepartmentInput{
+		Name:         r.Name,
+		Description:  r.Description,
+		ManagerID:    r.ManagerID,
+		CustomFields: r.CustomFields,
 	}
 }
 
 type DepartmentController struct {
-	db *gorm.DB
+	departments *services.DepartmentService
 }
 
-func NewDepartmentController(db *gorm.DB) *DepartmentController {
-	return &DepartmentController{db: db}
+func NewDepartmentController(departments *services.DepartmentService) *DepartmentController {
+	return &DepartmentController{departments: departments}
 }
 
+// GetDepartments lists departments; ?custom[key]=value keeps those whose
+// custom field key holds value
 func (dc *DepartmentController) GetDepartments(c *gin.Context) {
-	var departments []models.Department
-	if err := dc.db.Preload("Manager").Preload("Employees").Find(&departments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch departments"})
+	departments, err := dc.departments.List(c.QueryMap("custom"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch departments")
 		return
 	}
 
@@ -61,31 +109,33 @@ func (dc *DepartmentController) GetDepartments(c *gin.Context) {
 }
 
 func (dc *DepartmentController) GetDepartment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
 		return
 	}
 
-	var department models.Department
-	if err := dc.db.Preload("Manager").Preload("Employees").First(&department, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+	department, err := dc.departments.Summary(id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch department")
 		return
 	}
 
-	response := dc.transformDepartmentResponse(department)
+	setETag(c, department.Version)
+	response := dc.transformDepartmentResponse(*department)
 	c.JSON(http.StatusOK, response)
 }
 
 func (dc *DepartmentController) CreateDepartment(c *gin.Context) {
-	var department models.Department
-	if err := c.ShouldBindJSON(&department); err != nil {
+	var req CreateDepartmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := dc.db.Create(&department).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create department"})
+	department, err := dc.departments.Create(req.input())
+	if err != nil {
+		respondError(c, err, "Failed to create department")
 		return
 	}
 
@@ -93,41 +143,59 @@ func (dc *DepartmentController) CreateDepartment(c *gin.Context) {
 }
 
 func (dc *DepartmentController) UpdateDepartment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
 		return
 	}
 
-	var department models.Department
-	if err := dc.db.First(&department, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+	department, err := dc.departments.Get(id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch department")
 		return
 	}
 
-	var updateData models.Department
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !ifMatchSatisfied(c, department.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Department has been modified by another request"})
+		return
+	}
+
+	req := newUpdateDepartmentRequest(*department)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := dc.db.Model(&department).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update department"})
+	if err := dc.departments.Update(department, req.input()); err != nil {
+		respondError(c, err, "Failed to update department")
 		return
 	}
+	setETag(c, department.Version)
 
 	c.JSON(http.StatusOK, department)
 }
 
 func (dc *DepartmentController) DeleteDepartment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
 		return
 	}
 
-	if err := dc.db.Delete(&models.Department{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete department"})
+	// Employees still in the department are moved to reassignTo, if given
+	var reassignTo *uint
+	if value := c.Query("reassignTo"); value != "" {
+		target, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reassignTo department ID"})
+			return
+		}
+		targetID := uint(target)
+		reassignTo = &targetID
+	}
+
+	if err := dc.departments.Delete(id, reassignTo); err != nil {
+		respondError(c, err, "Failed to delete department")
 		return
 	}
 
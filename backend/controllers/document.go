@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formOverhead allows for the multipart boundaries and text fields sent
+// alongside an uploaded file
+const formOverhead = 1 << 20
+
+// UploadDocumentRequest is a multipart/form-data upload. The name defaults to
+// the file name; visibility defaults to hr, and is always employee for
+// employees uploading their own documents.
+type UploadDocumentRequest struct {
+	File       *multipart.FileHeader `json:"file" form:"file" binding:"required"`
+	Category   string                `json:"category" form:"category" binding:"required,oneof=contract id certificate other"`
+	Name       string                `json:"name" form:"name" binding:"max=255"`
+	Visibility string                `json:"visibility" form:"visibility" binding:"omitempty,oneof=hr manager employee"`
+	// ExpiresAt is a date such as 2027-03-31
+	ExpiresAt *time.Time `json:"expiresAt" form:"expiresAt" time_format:"2006-01-02"`
+}
+
+// UpdateDocumentRequest represents the editable details of a document
+type UpdateDocumentRequest struct {
+	Name       string     `json:"name" binding:"required,max=255"`
+	Category   string     `json:"category" binding:"required,oneof=contract id certificate other"`
+	Visibility string     `json:"visibility" binding:"required,oneof=hr manager employee"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+func newUpdateDocumentRequest(document models.Document) UpdateDocumentRequest {
+	return UpdateDocumentRequest{
+		Name:       document.Name,
+		Category:   document.Category,
+		Visibility: document.Visibility,
+		ExpiresAt:  document.ExpiresAt,
+	}
+}
+
+func (r UpdateDocumentRequest) input() services.DocumentInput {
+	return services.DocumentInput{
+		Name:       r.Name,
+		Category:   r.Category,
+		Visibility: r.Visibility,
+		ExpiresAt:  r.ExpiresAt,
+	}
+}
+
+// ExpiringDocumentResponse is a document that has expired or expires soon.
+// DaysLeft is negative once it has expired.
+type ExpiringDocumentResponse struct {
+	ID           uint   `json:"id"`
+	EmployeeID   uint   `json:"employeeId"`
+	EmployeeName string `json:"employeeName"`
+	Department   string `json:"department"`
+	Category     string `json:"category"`
+	Name         string `json:"name"`
+	ExpiresAt    string `json:"expiresAt"`
+	DaysLeft     int    `json:"daysLeft"`
+	Expired      bool   `json:"expired"`
+}
+
+func newExpiringDocumentResponses(documents []repositories.ExpiringDocument, today time.Time) []ExpiringDocumentResponse {
+	response := make([]ExpiringDocumentResponse, len(documents))
+	for i, document := range documents {
+		expires := document.ExpiresAt.UTC().Truncate(24 * time.Hour)
+		response[i] = ExpiringDocumentResponse{
+			ID:           document.ID,
+			EmployeeID:   document.EmployeeID,
+			EmployeeName: fullName(document.EmployeeFirstName, document.EmployeeLastName),
+			Department:   document.DepartmentName,
+			Category:     document.Category,
+			Name:         document.Name,
+			ExpiresAt:    expires.Format("2006-01-02"),
+			DaysLeft:     int(expires.Sub(today).Hours() / 24),
+			Expired:      expires.Before(today),
+		}
+	}
+	return response
+}
+
+type DocumentController struct {
+	documents *services.DocumentService
+}
+
+func NewDocumentController(documents *services.DocumentService) *DocumentController {
+	return &DocumentController{documents: documents}
+}
+
+// GetEmployeeDocuments - HR sees every document of an employee, managers those
+// of their department shared with managers and employees their own shared
+// with them
+func (dc *DocumentController) GetEmployeeDocuments(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	documents, err := dc.documents.List(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch documents")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    documents,
+	})
+}
+
+// UploadDocument - HR uploads documents for any employee, employees for
+// themselves. The request body is limited to the maximum document size.
+func (dc *DocumentController) UploadDocument(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	maxSize := dc.documents.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+formOverhead)
+	var req UploadDocumentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("Documents must be at most %d bytes", maxSize),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	file, err := req.File.Open()
+	if err != nil {
+		respondFailure(c, err, "Failed to read uploaded file")
+		return
+	}
+	defer file.Close()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = filepath.Base(req.File.Filename)
+	}
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = "hr"
+	}
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		date := req.ExpiresAt.UTC().Truncate(24 * time.Hour)
+		expiresAt = &date
+	}
+
+	document, err := dc.documents.Upload(c.Request.Context(), currentActor(c), id, services.DocumentUpload{
+		Name:       name,
+		Category:   req.Category,
+		Visibility: visibility,
+		ExpiresAt:  expiresAt,
+		Size:       req.File.Size,
+		Content:    file,
+	})
+	if err != nil {
+		respondFailure(c, err, "Failed to upload document")
+		return
+	}
+	setETag(c, document.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    document,
+		"message": "Document uploaded successfully",
+	})
+}
+
+// GetDocument - the details of a document the caller may see
+func (dc *DocumentController) GetDocument(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid document ID"})
+		return
+	}
+
+	document, err := dc.documents.Get(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch document")
+		return
+	}
+	setETag(c, document.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    document,
+	})
+}
+
+// DownloadDocument - the contents of a document the caller may see, as an
+// attachment with the type detected at upload
+func (dc *DocumentController) DownloadDocument(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid document ID"})
+		return
+	}
+
+	document, content, err := dc.documents.Open(c.Request.Context(), currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to download document")
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.Name}))
+	c.Header("Content-Length", strconv.FormatInt(document.Size, 10))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Type", document.ContentType)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, content); err != nil {
+		c.Error(err)
+	}
+}
+
+// UpdateDocument - HR edits a document's details; the contents cannot change
+func (dc *DocumentController) UpdateDocument(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid document ID"})
+		return
+	}
+
+	document, err := dc.documents.Get(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch document")
+		return
+	}
+
+	if !ifMatchSatisfied(c, document.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Document has been modified by another request",
+		})
+		return
+	}
+
+	req := newUpdateDocumentRequest(*document)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if req.ExpiresAt != nil {
+		date := req.ExpiresAt.UTC().Truncate(24 * time.Hour)
+		req.ExpiresAt = &date
+	}
+
+	if err := dc.documents.Update(document, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update document")
+		return
+	}
+	setETag(c, document.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    document,
+		"message": "Document updated successfully",
+	})
+}
+
+// DeleteDocument - HR deletes any document, others the documents they uploaded
+func (dc *DocumentController) DeleteDocument(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid document ID"})
+		return
+	}
+
+	if err := dc.documents.Delete(currentActor(c), id); err != nil {
+		respondFailure(c, err, "Failed to delete document")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Document deleted successfully",
+	})
+}
+
+// GetExpiringDocuments - documents expired or expiring within ?days=
+// (default 30): every document for HR, their department's documents shared
+// with managers for managers
+func (dc *DocumentController) GetExpiringDocuments(c *gin.Context) {
+	days := 30
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > 3650 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "days must be a whole number between 0 and 3650"})
+			return
+		}
+		days = parsed
+	}
+
+	documents, err := dc.documents.Expiring(currentActor(c), days)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch expiring documents")
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newExpiringDocumentResponses(documents, today),
+	})
+}
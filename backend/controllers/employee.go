@@ -2,149 +2,330 @@ package controllers
 
 import (
 	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // EmployeeResponse represents the employee data structure expected by frontend
 type EmployeeResponse struct {
-	ID         string  `json:"id"`
-	Name       string  `json:"name"`
-	Email      string  `json:"email"`
-	Phone      string  `json:"phone"`
-	Department string  `json:"department"`
-	Position   string  `json:"position"`
-	JoinDate   string  `json:"joinDate"`
-	Status     string  `json:"status"`
-	Salary     float64 `json:"salary"`
+	ID           string  `json:"id"`
+	EmployeeCode string  `json:"employeeCode"`
+	Name         string  `json:"name"`
+	Email        string  `json:"email"`
+	Phone        string  `json:"phone"`
+	Department   string  `json:"department"`
+	Position     string  `json:"position"`
+	JoinDate     string  `json:"joinDate"`
+	Status       string  `json:"status"`
+	Salary       float64 `json:"salary"`
+	Version      uint    `json:"version"`
+	// CustomFields holds the employee's custom field values by key
+	CustomFields models.CustomValues `json:"customFields"`
 }
 
 // Helper function to convert model to response format
 func (ec *EmployeeController) transformEmployeeResponse(emp models.Employee) EmployeeResponse {
-	departmentName := ""
-	if emp.Department.Name != "" {
-		departmentName = emp.Department.Name
-	}
+	return ec.transformEmployeeSummary(repositories.EmployeeSummary{
+		ID:             emp.ID,
+		EmployeeCode:   emp.EmployeeCode,
+		FirstName:      emp.FirstName,
+		LastName:       emp.LastName,
+		Email:          emp.Email,
+		Phone:          emp.Phone,
+		Position:       emp.Position,
+		HireDate:       emp.HireDate,
+		Status:         emp.Status,
+		Salary:         emp.Salary,
+		Version:        emp.Version,
+		DepartmentName: emp.Department.Name,
+		CustomFields:   emp.CustomFields,
+	})
+}
 
+// Helper function to convert a listed employee to response format
+func (ec *EmployeeController) transformEmployeeSummary(emp repositories.EmployeeSummary) EmployeeResponse {
 	joinDate := ""
 	if !emp.HireDate.IsZero() {
 		joinDate = emp.HireDate.Format("2006-01-02")
 	}
 
 	return EmployeeResponse{
-		ID:         strconv.Itoa(int(emp.Model.ID)),
-		Name:       emp.FirstName + " " + emp.LastName,
-		Email:      emp.Email,
-		Phone:      emp.Phone,
-		Department: departmentName,
-		Position:   emp.Position,
-		JoinDate:   joinDate,
-		Status:     emp.Status,
-		Salary:     emp.Salary,
+		ID:           strconv.Itoa(int(emp.ID)),
+		EmployeeCode: emp.EmployeeCode,
+		Name:         emp.FirstName + " " + emp.LastName,
+		Email:        emp.Email,
+		Phone:        emp.Phone,
+		Department:   emp.DepartmentName,
+		Position:     emp.Position,
+		JoinDate:     joinDate,
+		Status:       emp.Status,
+		Salary:       emp.Salary,
+		Version:      emp.Version,
+		CustomFields: emp.CustomFields,
+	}
+}
+
+// fullName joins a projected first and last name, or is empty when the
+// person was not found
+func fullName(firstName, lastName *string) string {
+	if firstName == nil || lastName == nil {
+		return ""
+	}
+	return *firstName + " " + *lastName
+}
+
+// CreateEmployeeRequest represents the payload accepted when creating an
+// employee. employeeCode may be left out when EMPLOYEE_CODE_PATTERN is set,
+// to have it generated.
+type CreateEmployeeRequest struct {
+	EmployeeCode string     `json:"employeeCode" binding:"max=50"`
+	FirstName    string     `json:"firstName" binding:"required,max=100"`
+	LastName     string     `json:"lastName" binding:"required,max=100"`
+	Email        string     `json:"email" binding:"required,email"`
+	Phone        string     `json:"phone" binding:"max=30"`
+	Address      string     `json:"address" binding:"max=255"`
+	DateOfBirth  *time.Time `json:"dateOfBirth" binding:"omitempty,ltfield=HireDate"`
+	HireDate     time.Time  `json:"hireDate" binding:"required"`
+	Salary       float64    `json:"salary" binding:"gte=0"`
+	Position     string     `json:"position" binding:"required,max=100"`
+	Status       string     `json:"status" binding:"omitempty,oneof=active inactive"`
+	DepartmentID uint       `json:"departmentId" binding:"required,exists=departments"`
+	ManagerID    *uint      `json:"managerId" binding:"omitempty,exists=employees"`
+	// CustomFields holds values for the custom fields defined for employees, by key
+	CustomFields models.CustomValues `json:"customFields"`
+	// Account optionally opens a login for the new employee in the same transaction
+	Account *CreateEmployeeAccountRequest `json:"account"`
+}
+
+// CreateEmployeeAccountRequest is the login created together with an employee;
+// it takes the employee's email and name
+type CreateEmployeeAccountRequest struct {
+	Password string `json:"password" binding:"required,min=8,max=72"`
+	Role     string `json:"role" binding:"omitempty,oneof=admin hr manager employee"`
+}
+
+// UpdateEmployeeRequest is the writable view of an employee. Updates are sent
+// as a JSON Merge Patch against this view, so explicit nulls and zero values
+// are written instead of being skipped.
+type UpdateEmployeeRequest struct {
+	EmployeeCode string     `json:"employeeCode" binding:"required,max=50"`
+	FirstName    string     `json:"firstName" binding:"required,max=100"`
+	LastName     string     `json:"lastName" binding:"required,max=100"`
+	Email        string     `json:"email" binding:"required,email"`
+	Phone        string     `json:"phone" binding:"max=30"`
+	Address      string     `json:"address" binding:"max=255"`
+	DateOfBirth  *time.Time `json:"dateOfBirth" binding:"omitempty,ltfield=HireDate"`
+	HireDate     time.Time  `json:"hireDate" binding:"required"`
+	Salary       float64    `json:"salary" binding:"gte=0"`
+	Position     string     `json:"position" binding:"required,max=100"`
+	Status       string     `json:"status" binding:"required,oneof=active inactive terminated"`
+	DepartmentID uint       `json:"departmentId" binding:"required,exists=departments"`
+	ManagerID    *uint      `json:"managerId" binding:"omitempty,exists=employees"`
+	// CustomFields is merged key by key; set a key to null to clear it
+	CustomFields models.CustomValues `json:"customFields"`
+}
+
+func (r CreateEmployeeRequest) input() services.EmployeeInput {
+	var account *services.AccountInput
+	if r.Account != nil {
+		account = &services.AccountInput{Password: r.Account.Password, Role: r.Account.Role}
+	}
+
+	return services.EmployeeInput{
+		EmployeeCode: r.EmployeeCode,
+		FirstName:    r.FirstName,
+		LastName:     r.LastName,
+		Email:        r.Email,
+		Phone:        r.Phone,
+		Address:      r.Address,
+		DateOfBirth:  r.DateOfBirth,
+		HireDate:     r.HireDate,
+		Salary:       r.Salary,
+		Position:     r.Position,
+		Status:       r.Status,
+		DepartmentID: r.DepartmentID,
+		ManagerID:    r.ManagerID,
+		CustomFields: r.CustomFields,
+		Account:      account,
+	}
+}
+
+func newUpdateEmployeeRequest(emp models.Employee) UpdateEmployeeRequest {
+	return UpdateEmployeeRequest{
+		EmployeeCode: emp.EmployeeCode,
+		FirstName:    emp.FirstName,
+		LastName:     emp.LastName,
+		Email:        emp.Email,
+		Phone:        emp.Phone,
+		Address:      emp.Address,
+		DateOfBirth:  emp.DateOfBirth,
+		HireDate:     emp.HireDate,
+		Salary:       emp.Salary,
+		Position:     emp.Position,
+		Status:       emp.Status,
+		DepartmentID: emp.DepartmentID,
+		ManagerID:    emp.ManagerID,
+		CustomFields: emp.CustomFields,
+	}
+}
+
+func (r UpdateEmployeeRequest) input() services.EmployeeInput {
+	return services.EmployeeInput{
+		EmployeeCode: r.EmployeeCode,
+		FirstName:    r.FirstName,
+		LastName:     r.LastName,
+		Email:        r.Email,
+		Phone:        r.Phone,
+		Address:      r.Address,
+		DateOfBirth:  r.DateOfBirth,
+		HireDate:     r.HireDate,
+		Salary:       r.Salary,
+		Position:     r.Position,
+		Status:       r.Status,
+		DepartmentID: r.DepartmentID,
+		ManagerID:    r.ManagerID,
+		CustomFields: r.CustomFields,
 	}
 }
 
 type EmployeeController struct {
-	db *gorm.DB
+	employees *services.EmployeeService
 }
 
-func NewEmployeeController(db *gorm.DB) *EmployeeController {
-	return &EmployeeController{db: db}
+func NewEmployeeController(employees *services.EmployeeService) *EmployeeController {
+	return &EmployeeController{employees: employees}
 }
 
+// GetEmployees lists employees; ?custom[key]=value keeps those whose custom
+// field key holds value
 func (ec *EmployeeController) GetEmployees(c *gin.Context) {
-	var employees []models.Employee
-	if err := ec.db.Preload("Department").Preload("Manager").Preload("User").Find(&employees).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+	employees, err := ec.employees.List(c.QueryMap("custom"))
+	if err != nil {
+		respondError(c, err, "Failed to fetch employees")
 		return
 	}
 
 	// Transform to frontend expected format
 	var response []EmployeeResponse
 	for _, emp := range employees {
-		response = append(response, ec.transformEmployeeResponse(emp))
+		response = append(response, ec.transformEmployeeSummary(emp))
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// CodeCheckResponse lists the employees whose codes do not fit the code
+// pattern; the pattern is empty when codes are entered by hand
+type CodeCheckResponse struct {
+	Pattern    string                 `json:"pattern"`
+	Mismatches []CodeMismatchResponse `json:"mismatches"`
+}
+
+// CodeMismatchResponse is an employee whose code does not fit the pattern
+type CodeMismatchResponse struct {
+	ID           uint   `json:"id"`
+	EmployeeCode string `json:"employeeCode"`
+	Name         string `json:"name"`
+}
+
+// GetCodeCheck - HR lists the employees whose codes do not fit the
+// configured code pattern, to correct them by hand
+func (ec *EmployeeController) GetCodeCheck(c *gin.Context) {
+	mismatches, err := ec.employees.CodeMismatches()
+	if err != nil {
+		respondError(c, err, "Failed to check employee codes")
+		return
+	}
+
+	response := CodeCheckResponse{Pattern: ec.employees.CodePattern(), Mismatches: make([]CodeMismatchResponse, len(mismatches))}
+	for i, mismatch := range mismatches {
+		response.Mismatches[i] = CodeMismatchResponse{ID: mismatch.ID, EmployeeCode: mismatch.EmployeeCode, Name: mismatch.Name}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 func (ec *EmployeeController) GetEmployee(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
 		return
 	}
 
-	var employee models.Employee
-	if err := ec.db.Preload("Department").Preload("Manager").Preload("User").First(&employee, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	employee, err := ec.employees.Get(id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch employee")
 		return
 	}
 
-	response := ec.transformEmployeeResponse(employee)
+	setETag(c, employee.Version)
+	response := ec.transformEmployeeResponse(*employee)
 	c.JSON(http.StatusOK, response)
 }
 
 func (ec *EmployeeController) CreateEmployee(c *gin.Context) {
-	var employee models.Employee
-	if err := c.ShouldBindJSON(&employee); err != nil {
+	var req CreateEmployeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := ec.db.Create(&employee).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create employee"})
+	employee, err := ec.employees.Create(currentActor(c), req.input())
+	if err != nil {
+		respondError(c, err, "Failed to create employee")
 		return
 	}
 
-	// Load relationships for response
-	ec.db.Preload("Department").Preload("Manager").Preload("User").First(&employee, employee.Model.ID)
-
+	setETag(c, employee.Version)
 	c.JSON(http.StatusCreated, employee)
 }
 
 func (ec *EmployeeController) UpdateEmployee(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
 		return
 	}
 
-	var employee models.Employee
-	if err := ec.db.First(&employee, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	employee, err := ec.employees.Get(id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch employee")
 		return
 	}
 
-	var updateData models.Employee
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !ifMatchSatisfied(c, employee.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Employee has been modified by another request"})
 		return
 	}
 
-	if err := ec.db.Model(&employee).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update employee"})
+	req := newUpdateEmployeeRequest(*employee)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// Load relationships for response
-	ec.db.Preload("Department").Preload("Manager").Preload("User").First(&employee, employee.Model.ID)
+	if err := ec.employees.Update(employee, req.input()); err != nil {
+		respondError(c, err, "Failed to update employee")
+		return
+	}
+	setETag(c, employee.Version)
 
 	c.JSON(http.StatusOK, employee)
 }
 
 func (ec *EmployeeController) DeleteEmployee(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
 		return
 	}
 
-	if err := ec.db.Delete(&models.Employee{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete employee"})
+	if err := ec.employees.Delete(id); err != nil {
+		respondError(c, err, "Failed to delete employee")
 		return
 	}
 
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"hrms-backend/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordEmploymentChangeRequest represents a job, pay or reporting change.
+// Omitted fields keep their value; set clearManager to remove the manager.
+type RecordEmploymentChangeRequest struct {
+	EffectiveDate time.Time `json:"effectiveDate" binding:"required"`
+	Reason        string    `json:"reason" binding:"required,max=500"`
+	Position      *string   `json:"position" binding:"omitempty,min=1,max=100"`
+	Salary        *float64  `json:"salary" binding:"omitempty,gte=0"`
+	DepartmentID  *uint     `json:"departmentId" binding:"omitempty,exists=departments"`
+	ManagerID     *uint     `json:"managerId" binding:"omitempty,exists=employees"`
+	ClearManager  bool      `json:"clearManager"`
+}
+
+func (r RecordEmploymentChangeRequest) input() services.EmploymentChangeInput {
+	return services.EmploymentChangeInput{
+		EffectiveDate: r.EffectiveDate,
+		Reason:        r.Reason,
+		Position:      r.Position,
+		Salary:        r.Salary,
+		DepartmentID:  r.DepartmentID,
+		ManagerID:     r.ManagerID,
+		ClearManager:  r.ClearManager,
+	}
+}
+
+// EmploymentSnapshotResponse is an employee's job as it stood on a date
+type EmploymentSnapshotResponse struct {
+	EmployeeID   uint    `json:"employeeId"`
+	AsOf         string  `json:"asOf"`
+	Position     string  `json:"position"`
+	Salary       float64 `json:"salary"`
+	DepartmentID uint    `json:"departmentId"`
+	ManagerID    *uint   `json:"managerId"`
+	Since        string  `json:"since"`
+}
+
+type EmploymentController struct {
+	employment *services.EmploymentService
+}
+
+func NewEmploymentController(employment *services.EmploymentService) *EmploymentController {
+	return &EmploymentController{employment: employment}
+}
+
+// GetEmploymentHistory - HR lists an employee's recorded and scheduled changes
+func (ec *EmploymentController) GetEmploymentHistory(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	changes, err := ec.employment.History(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch employment history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// GetEmploymentAsOf - HR reads an employee's job on the asOf date, today by default
+func (ec *EmploymentController) GetEmploymentAsOf(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	asOf := time.Now()
+	if value := c.Query("asOf"); value != "" {
+		if asOf, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "asOf must be a YYYY-MM-DD date"})
+			return
+		}
+	}
+
+	snapshot, err := ec.employment.AsOf(id, asOf)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch employment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": EmploymentSnapshotResponse{
+			EmployeeID:   snapshot.EmployeeID,
+			AsOf:         snapshot.AsOf.Format("2006-01-02"),
+			Position:     snapshot.Position,
+			Salary:       snapshot.Salary,
+			DepartmentID: snapshot.DepartmentID,
+			ManagerID:    snapshot.ManagerID,
+			Since:        snapshot.Since.Format("2006-01-02"),
+		},
+	})
+}
+
+// RecordEmploymentChange - HR records a change. One effective today or earlier
+// updates the employee at once; a later one is scheduled.
+func (ec *EmploymentController) RecordEmploymentChange(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	var req RecordEmploymentChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	change, err := ec.employment.Record(currentActor(c), id, req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to record employment change")
+		return
+	}
+
+	message := "Employment change applied"
+	if change.Status == "scheduled" {
+		message = "Employment change scheduled for " + change.EffectiveDate.Format("2006-01-02")
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    change,
+		"message": message,
+	})
+}
+
+// CancelEmploymentChange - HR withdraws a scheduled change before it takes effect
+func (ec *EmploymentController) CancelEmploymentChange(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+	changeID, err := strconv.ParseUint(c.Param("changeId"), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid change ID"})
+		return
+	}
+
+	if err := ec.employment.Cancel(id, uint(changeID)); err != nil {
+		respondFailure(c, err, "Failed to cancel employment change")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Employment change cancelled",
+	})
+}
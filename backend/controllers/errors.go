@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"hrms-backend/middleware"
+	"hrms-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus maps a service error onto an HTTP status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrStale):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, services.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorMessage returns the client-facing message of a service error, or
+// fallback for unexpected errors whose details must not leak
+func errorMessage(err error, fallback string) string {
+	var serviceErr *services.Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Message
+	}
+	return fallback
+}
+
+// respondError writes err in the {"error": "..."} format
+func respondError(c *gin.Context, err error, fallback string) {
+	c.JSON(errorStatus(err), gin.H{"error": errorMessage(err, fallback)})
+}
+
+// respondFailure writes err in the {"success": false, "message": "..."} format
+func respondFailure(c *gin.Context, err error, fallback string) {
+	c.JSON(errorStatus(err), gin.H{
+		"success": false,
+		"message": errorMessage(err, fallback),
+	})
+}
+
+// currentActor identifies the authenticated caller for service calls
+func currentActor(c *gin.Context) services.Actor {
+	userID, _ := middleware.CurrentUserID(c)
+	return services.Actor{UserID: userID, Role: c.GetString("userRole")}
+}
+
+// parseID reads the numeric :id path parameter
+func parseID(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	return uint(id), err
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag formats a record version as a strong entity tag
+func etag(version uint) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// setETag exposes the record version so clients can send it back in If-Match
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", etag(version))
+}
+
+// ifMatchSatisfied reports whether the If-Match request header, when present,
+// matches the current version of the record. A missing header or "*" matches.
+func ifMatchSatisfied(c *gin.Context, version uint) bool {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		// If-Match uses the strong comparison, so weak tags never match
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIfMatchSatisfied(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		want   bool
+	}{
+		"missing header": {"", true},
+		"any version":    {"*", true},
+		"current":        {`"3"`, true},
+		"one of several": {`"2", "3"`, true},
+		"stale":          {`"2"`, false},
+		"weak tag":       {`W/"3"`, false},
+		"unquoted":       {"3", false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			if test.header != "" {
+				c.Request.Header.Set("If-Match", test.header)
+			}
+			if got := ifMatchSatisfied(c, 3); got != test.want {
+				t.Errorf("ifMatchSatisfied(%q) = %v, want %v", test.header, got, test.want)
+			}
+		})
+	}
+}
+
+func TestStaleIfMatchIsPreconditionFailed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PATCH("/records/:id", func(c *gin.Context) {
+		if !ifMatchSatisfied(c, 3) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"success": false})
+			return
+		}
+		setETag(c, 4)
+		c.Status(http.StatusOK)
+	})
+
+	for header, want := range map[string]int{`"2"`: http.StatusPreconditionFailed, `"3"`: http.StatusOK, "*": http.StatusOK, "": http.StatusOK} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPatch, "/records/1", nil)
+		if header != "" {
+			request.Header.Set("If-Match", header)
+		}
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != want {
+			t.Errorf("If-Match %q: status %d, want %d", header, recorder.Code, want)
+		}
+		if want == http.StatusOK && recorder.Header().Get("ETag") != `"4"` {
+			t.Errorf("If-Match %q: ETag %q, want \"4\"", header, recorder.Header().Get("ETag"))
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFeatureFlagRequest represents the payload accepted when creating a flag.
+// Leave the targeting lists empty to switch the feature on for everyone.
+type CreateFeatureFlagRequest struct {
+	Key           string   `json:"key" binding:"required,max=100"`
+	Description   string   `json:"description" binding:"max=500"`
+	Enabled       bool     `json:"enabled"`
+	Roles         []string `json:"roles" binding:"omitempty,dive,oneof=admin hr manager employee"`
+	DepartmentIDs []uint   `json:"departmentIds" binding:"omitempty,dive,exists=departments"`
+	UserIDs       []uint   `json:"userIds" binding:"omitempty,dive,exists=users"`
+}
+
+// UpdateFeatureFlagRequest represents the fields editable on an existing flag
+type UpdateFeatureFlagRequest struct {
+	Description   string   `json:"description" binding:"max=500"`
+	Enabled       bool     `json:"enabled"`
+	Roles         []string `json:"roles" binding:"omitempty,dive,oneof=admin hr manager employee"`
+	DepartmentIDs []uint   `json:"departmentIds" binding:"omitempty,dive,exists=departments"`
+	UserIDs       []uint   `json:"userIds" binding:"omitempty,dive,exists=users"`
+}
+
+func (r CreateFeatureFlagRequest) input() services.FeatureFlagInput {
+	return services.FeatureFlagInput{
+		Key:           r.Key,
+		Description:   r.Description,
+		Enabled:       r.Enabled,
+		Roles:         r.Roles,
+		DepartmentIDs: r.DepartmentIDs,
+		UserIDs:       r.UserIDs,
+	}
+}
+
+func newUpdateFeatureFlagRequest(flag models.FeatureFlag) UpdateFeatureFlagRequest {
+	return UpdateFeatureFlagRequest{
+		Description:   flag.Description,
+		Enabled:       flag.Enabled,
+		Roles:         flag.Roles,
+		DepartmentIDs: flag.DepartmentIDs,
+		UserIDs:       flag.UserIDs,
+	}
+}
+
+func (r UpdateFeatureFlagRequest) input() services.FeatureFlagInput {
+	return services.FeatureFlagInput{
+		Description:   r.Description,
+		Enabled:       r.Enabled,
+		Roles:         r.Roles,
+		DepartmentIDs: r.DepartmentIDs,
+		UserIDs:       r.UserIDs,
+	}
+}
+
+type FeatureFlagController struct {
+	features *services.FeatureService
+}
+
+func NewFeatureFlagController(features *services.FeatureService) *FeatureFlagController {
+	return &FeatureFlagController{features: features}
+}
+
+// GetFeatureFlags - Admin lists every flag with its targeting
+func (fc *FeatureFlagController) GetFeatureFlags(c *gin.Context) {
+	flags, err := fc.features.List()
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch feature flags")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    flags,
+	})
+}
+
+func (fc *FeatureFlagController) GetFeatureFlag(c *gin.Context) {
+	flag, err := fc.features.Get(c.Param("key"))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch feature flag")
+		return
+	}
+
+	setETag(c, flag.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    flag,
+	})
+}
+
+func (fc *FeatureFlagController) CreateFeatureFlag(c *gin.Context) {
+	var req CreateFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	flag, err := fc.features.Create(req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create feature flag")
+		return
+	}
+	setETag(c, flag.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    flag,
+		"message": "Feature flag created successfully",
+	})
+}
+
+// UpdateFeatureFlag - Admin toggles a flag or changes its targeting. The change
+// applies to the next request without a restart.
+func (fc *FeatureFlagController) UpdateFeatureFlag(c *gin.Context) {
+	flag, err := fc.features.Get(c.Param("key"))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch feature flag")
+		return
+	}
+
+	if !ifMatchSatisfied(c, flag.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Feature flag has been modified by another request",
+		})
+		return
+	}
+
+	req := newUpdateFeatureFlagRequest(*flag)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := fc.features.Update(flag, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update feature flag")
+		return
+	}
+	setETag(c, flag.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    flag,
+		"message": "Feature flag updated successfully",
+	})
+}
+
+func (fc *FeatureFlagController) DeleteFeatureFlag(c *gin.Context) {
+	if err := fc.features.Delete(c.Param("key")); err != nil {
+		respondFailure(c, err, "Failed to delete feature flag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Feature flag deleted successfully",
+	})
+}
+
+// GetMyFeatures - Any user lists the flags that are on for them, so clients
+// can show or hide features
+func (fc *FeatureFlagController) GetMyFeatures(c *gin.Context) {
+	keys, err := fc.features.EnabledKeys(currentActor(c))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch features")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keys,
+	})
+}
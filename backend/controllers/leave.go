@@ -2,12 +2,13 @@ package controllers
 
 import (
 	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // LeaveResponse represents the leave request data structure expected by frontend
@@ -25,15 +26,11 @@ type LeaveResponse struct {
 	ApprovedBy   string `json:"approvedBy,omitempty"`
 	ApprovedAt   string `json:"approvedAt,omitempty"`
 	Comments     string `json:"comments,omitempty"`
+	Version      uint   `json:"version"`
 }
 
-// Helper function to convert model to response format
-func (lc *LeaveController) transformLeaveResponse(leave models.LeaveRequest) LeaveResponse {
-	employeeName := ""
-	if leave.Employee.FirstName != "" && leave.Employee.LastName != "" {
-		employeeName = leave.Employee.FirstName + " " + leave.Employee.LastName
-	}
-
+// Helper function to convert a listed leave request to response format
+func (lc *LeaveController) transformLeaveResponse(leave repositories.LeaveSummary) LeaveResponse {
 	startDate := ""
 	if !leave.StartDate.IsZero() {
 		startDate = leave.StartDate.Format("2006-01-02")
@@ -49,20 +46,15 @@ func (lc *LeaveController) transformLeaveResponse(leave models.LeaveRequest) Lea
 		appliedDate = leave.CreatedAt.Format("2006-01-02")
 	}
 
-	approvedBy := ""
-	if leave.Approver != nil {
-		approvedBy = leave.Approver.FirstName + " " + leave.Approver.LastName
-	}
-
 	approvedAt := ""
 	if leave.ApprovedAt != nil {
 		approvedAt = leave.ApprovedAt.Format("2006-01-02")
 	}
 
 	return LeaveResponse{
-		ID:           strconv.Itoa(int(leave.Model.ID)),
+		ID:           strconv.Itoa(int(leave.ID)),
 		EmployeeID:   strconv.Itoa(int(leave.EmployeeID)),
-		EmployeeName: employeeName,
+		EmployeeName: fullName(leave.EmployeeFirstName, leave.EmployeeLastName),
 		LeaveType:    leave.LeaveType,
 		StartDate:    startDate,
 		EndDate:      endDate,
@@ -70,86 +62,80 @@ func (lc *LeaveController) transformLeaveResponse(leave models.LeaveRequest) Lea
 		Reason:       leave.Reason,
 		Status:       leave.Status,
 		AppliedDate:  appliedDate,
-		ApprovedBy:   approvedBy,
+		ApprovedBy:   fullName(leave.ApproverFirstName, leave.ApproverLastName),
 		ApprovedAt:   approvedAt,
 		Comments:     leave.Comments,
+		Version:      leave.Version,
+	}
+}
+
+// CreateLeaveRequestPayload represents the payload accepted when applying for leave.
+// EmployeeID is ignored for employees, who can only apply for themselves.
+type CreateLeaveRequestPayload struct {
+	EmployeeID uint      `json:"employeeId" binding:"omitempty,exists=employees"`
+	LeaveType  string    `json:"leaveType" binding:"required,oneof=annual sick emergency maternity paternity unpaid"`
+	StartDate  time.Time `json:"startDate" binding:"required"`
+	EndDate    time.Time `json:"endDate" binding:"required,gtefield=StartDate"`
+	Reason     string    `json:"reason" binding:"max=1000"`
+}
+
+// UpdateLeaveRequestPayload is the writable view of a leave request. Updates are
+// sent as a JSON Merge Patch against this view. Status and approval fields are
+// only changed through ApproveLeaveRequest.
+type UpdateLeaveRequestPayload struct {
+	LeaveType string    `json:"leaveType" binding:"required,oneof=annual sick emergency maternity paternity unpaid"`
+	StartDate time.Time `json:"startDate" binding:"required"`
+	EndDate   time.Time `json:"endDate" binding:"required,gtefield=StartDate"`
+	Reason    string    `json:"reason" binding:"max=1000"`
+}
+
+// ApproveLeaveRequestPayload represents an approver's decision on a leave request
+type ApproveLeaveRequestPayload struct {
+	Status   string `json:"status" binding:"required,oneof=approved rejected"`
+	Comments string `json:"comments" binding:"max=1000"`
+}
+
+func (r CreateLeaveRequestPayload) input() services.LeaveInput {
+	return services.LeaveInput{
+		EmployeeID: r.EmployeeID,
+		LeaveType:  r.LeaveType,
+		StartDate:  r.StartDate,
+		EndDate:    r.EndDate,
+		Reason:     r.Reason,
+	}
+}
+
+func newUpdateLeaveRequestPayload(leave models.LeaveRequest) UpdateLeaveRequestPayload {
+	return UpdateLeaveRequestPayload{
+		LeaveType: leave.LeaveType,
+		StartDate: leave.StartDate,
+		EndDate:   leave.EndDate,
+		Reason:    leave.Reason,
+	}
+}
+
+func (r UpdateLeaveRequestPayload) input() services.LeaveInput {
+	return services.LeaveInput{
+		LeaveType: r.LeaveType,
+		StartDate: r.StartDate,
+		EndDate:   r.EndDate,
+		Reason:    r.Reason,
 	}
 }
 
 type LeaveController struct {
-	db *gorm.DB
+	leaves *services.LeaveService
 }
 
-func NewLeaveController(db *gorm.DB) *LeaveController {
-	return &LeaveController{db: db}
+func NewLeaveController(leaves *services.LeaveService) *LeaveController {
+	return &LeaveController{leaves: leaves}
 }
 
 // GetLeaveRequests - HR sees all, Managers see department requests, Employees see own
 func (lc *LeaveController) GetLeaveRequests(c *gin.Context) {
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
-
-	var leaveRequests []models.LeaveRequest
-	query := lc.db.Preload("Employee").Preload("Employee.Department").Preload("Approver")
-
-	switch userRole {
-	case "hr", "admin":
-		// HR can see all leave requests
-		if err := query.Find(&leaveRequests).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leave requests"})
-			return
-		}
-
-	case "manager":
-		// Managers can see leave requests from their department
-		var user models.User
-		if err := lc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Manager must be associated with an employee record"})
-			return
-		}
-
-		// Get leave requests from the same department
-		if err := query.Joins("JOIN employees ON leave_requests.employee_id = employees.id").
-			Where("employees.department_id = ?", user.Employee.DepartmentID).
-			Find(&leaveRequests).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch department leave requests"})
-			return
-		}
-
-	case "employee":
-		// Employees can only see their own leave requests
-		var user models.User
-		if err := lc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
-
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "User must be associated with an employee record",
-			})
-			return
-		}
-
-		if err := query.Where("employee_id = ?", user.Employee.ID).Find(&leaveRequests).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to fetch personal leave requests",
-			})
-			return
-		}
-
-	default:
-		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user role"})
+	leaveRequests, err := lc.leaves.List(currentActor(c))
+	if err != nil {
+		respondError(c, err, "Failed to fetch leave requests")
 		return
 	}
 
@@ -162,61 +148,46 @@ func (lc *LeaveController) GetLeaveRequests(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// CreateLeaveRequest - Employees create leave requests, HR can create for anyone
-func (lc *LeaveController) CreateLeaveRequest(c *gin.Context) {
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
-
-	var leaveRequest models.LeaveRequest
-	if err := c.ShouldBindJSON(&leaveRequest); err != nil {
+// GetLeaveRequest - HR sees any request, Managers their department's, Employees their own
+func (lc *LeaveController) GetLeaveRequest(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": err.Error(),
+			"message": "Invalid leave request ID",
 		})
 		return
 	}
 
-	// For employees, ensure they can only create requests for themselves
-	if userRole == "employee" {
-		var user models.User
-		if err := lc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
-
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "User must be associated with an employee record",
-			})
-			return
-		}
-
-		leaveRequest.EmployeeID = user.Employee.ID
+	leaveRequest, err := lc.leaves.View(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch leave request")
+		return
 	}
+	setETag(c, leaveRequest.Version)
 
-	// Calculate number of days
-	duration := leaveRequest.EndDate.Sub(leaveRequest.StartDate)
-	leaveRequest.Days = int(duration.Hours()/24) + 1 // +1 to include both start and end days
-
-	// Set initial status
-	if leaveRequest.Status == "" {
-		leaveRequest.Status = "pending"
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    leaveRequest,
+	})
+}
 
-	if err := lc.db.Create(&leaveRequest).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+// CreateLeaveRequest - Employees create leave requests, HR can create for anyone
+func (lc *LeaveController) CreateLeaveRequest(c *gin.Context) {
+	var req CreateLeaveRequestPayload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Failed to create leave request",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Load employee data for response
-	lc.db.Preload("Employee").Preload("Employee.Department").First(&leaveRequest, leaveRequest.ID)
+	leaveRequest, err := lc.leaves.Apply(currentActor(c), req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create leave request")
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -225,9 +196,9 @@ func (lc *LeaveController) CreateLeaveRequest(c *gin.Context) {
 	})
 }
 
-// UpdateLeaveRequest - Update leave request (own requests for employees, any for HR)
+// UpdateLeaveRequest - Update leave request (own pending requests for employees, any for HR)
 func (lc *LeaveController) UpdateLeaveRequest(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -236,71 +207,34 @@ func (lc *LeaveController) UpdateLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
+	leaveRequest, err := lc.leaves.GetEditable(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch leave request")
+		return
+	}
 
-	var leaveRequest models.LeaveRequest
-	if err := lc.db.Preload("Employee").First(&leaveRequest, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
+	if !ifMatchSatisfied(c, leaveRequest.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
 			"success": false,
-			"message": "Leave request not found",
+			"message": "Leave request has been modified by another request",
 		})
 		return
 	}
 
-	// Check permissions: employees can only update their own pending requests
-	if userRole == "employee" {
-		var user models.User
-		if err := lc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
-
-		if user.Employee == nil || user.Employee.ID != leaveRequest.EmployeeID {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "You can only update your own leave requests",
-			})
-			return
-		}
-
-		if leaveRequest.Status != "pending" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "You can only update pending leave requests",
-			})
-			return
-		}
-	}
-
-	var updateData models.LeaveRequest
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+	req := newUpdateLeaveRequestPayload(*leaveRequest)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
 
-	// Recalculate days if dates are updated
-	if !updateData.StartDate.IsZero() && !updateData.EndDate.IsZero() {
-		duration := updateData.EndDate.Sub(updateData.StartDate)
-		updateData.Days = int(duration.Hours()/24) + 1
-	}
-
-	if err := lc.db.Model(&leaveRequest).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to update leave request",
-		})
+	if err := lc.leaves.Update(leaveRequest, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update leave request")
 		return
 	}
-
-	// Load updated data for response
-	lc.db.Preload("Employee").Preload("Employee.Department").Preload("Approver").First(&leaveRequest, leaveRequest.ID)
+	setETag(c, leaveRequest.Version)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -311,7 +245,7 @@ func (lc *LeaveController) UpdateLeaveRequest(c *gin.Context) {
 
 // ApproveLeaveRequest - HR approves/rejects leave requests
 func (lc *LeaveController) ApproveLeaveRequest(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -320,22 +254,21 @@ func (lc *LeaveController) ApproveLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("userID")
+	leaveRequest, err := lc.leaves.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch leave request")
+		return
+	}
 
-	var leaveRequest models.LeaveRequest
-	if err := lc.db.First(&leaveRequest, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
+	if !ifMatchSatisfied(c, leaveRequest.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
 			"success": false,
-			"message": "Leave request not found",
+			"message": "Leave request has been modified by another request",
 		})
 		return
 	}
 
-	var approvalData struct {
-		Status   string `json:"status" binding:"required,oneof=approved rejected"`
-		Comments string `json:"comments"`
-	}
-
+	var approvalData ApproveLeaveRequestPayload
 	if err := c.ShouldBindJSON(&approvalData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -344,57 +277,23 @@ func (lc *LeaveController) ApproveLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	// Get approver employee ID
-	var user models.User
-	if err := lc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Approver not found",
-		})
-		return
-	}
-
-	if user.Employee == nil {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "Approver must be associated with an employee record",
-		})
+	decision := services.LeaveDecision{Status: approvalData.Status, Comments: approvalData.Comments}
+	if err := lc.leaves.Decide(currentActor(c), leaveRequest, decision); err != nil {
+		respondFailure(c, err, "Failed to update leave request status")
 		return
 	}
-
-	// Update leave request
-	now := time.Now()
-	leaveRequest.Status = approvalData.Status
-	leaveRequest.Comments = approvalData.Comments
-	leaveRequest.ApprovedBy = &user.Employee.ID
-	leaveRequest.ApprovedAt = &now
-
-	if err := lc.db.Save(&leaveRequest).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to update leave request status",
-		})
-		return
-	}
-
-	// Load updated data for response
-	lc.db.Preload("Employee").Preload("Employee.Department").Preload("Approver").First(&leaveRequest, leaveRequest.ID)
-
-	action := "approved"
-	if approvalData.Status == "rejected" {
-		action = "rejected"
-	}
+	setETag(c, leaveRequest.Version)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    leaveRequest,
-		"message": "Leave request " + action + " successfully",
+		"message": "Leave request " + approvalData.Status + " successfully",
 	})
 }
 
 // DeleteLeaveRequest - Delete leave request (own pending requests for employees, any for HR)
 func (lc *LeaveController) DeleteLeaveRequest(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -403,51 +302,8 @@ func (lc *LeaveController) DeleteLeaveRequest(c *gin.Context) {
 		return
 	}
 
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
-
-	var leaveRequest models.LeaveRequest
-	if err := lc.db.Preload("Employee").First(&leaveRequest, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Leave request not found",
-		})
-		return
-	}
-
-	// Check permissions: employees can only delete their own pending requests
-	if userRole == "employee" {
-		var user models.User
-		if err := lc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
-
-		if user.Employee == nil || user.Employee.ID != leaveRequest.EmployeeID {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "You can only delete your own leave requests",
-			})
-			return
-		}
-
-		if leaveRequest.Status != "pending" {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "You can only delete pending leave requests",
-			})
-			return
-		}
-	}
-
-	if err := lc.db.Delete(&leaveRequest).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to delete leave request",
-		})
+	if err := lc.leaves.Delete(currentActor(c), id); err != nil {
+		respondFailure(c, err, "Failed to delete leave request")
 		return
 	}
 
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"hrms-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notifications *services.NotificationService
+}
+
+func NewNotificationController(notifications *services.NotificationService) *NotificationController {
+	return &NotificationController{notifications: notifications}
+}
+
+// GetNotifications - the caller's notifications, newest first; only unread
+// ones with ?unread=true
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	notifications, err := nc.notifications.List(currentActor(c), c.Query("unread") == "true")
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch notifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notifications,
+	})
+}
+
+// MarkNotificationRead - the caller marks one of their notifications read
+func (nc *NotificationController) MarkNotificationRead(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid notification ID"})
+		return
+	}
+
+	notification, err := nc.notifications.MarkRead(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to update notification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+	})
+}
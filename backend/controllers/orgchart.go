@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrgMemberResponse is an employee found by walking reporting lines. Depth is
+// 1 for direct reports or the direct manager and grows by one per level.
+type OrgMemberResponse struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Position     string `json:"position"`
+	DepartmentID uint   `json:"departmentId"`
+	Department   string `json:"department"`
+	ManagerID    *uint  `json:"managerId"`
+	Depth        int    `json:"depth"`
+}
+
+// OrgChartNode is an employee in the org chart with their direct reports
+type OrgChartNode struct {
+	ID           uint           `json:"id"`
+	Name         string         `json:"name"`
+	Position     string         `json:"position"`
+	DepartmentID uint           `json:"departmentId"`
+	Department   string         `json:"department"`
+	ManagerID    *uint          `json:"managerId"`
+	Reports      []OrgChartNode `json:"reports"`
+}
+
+func newOrgMemberResponses(members []services.OrgMember) []OrgMemberResponse {
+	response := make([]OrgMemberResponse, len(members))
+	for i, member := range members {
+		response[i] = OrgMemberResponse{
+			ID:           member.ID,
+			Name:         member.FirstName + " " + member.LastName,
+			Position:     member.Position,
+			DepartmentID: member.DepartmentID,
+			Department:   member.DepartmentName,
+			ManagerID:    member.ManagerID,
+			Depth:        member.Depth,
+		}
+	}
+	return response
+}
+
+func newOrgChartNodes(nodes []*services.OrgNode) []OrgChartNode {
+	response := make([]OrgChartNode, len(nodes))
+	for i, node := range nodes {
+		response[i] = OrgChartNode{
+			ID:           node.ID,
+			Name:         node.FirstName + " " + node.LastName,
+			Position:     node.Position,
+			DepartmentID: node.DepartmentID,
+			Department:   node.DepartmentName,
+			ManagerID:    node.ManagerID,
+			Reports:      newOrgChartNodes(node.Reports),
+		}
+	}
+	return response
+}
+
+// orgChartDOT renders the chart as a Graphviz digraph with an edge from each
+// manager to each of their reports
+func orgChartDOT(nodes []*services.OrgNode) string {
+	var b strings.Builder
+	b.WriteString("digraph \"org-chart\" {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+
+	var write func(nodes []*services.OrgNode)
+	write = func(nodes []*services.OrgNode) {
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "  e%d [label=%s];\n", node.ID, dotQuote(orgLabel(node.ReportingLine)))
+			for _, report := range node.Reports {
+				fmt.Fprintf(&b, "  e%d -> e%d;\n", node.ID, report.ID)
+			}
+			write(node.Reports)
+		}
+	}
+	write(nodes)
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func orgLabel(line repositories.ReportingLine) string {
+	label := line.FirstName + " " + line.LastName + "\n" + line.Position
+	if line.DepartmentName != "" {
+		label += "\n" + line.DepartmentName
+	}
+	return label
+}
+
+// dotQuote quotes s as a DOT string, escaping quotes, backslashes and newlines
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+type OrgChartController struct {
+	orgChart *services.OrgChartService
+}
+
+func NewOrgChartController(orgChart *services.OrgChartService) *OrgChartController {
+	return &OrgChartController{orgChart: orgChart}
+}
+
+// GetReports - Any user lists an employee's direct reports, or everyone below
+// them with transitive=true
+func (oc *OrgChartController) GetReports(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+	transitive, err := strconv.ParseBool(c.DefaultQuery("transitive", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "transitive must be true or false"})
+		return
+	}
+
+	reports, err := oc.orgChart.Reports(id, transitive)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch reports")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newOrgMemberResponses(reports),
+	})
+}
+
+// GetChain - Any user lists an employee's managers up to the top of the chart
+func (oc *OrgChartController) GetChain(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	chain, err := oc.orgChart.Chain(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch management chain")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newOrgMemberResponses(chain),
+	})
+}
+
+// chart builds the chart from the optional root query parameter, writing the
+// error response itself when it fails
+func (oc *OrgChartController) chart(c *gin.Context) ([]*services.OrgNode, bool) {
+	var rootID uint64
+	if value := c.Query("root"); value != "" {
+		var err error
+		if rootID, err = strconv.ParseUint(value, 10, 0); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid root employee ID"})
+			return nil, false
+		}
+	}
+
+	nodes, err := oc.orgChart.Chart(uint(rootID))
+	if err != nil {
+		respondFailure(c, err, "Failed to build org chart")
+		return nil, false
+	}
+	return nodes, true
+}
+
+// GetOrgChart - Any user reads the org chart as a tree
+func (oc *OrgChartController) GetOrgChart(c *gin.Context) {
+	nodes, ok := oc.chart(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newOrgChartNodes(nodes),
+	})
+}
+
+// ExportOrgChart - Any user downloads the org chart as JSON or Graphviz DOT
+func (oc *OrgChartController) ExportOrgChart(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dot" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "format must be json or dot"})
+		return
+	}
+	nodes, ok := oc.chart(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="org-chart.`+format+`"`)
+	if format == "dot" {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(orgChartDOT(nodes)))
+		return
+	}
+	body, err := json.MarshalIndent(newOrgChartNodes(nodes), "", "  ")
+	if err != nil {
+		respondFailure(c, err, "Failed to export org chart")
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
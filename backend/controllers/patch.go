@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"hrms-backend/utils"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxPatchSize is the largest merge patch accepted, in bytes
+const maxPatchSize = 1 << 20
+
+// bindMergePatch applies the request body as a JSON Merge Patch (RFC 7396) to
+// document, which must already hold the writable view of the stored record.
+// Members the patch sets to null are cleared and explicit zero values are kept,
+// so the resulting document describes every writable column. The merged
+// document is validated with the same binding rules as a regular request.
+// Patches over maxPatchSize are refused; see patchStatus.
+func bindMergePatch(c *gin.Context, document interface{}) error {
+	current, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchSize))
+	if err != nil {
+		return err
+	}
+	if len(patch) == 0 {
+		return errors.New("request body is empty")
+	}
+
+	merged, err := utils.MergePatch(current, patch)
+	if err != nil {
+		return err
+	}
+
+	// Start from the zero value so members removed by the patch stay cleared
+	value := reflect.ValueOf(document).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	if err := json.Unmarshal(merged, document); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(document)
+}
+
+// patchStatus is the response status for an error from bindMergePatch: 413
+// for a patch over maxPatchSize, otherwise 400
+func patchStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
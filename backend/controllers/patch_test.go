@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type patchDocument struct {
+	Name  string  `json:"name" binding:"required"`
+	Note  *string `json:"note"`
+	Count int     `json:"count"`
+}
+
+func patchContext(body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	return c, recorder
+}
+
+func TestBindMergePatch(t *testing.T) {
+	note := "keep"
+	doc := patchDocument{Name: "before", Note: &note, Count: 3}
+	c, _ := patchContext(`{"note": null, "count": 0}`)
+	if err := bindMergePatch(c, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Name != "before" || doc.Note != nil || doc.Count != 0 {
+		t.Errorf("merged document = %+v", doc)
+	}
+
+	// The merged document is validated
+	c, _ = patchContext(`{"name": ""}`)
+	if err := bindMergePatch(c, &doc); err == nil || patchStatus(err) != http.StatusBadRequest {
+		t.Errorf("clearing a required member: err = %v", err)
+	}
+}
+
+func TestBindMergePatchRejectsLargePatch(t *testing.T) {
+	doc := patchDocument{Name: "before"}
+	c, _ := patchContext(`{"name": "` + strings.Repeat("x", maxPatchSize) + `"}`)
+	err := bindMergePatch(c, &doc)
+	if status := patchStatus(err); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d (%v), want 413", status, err)
+	}
+}
@@ -2,114 +2,132 @@ package controllers
 
 import (
 	"hrms-backend/models"
+	"hrms-backend/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-type PayrollController struct {
-	db *gorm.DB
+// CreatePayrollRecordRequest represents the payload accepted when creating a payroll record.
+// Gross and net pay are always computed by the server.
+type CreatePayrollRecordRequest struct {
+	EmployeeID     uint      `json:"employeeId" binding:"required,exists=employees"`
+	PayPeriodStart time.Time `json:"payPeriodStart" binding:"required"`
+	PayPeriodEnd   time.Time `json:"payPeriodEnd" binding:"required,gtefield=PayPeriodStart"`
+	BasicSalary    float64   `json:"basicSalary" binding:"gte=0"`
+	Allowances     float64   `json:"allowances" binding:"gte=0"`
+	Deductions     float64   `json:"deductions" binding:"gte=0"`
+	Overtime       float64   `json:"overtime" binding:"gte=0"`
+	Tax            float64   `json:"tax" binding:"gte=0"`
+	Status         string    `json:"status" binding:"omitempty,oneof=draft processed paid"`
 }
 
-func NewPayrollController(db *gorm.DB) *PayrollController {
-	return &PayrollController{db: db}
+// UpdatePayrollRecordRequest is the writable view of a payroll record. Updates
+// are sent as a JSON Merge Patch against this view; gross and net pay are then
+// recomputed from the merged record rather than from the fields that were sent.
+type UpdatePayrollRecordRequest struct {
+	PayPeriodStart time.Time `json:"payPeriodStart" binding:"required"`
+	PayPeriodEnd   time.Time `json:"payPeriodEnd" binding:"required,gtefield=PayPeriodStart"`
+	BasicSalary    float64   `json:"basicSalary" binding:"gte=0"`
+	Allowances     float64   `json:"allowances" binding:"gte=0"`
+	Deductions     float64   `json:"deductions" binding:"gte=0"`
+	Overtime       float64   `json:"overtime" binding:"gte=0"`
+	Tax            float64   `json:"tax" binding:"gte=0"`
+	Status         string    `json:"status" binding:"required,oneof=draft processed paid"`
 }
 
-// GetPayrollRecords - HR sees all, Employees see only their own
-func (pc *PayrollController) GetPayrollRecords(c *gin.Context) {
-	userRole := c.GetString("userRole")
-	userID, _ := c.Get("userID")
-
-	var payrollRecords []models.PayrollRecord
-	query := pc.db.Preload("Employee").Preload("Employee.Department")
-
-	switch userRole {
-	case "hr", "admin":
-		// HR can see all payroll records
-		if err := query.Find(&payrollRecords).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to fetch payroll records",
-			})
-			return
-		}
+func (r CreatePayrollRecordRequest) input() services.PayrollInput {
+	return services.PayrollInput{
+		EmployeeID:     r.EmployeeID,
+		PayPeriodStart: r.PayPeriodStart,
+		PayPeriodEnd:   r.PayPeriodEnd,
+		BasicSalary:    r.BasicSalary,
+		Allowances:     r.Allowances,
+		Deductions:     r.Deductions,
+		Overtime:       r.Overtime,
+		Tax:            r.Tax,
+		Status:         r.Status,
+	}
+}
 
-	case "manager":
-		// Managers can see their department's payroll records
-		var user models.User
-		if err := pc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
+func newUpdatePayrollRecordRequest(record models.PayrollRecord) UpdatePayrollRecordRequest {
+	return UpdatePayrollRecordRequest{
+		PayPeriodStart: record.PayPeriodStart,
+		PayPeriodEnd:   record.PayPeriodEnd,
+		BasicSalary:    record.BasicSalary,
+		Allowances:     record.Allowances,
+		Deductions:     record.Deductions,
+		Overtime:       record.Overtime,
+		Tax:            record.Tax,
+		Status:         record.Status,
+	}
+}
 
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "Manager must be associated with an employee record",
-			})
-			return
-		}
+func (r UpdatePayrollRecordRequest) input() services.PayrollInput {
+	return services.PayrollInput{
+		PayPeriodStart: r.PayPeriodStart,
+		PayPeriodEnd:   r.PayPeriodEnd,
+		BasicSalary:    r.BasicSalary,
+		Allowances:     r.Allowances,
+		Deductions:     r.Deductions,
+		Overtime:       r.Overtime,
+		Tax:            r.Tax,
+		Status:         r.Status,
+	}
+}
 
-		if err := query.Joins("JOIN employees ON payroll_records.employee_id = employees.id").
-			Where("employees.department_id = ?", user.Employee.DepartmentID).
-			Find(&payrollRecords).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to fetch department payroll records",
-			})
-			return
-		}
+type PayrollController struct {
+	payroll *services.PayrollService
+}
 
-	case "employee":
-		// Employees can only see their own payroll records
-		var user models.User
-		if err := pc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"success": false,
-				"message": "User not found",
-			})
-			return
-		}
+func NewPayrollController(payroll *services.PayrollService) *PayrollController {
+	return &PayrollController{payroll: payroll}
+}
 
-		if user.Employee == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "User must be associated with an employee record",
-			})
-			return
-		}
+// GetPayrollRecords - HR sees all, Managers see their department, Employees see only their own
+func (pc *PayrollController) GetPayrollRecords(c *gin.Context) {
+	payrollRecords, err := pc.payroll.List(currentActor(c))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch payroll records")
+		return
+	}
 
-		if err := query.Where("employee_id = ?", user.Employee.ID).Find(&payrollRecords).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "Failed to fetch personal payroll records",
-			})
-			return
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    payrollRecords,
+	})
+}
 
-	default:
-		c.JSON(http.StatusForbidden, gin.H{
+// GetPayrollRecord - HR sees any record, Managers their department's, Employees their own
+func (pc *PayrollController) GetPayrollRecord(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "Invalid user role",
+			"message": "Invalid payroll record ID",
 		})
 		return
 	}
 
+	payrollRecord, err := pc.payroll.View(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch payroll record")
+		return
+	}
+	setETag(c, payrollRecord.Version)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    payrollRecords,
+		"data":    payrollRecord,
 	})
 }
 
 // CreatePayrollRecord - HR only
 func (pc *PayrollController) CreatePayrollRecord(c *gin.Context) {
-	var payrollRecord models.PayrollRecord
-	if err := c.ShouldBindJSON(&payrollRecord); err != nil {
+	var req CreatePayrollRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": err.Error(),
@@ -117,21 +135,12 @@ func (pc *PayrollController) CreatePayrollRecord(c *gin.Context) {
 		return
 	}
 
-	// Calculate gross pay and net pay
-	payrollRecord.GrossPay = payrollRecord.BasicSalary + payrollRecord.Allowances + payrollRecord.Overtime
-	payrollRecord.NetPay = payrollRecord.GrossPay - payrollRecord.Deductions - payrollRecord.Tax
-
-	if err := pc.db.Create(&payrollRecord).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to create payroll record",
-		})
+	payrollRecord, err := pc.payroll.Create(req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create payroll record")
 		return
 	}
 
-	// Load employee data for response
-	pc.db.Preload("Employee").Preload("Employee.Department").First(&payrollRecord, payrollRecord.ID)
-
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"data":    payrollRecord,
@@ -141,7 +150,7 @@ func (pc *PayrollController) CreatePayrollRecord(c *gin.Context) {
 
 // UpdatePayrollRecord - HR only
 func (pc *PayrollController) UpdatePayrollRecord(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -150,40 +159,34 @@ func (pc *PayrollController) UpdatePayrollRecord(c *gin.Context) {
 		return
 	}
 
-	var payrollRecord models.PayrollRecord
-	if err := pc.db.First(&payrollRecord, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Payroll record not found",
-		})
+	payrollRecord, err := pc.payroll.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch payroll record")
 		return
 	}
 
-	var updateData models.PayrollRecord
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+	if !ifMatchSatisfied(c, payrollRecord.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
 			"success": false,
-			"message": err.Error(),
+			"message": "Payroll record has been modified by another request",
 		})
 		return
 	}
 
-	// Recalculate gross pay and net pay
-	if updateData.BasicSalary > 0 || updateData.Allowances > 0 || updateData.Overtime > 0 || updateData.Deductions > 0 || updateData.Tax > 0 {
-		updateData.GrossPay = updateData.BasicSalary + updateData.Allowances + updateData.Overtime
-		updateData.NetPay = updateData.GrossPay - updateData.Deductions - updateData.Tax
-	}
-
-	if err := pc.db.Model(&payrollRecord).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	req := newUpdatePayrollRecordRequest(*payrollRecord)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
 			"success": false,
-			"message": "Failed to update payroll record",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Load updated data for response
-	pc.db.Preload("Employee").Preload("Employee.Department").First(&payrollRecord, payrollRecord.ID)
+	if err := pc.payroll.Update(payrollRecord, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update payroll record")
+		return
+	}
+	setETag(c, payrollRecord.Version)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -194,7 +197,7 @@ func (pc *PayrollController) UpdatePayrollRecord(c *gin.Context) {
 
 // DeletePayrollRecord - HR only
 func (pc *PayrollController) DeletePayrollRecord(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -203,11 +206,8 @@ func (pc *PayrollController) DeletePayrollRecord(c *gin.Context) {
 		return
 	}
 
-	if err := pc.db.Delete(&models.PayrollRecord{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to delete payroll record",
-		})
+	if err := pc.payroll.Delete(id); err != nil {
+		respondFailure(c, err, "Failed to delete payroll record")
 		return
 	}
 
@@ -219,29 +219,39 @@ func (pc *PayrollController) DeletePayrollRecord(c *gin.Context) {
 
 // DownloadPayrollReport - HR can download all payroll reports
 func (pc *PayrollController) DownloadPayrollReport(c *gin.Context) {
-	// Get query parameters for filtering
-	month := c.DefaultQuery("month", "")
-	year := c.DefaultQuery("year", "")
-	departmentID := c.DefaultQuery("department_id", "")
-
-	var payrollRecords []models.PayrollRecord
-	query := pc.db.Preload("Employee").Preload("Employee.Department")
-
-	// Apply filters
-	if month != "" && year != "" {
-		query = query.Where("EXTRACT(MONTH FROM pay_period_start) = ? AND EXTRACT(YEAR FROM pay_period_start) = ?", month, year)
+	// Get query parameters for filtering; the month filter needs both values
+	var year, month int
+	var departmentID uint64
+	var err error
+	if c.Query("month") != "" && c.Query("year") != "" {
+		if month, err = strconv.Atoi(c.Query("month")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "month must be a number between 1 and 12",
+			})
+			return
+		}
+		if year, err = strconv.Atoi(c.Query("year")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "year must be a positive number",
+			})
+			return
+		}
 	}
-
-	if departmentID != "" {
-		query = query.Joins("JOIN employees ON payroll_records.employee_id = employees.id").
-			Where("employees.department_id = ?", departmentID)
+	if c.Query("department_id") != "" {
+		if departmentID, err = strconv.ParseUint(c.Query("department_id"), 10, 0); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Invalid department ID",
+			})
+			return
+		}
 	}
 
-	if err := query.Find(&payrollRecords).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Failed to fetch payroll records for report",
-		})
+	payrollRecords, err := pc.payroll.Report(year, month, uint(departmentID))
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch payroll records for report")
 		return
 	}
 
@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmergencyContactRequest represents an emergency contact. Contacts are called
+// in priority order, lowest first; leave priority out when adding a contact to
+// add it last.
+type EmergencyContactRequest struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	Relationship string `json:"relationship" binding:"required,max=50"`
+	Phone        string `json:"phone" binding:"required,max=30"`
+	Email        string `json:"email" binding:"omitempty,email,max=100"`
+	Priority     int    `json:"priority" binding:"gte=0,lte=99"`
+}
+
+func newEmergencyContactRequest(contact models.EmergencyContact) EmergencyContactRequest {
+	return EmergencyContactRequest{
+		Name:         contact.Name,
+		Relationship: contact.Relationship,
+		Phone:        contact.Phone,
+		Email:        contact.Email,
+		Priority:     contact.Priority,
+	}
+}
+
+func (r EmergencyContactRequest) input() services.EmergencyContactInput {
+	return services.EmergencyContactInput{
+		Name:         r.Name,
+		Relationship: r.Relationship,
+		Phone:        r.Phone,
+		Email:        r.Email,
+		Priority:     r.Priority,
+	}
+}
+
+// DependentRequest represents an employee's dependent
+type DependentRequest struct {
+	FirstName    string    `json:"firstName" binding:"required,max=100"`
+	LastName     string    `json:"lastName" binding:"required,max=100"`
+	Relationship string    `json:"relationship" binding:"required,oneof=spouse partner child other"`
+	BirthDate    time.Time `json:"birthDate" binding:"required"`
+}
+
+func newDependentRequest(dependent models.Dependent) DependentRequest {
+	return DependentRequest{
+		FirstName:    dependent.FirstName,
+		LastName:     dependent.LastName,
+		Relationship: dependent.Relationship,
+		BirthDate:    dependent.BirthDate,
+	}
+}
+
+func (r DependentRequest) input() services.DependentInput {
+	return services.DependentInput{
+		FirstName:    r.FirstName,
+		LastName:     r.LastName,
+		Relationship: r.Relationship,
+		BirthDate:    r.BirthDate,
+	}
+}
+
+type PersonalController struct {
+	personal *services.PersonalService
+}
+
+func NewPersonalController(personal *services.PersonalService) *PersonalController {
+	return &PersonalController{personal: personal}
+}
+
+// parseChildID reads the :id employee and the numeric child path parameter
+// name, such as :contactId, reporting a bad ID itself
+func parseChildID(c *gin.Context, name, label string) (uint, uint, bool) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return 0, 0, false
+	}
+	childID, err := strconv.ParseUint(c.Param(name), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid " + label + " ID"})
+		return 0, 0, false
+	}
+	return id, uint(childID), true
+}
+
+// GetEmergencyContacts - HR, the employee and managers the employee reports
+// to, directly or not, see the employee's emergency contacts
+func (pc *PersonalController) GetEmergencyContacts(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	contacts, err := pc.personal.ListEmergencyContacts(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch emergency contacts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contacts,
+	})
+}
+
+// CreateEmergencyContact - employees add their own contacts, HR anyone's
+func (pc *PersonalController) CreateEmergencyContact(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	var req EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	contact, err := pc.personal.CreateEmergencyContact(currentActor(c), id, req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create emergency contact")
+		return
+	}
+	setETag(c, contact.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    contact,
+		"message": "Emergency contact created successfully",
+	})
+}
+
+// UpdateEmergencyContact - employees edit their own contacts, HR anyone's
+func (pc *PersonalController) UpdateEmergencyContact(c *gin.Context) {
+	id, contactID, ok := parseChildID(c, "contactId", "contact")
+	if !ok {
+		return
+	}
+
+	contact, err := pc.personal.GetEmergencyContact(currentActor(c), id, contactID)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch emergency contact")
+		return
+	}
+
+	if !ifMatchSatisfied(c, contact.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Emergency contact has been modified by another request",
+		})
+		return
+	}
+
+	req := newEmergencyContactRequest(*contact)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := pc.personal.UpdateEmergencyContact(contact, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update emergency contact")
+		return
+	}
+	setETag(c, contact.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contact,
+		"message": "Emergency contact updated successfully",
+	})
+}
+
+// DeleteEmergencyContact - employees remove their own contacts, HR anyone's
+func (pc *PersonalController) DeleteEmergencyContact(c *gin.Context) {
+	id, contactID, ok := parseChildID(c, "contactId", "contact")
+	if !ok {
+		return
+	}
+
+	if err := pc.personal.DeleteEmergencyContact(currentActor(c), id, contactID); err != nil {
+		respondFailure(c, err, "Failed to delete emergency contact")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Emergency contact deleted successfully",
+	})
+}
+
+// GetDependents - HR and the employee see the employee's dependents
+func (pc *PersonalController) GetDependents(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	dependents, err := pc.personal.ListDependents(currentActor(c), id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch dependents")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dependents,
+	})
+}
+
+// CreateDependent - employees add their own dependents, HR anyone's
+func (pc *PersonalController) CreateDependent(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	var req DependentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	dependent, err := pc.personal.CreateDependent(currentActor(c), id, req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create dependent")
+		return
+	}
+	setETag(c, dependent.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    dependent,
+		"message": "Dependent created successfully",
+	})
+}
+
+// UpdateDependent - employees edit their own dependents, HR anyone's
+func (pc *PersonalController) UpdateDependent(c *gin.Context) {
+	id, dependentID, ok := parseChildID(c, "dependentId", "dependent")
+	if !ok {
+		return
+	}
+
+	dependent, err := pc.personal.GetDependent(currentActor(c), id, dependentID)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch dependent")
+		return
+	}
+
+	if !ifMatchSatisfied(c, dependent.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Dependent has been modified by another request",
+		})
+		return
+	}
+
+	req := newDependentRequest(*dependent)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := pc.personal.UpdateDependent(dependent, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update dependent")
+		return
+	}
+	setETag(c, dependent.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dependent,
+		"message": "Dependent updated successfully",
+	})
+}
+
+// DeleteDependent - employees remove their own dependents, HR anyone's
+func (pc *PersonalController) DeleteDependent(c *gin.Context) {
+	id, dependentID, ok := parseChildID(c, "dependentId", "dependent")
+	if !ok {
+		return
+	}
+
+	if err := pc.personal.DeleteDependent(currentActor(c), id, dependentID); err != nil {
+		respondFailure(c, err, "Failed to delete dependent")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dependent deleted successfully",
+	})
+}
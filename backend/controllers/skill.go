@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SkillRequest represents a skill in the catalog
+type SkillRequest struct {
+	Name        string `json:"name" binding:"required,max=191"`
+	Category    string `json:"category" binding:"max=50"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+func newSkillRequest(skill models.Skill) SkillRequest {
+	return SkillRequest{
+		Name:        skill.Name,
+		Category:    skill.Category,
+		Description: skill.Description,
+	}
+}
+
+func (r SkillRequest) input() services.SkillInput {
+	return services.SkillInput{
+		Name:        r.Name,
+		Category:    r.Category,
+		Description: r.Description,
+	}
+}
+
+type SkillController struct {
+	skills *services.SkillService
+}
+
+func NewSkillController(skills *services.SkillService) *SkillController {
+	return &SkillController{skills: skills}
+}
+
+// GetSkills - All roles list the skills catalog
+func (sc *SkillController) GetSkills(c *gin.Context) {
+	skills, err := sc.skills.List()
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch skills")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    skills,
+	})
+}
+
+func (sc *SkillController) GetSkill(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid skill ID"})
+		return
+	}
+
+	skill, err := sc.skills.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch skill")
+		return
+	}
+	setETag(c, skill.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    skill,
+	})
+}
+
+// CreateSkill - HR adds a skill to the catalog
+func (sc *SkillController) CreateSkill(c *gin.Context) {
+	var req SkillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	skill, err := sc.skills.Create(req.input())
+	if err != nil {
+		respondFailure(c, err, "Failed to create skill")
+		return
+	}
+	setETag(c, skill.Version)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    skill,
+		"message": "Skill created successfully",
+	})
+}
+
+// UpdateSkill - HR edits a skill
+func (sc *SkillController) UpdateSkill(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid skill ID"})
+		return
+	}
+
+	skill, err := sc.skills.Get(id)
+	if err != nil {
+		respondFailure(c, err, "Failed to fetch skill")
+		return
+	}
+
+	if !ifMatchSatisfied(c, skill.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"success": false,
+			"message": "Skill has been modified by another request",
+		})
+		return
+	}
+
+	req := newSkillRequest(*skill)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := sc.skills.Update(skill, req.input()); err != nil {
+		respondFailure(c, err, "Failed to update skill")
+		return
+	}
+	setETag(c, skill.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    skill,
+		"message": "Skill updated successfully",
+	})
+}
+
+// DeleteSkill - HR removes a skill nobody holds a certification for
+func (sc *SkillController) DeleteSkill(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid skill ID"})
+		return
+	}
+
+	if err := sc.skills.Delete(id); err != nil {
+		respondFailure(c, err, "Failed to delete skill")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Skill deleted successfully",
+	})
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TerminateEmployeeRequest represents an employee's departure
+type TerminateEmployeeRequest struct {
+	LastWorkingDay time.Time `json:"lastWorkingDay" binding:"required"`
+	Reason         string    `json:"reason" binding:"required,max=500"`
+}
+
+// TerminationResponse reports what terminating an employee did. The
+// settlement is a draft payroll record for the final month with the prorated
+// salary as basic pay and the leave encashment as allowances.
+type TerminationResponse struct {
+	Employee           *models.Employee      `json:"employee"`
+	Settlement         *models.PayrollRecord `json:"settlement"`
+	ProratedSalary     float64               `json:"proratedSalary"`
+	UnusedLeaveDays    float64               `json:"unusedLeaveDays"`
+	LeaveEncashment    float64               `json:"leaveEncashment"`
+	CancelledLeaveIDs  []uint                `json:"cancelledLeaveIds"`
+	ShortenedLeaveIDs  []uint                `json:"shortenedLeaveIds"`
+	ChecklistTasks     int                   `json:"checklistTasks"`
+	AccountDeactivated bool                  `json:"accountDeactivated"`
+}
+
+type TerminationController struct {
+	termination *services.TerminationService
+}
+
+func NewTerminationController(termination *services.TerminationService) *TerminationController {
+	return &TerminationController{termination: termination}
+}
+
+// TerminateEmployee - HR records an employee's last working day. Leave past it
+// is cancelled, final pay is drafted and offboarding starts; the account is
+// deactivated once the day is over.
+func (tc *TerminationController) TerminateEmployee(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid employee ID"})
+		return
+	}
+
+	var req TerminateEmployeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	termination, err := tc.termination.Terminate(id, services.TerminationInput{
+		LastWorkingDay: req.LastWorkingDay,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		respondFailure(c, err, "Failed to terminate employee")
+		return
+	}
+
+	message := "Employee terminated"
+	if termination.AccountDeactivated {
+		message += " and account deactivated"
+	} else if termination.Employee.Status != "terminated" {
+		message = "Termination recorded; the employee leaves after " +
+			termination.FinalPay.PeriodEnd.Format("2006-01-02")
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data": TerminationResponse{
+			Employee:           termination.Employee,
+			Settlement:         termination.Settlement,
+			ProratedSalary:     termination.FinalPay.ProratedSalary,
+			UnusedLeaveDays:    termination.FinalPay.UnusedLeaveDays,
+			LeaveEncashment:    termination.FinalPay.LeaveEncashment,
+			CancelledLeaveIDs:  termination.CancelledLeave,
+			ShortenedLeaveIDs:  termination.ShortenedLeave,
+			ChecklistTasks:     termination.ChecklistTasks,
+			AccountDeactivated: termination.AccountDeactivated,
+		},
+	})
+}
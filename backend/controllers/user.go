@@ -1,13 +1,13 @@
 package controllers
 
 import (
+	"hrms-backend/middleware"
 	"hrms-backend/models"
+	"hrms-backend/services"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // UserResponse represents the user data structure expected by frontend
@@ -21,6 +21,7 @@ type UserResponse struct {
 	IsActive   bool              `json:"isActive"`
 	EmployeeID *int              `json:"employeeId,omitempty"`
 	Employee   *EmployeeResponse `json:"employee,omitempty"`
+	Version    uint              `json:"version"`
 }
 
 // Helper function to convert model to response format
@@ -43,87 +44,149 @@ func (uc *UserController) transformUserResponse(user models.User) UserResponse {
 		IsActive:   user.IsActive,
 		EmployeeID: employeeID,
 		// Employee field would need separate handling if needed
+		Version: user.Version,
+	}
+}
+
+// CreateUserRequest represents the payload accepted when HR creates an account
+type CreateUserRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=8,max=72"`
+	FirstName  string `json:"firstName" binding:"required,max=100"`
+	LastName   string `json:"lastName" binding:"required,max=100"`
+	Role       string `json:"role" binding:"omitempty,oneof=admin hr manager employee"`
+	IsActive   *bool  `json:"isActive"`
+	EmployeeID *uint  `json:"employeeId" binding:"omitempty,exists=employees"`
+}
+
+// UpdateUserRequest is the writable view of an account. Updates are sent as a
+// JSON Merge Patch against this view; which columns are actually written
+// depends on the caller's role. Password is write-only and never echoed back.
+type UpdateUserRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password,omitempty" binding:"omitempty,min=8,max=72"`
+	FirstName  string `json:"firstName" binding:"required,max=100"`
+	LastName   string `json:"lastName" binding:"required,max=100"`
+	Role       string `json:"role" binding:"required,oneof=admin hr manager employee"`
+	IsActive   bool   `json:"isActive"`
+	EmployeeID *uint  `json:"employeeId" binding:"omitempty,exists=employees"`
+}
+
+// UpdateCurrentUserRequest represents the profile fields users may change on their own account
+type UpdateCurrentUserRequest struct {
+	FirstName string `json:"firstName" binding:"max=100"`
+	LastName  string `json:"lastName" binding:"max=100"`
+	Password  string `json:"password,omitempty" binding:"omitempty,min=8,max=72"`
+}
+
+func (r CreateUserRequest) input() services.NewUserInput {
+	return services.NewUserInput{
+		Email:      r.Email,
+		Password:   r.Password,
+		FirstName:  r.FirstName,
+		LastName:   r.LastName,
+		Role:       r.Role,
+		IsActive:   r.IsActive,
+		EmployeeID: r.EmployeeID,
+	}
+}
+
+func newUpdateUserRequest(user models.User) UpdateUserRequest {
+	return UpdateUserRequest{
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Role:       user.Role,
+		IsActive:   user.IsActive,
+		EmployeeID: user.EmployeeID,
+	}
+}
+
+func (r UpdateUserRequest) input() services.UserInput {
+	return services.UserInput{
+		Email:      r.Email,
+		Password:   r.Password,
+		FirstName:  r.FirstName,
+		LastName:   r.LastName,
+		Role:       r.Role,
+		IsActive:   r.IsActive,
+		EmployeeID: r.EmployeeID,
+	}
+}
+
+func (r UpdateCurrentUserRequest) input() services.ProfileInput {
+	return services.ProfileInput{
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+		Password:  r.Password,
 	}
 }
 
 type UserController struct {
-	db *gorm.DB
+	users *services.UserService
 }
 
-func NewUserController(db *gorm.DB) *UserController {
-	return &UserController{db: db}
+func NewUserController(users *services.UserService) *UserController {
+	return &UserController{users: users}
 }
 
 func (uc *UserController) GetCurrentUser(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := middleware.CurrentUserID(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
 		return
 	}
 
-	var user models.User
-	if err := uc.db.Preload("Employee").First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	user, err := uc.users.Get(userID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch user")
 		return
 	}
 
-	response := uc.transformUserResponse(user)
+	setETag(c, user.Version)
+	response := uc.transformUserResponse(*user)
 	c.JSON(http.StatusOK, response)
 }
 
 func (uc *UserController) UpdateCurrentUser(c *gin.Context) {
-	userID, exists := c.Get("userID")
+	userID, exists := middleware.CurrentUserID(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
 		return
 	}
 
-	var user models.User
-	if err := uc.db.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	user, err := uc.users.Get(userID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch user")
 		return
 	}
 
-	var updateData struct {
-		FirstName string `json:"firstName"`
-		LastName  string `json:"lastName"`
-		Password  string `json:"password,omitempty"`
+	if !ifMatchSatisfied(c, user.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "User has been modified by another request"})
+		return
 	}
 
+	var updateData UpdateCurrentUserRequest
+
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Update allowed fields
-	if updateData.FirstName != "" {
-		user.FirstName = updateData.FirstName
-	}
-	if updateData.LastName != "" {
-		user.LastName = updateData.LastName
-	}
-	if updateData.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updateData.Password), bcrypt.DefaultCost)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-			return
-		}
-		user.Password = string(hashedPassword)
-	}
-
-	if err := uc.db.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	if err := uc.users.UpdateProfile(user, updateData.input()); err != nil {
+		respondError(c, err, "Failed to update user")
 		return
 	}
+	setETag(c, user.Version)
 
 	user.Password = "" // Remove password from response
 	c.JSON(http.StatusOK, user)
 }
 
 func (uc *UserController) GetUsers(c *gin.Context) {
-	var users []models.User
-	if err := uc.db.Preload("Employee").Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+	users, err := uc.users.List()
+	if err != nil {
+		respondError(c, err, "Failed to fetch users")
 		return
 	}
 
@@ -137,39 +200,33 @@ func (uc *UserController) GetUsers(c *gin.Context) {
 }
 
 func (uc *UserController) GetUser(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	var user models.User
-	if err := uc.db.Preload("Employee").First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	user, err := uc.users.Get(id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch user")
 		return
 	}
 
+	setETag(c, user.Version)
 	user.Password = "" // Remove password from response
 	c.JSON(http.StatusOK, user)
 }
 
 func (uc *UserController) CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	user, err := uc.users.Create(currentActor(c), req.input())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
-	}
-	user.Password = string(hashedPassword)
-
-	if err := uc.db.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		respondError(c, err, "Failed to create user")
 		return
 	}
 
@@ -178,52 +235,48 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 }
 
 func (uc *UserController) UpdateUser(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	var user models.User
-	if err := uc.db.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	user, err := uc.users.Get(id)
+	if err != nil {
+		respondError(c, err, "Failed to fetch user")
 		return
 	}
 
-	var updateData models.User
-	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !ifMatchSatisfied(c, user.Version) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "User has been modified by another request"})
 		return
 	}
 
-	// Hash password if provided
-	if updateData.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updateData.Password), bcrypt.DefaultCost)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-			return
-		}
-		updateData.Password = string(hashedPassword)
+	req := newUpdateUserRequest(*user)
+	if err := bindMergePatch(c, &req); err != nil {
+		c.JSON(patchStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := uc.db.Model(&user).Updates(updateData).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+	if err := uc.users.Update(currentActor(c), user, req.input()); err != nil {
+		respondError(c, err, "Failed to update user")
 		return
 	}
+	setETag(c, user.Version)
 
 	user.Password = "" // Remove password from response
 	c.JSON(http.StatusOK, user)
 }
 
 func (uc *UserController) DeleteUser(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	if err := uc.db.Delete(&models.User{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+	if err := uc.users.Delete(currentActor(c), id); err != nil {
+		respondError(c, err, "Failed to delete user")
 		return
 	}
 
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// existsModels maps the table names accepted by the "exists" binding rule to
+// the model used to look them up, so soft-deleted rows are not considered.
+var existsModels = map[string]interface{}{
+	"users":       &models.User{},
+	"departments": &models.Department{},
+	"employees":   &models.Employee{},
+}
+
+// RegisterValidators installs the custom binding rules used by the request DTOs.
+// It must be called once before the routes start serving requests.
+func RegisterValidators(db *gorm.DB) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected binding validator engine")
+	}
+
+	return v.RegisterValidation("exists", func(fl validator.FieldLevel) bool {
+		model, ok := existsModels[fl.Param()]
+		if !ok {
+			return false
+		}
+
+		field := fl.Field()
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				return true
+			}
+			field = field.Elem()
+		}
+
+		var id uint64
+		switch field.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			id = field.Uint()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if field.Int() < 0 {
+				return false
+			}
+			id = uint64(field.Int())
+		default:
+			return false
+		}
+
+		var count int64
+		if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+			return false
+		}
+		return count > 0
+	})
+}
@@ -1,33 +1,130 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"hrms-backend/config"
-	"hrms-backend/models"
+	"net"
+	"sort"
+	"strings"
 
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
 )
 
+// Driver opens connections to one database backend
+type Driver struct {
+	Open func(cfg *config.Config) (gorm.Dialector, error)
+	// OpenReplica opens the read replica at address, one item of DB_REPLICAS
+	OpenReplica func(cfg *config.Config, address string) (gorm.Dialector, error)
+	// Configure optionally tunes the connection pool once connected
+	Configure func(sqlDB *sql.DB, cfg *config.Config)
+	// Retryable reports whether err is a serialization failure or deadlock
+	// after which the whole transaction may safely be run again
+	Retryable func(err error) bool
+}
+
+// Reports names the resolver that sends report queries to the read replicas.
+// Queries opt in with Clauses(dbresolver.Use(Reports)); everything else,
+// including all writes and transactions, stays on the primary so callers
+// always read their own writes. Without replicas reports use the primary too.
+const Reports = "reports"
+
+// drivers maps DB_DRIVER values to their drivers. Each backend registers
+// itself from its own file so it can be left out with build constraints.
+var drivers = map[string]Driver{}
+
+func registerDriver(name string, driver Driver) {
+	drivers[name] = driver
+}
+
+// Drivers returns the names of the database backends compiled into this build
+func Drivers() []string {
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Retryable returns the serialization failure check of the backend behind db
+func Retryable(db *gorm.DB) func(err error) bool {
+	if driver, ok := drivers[db.Dialector.Name()]; ok && driver.Retryable != nil {
+		return driver.Retryable
+	}
+	return func(error) bool { return false }
+}
+
 func InitDB(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, cfg.DBSSLMode)
+	driver, ok := drivers[cfg.DBDriver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q (available: %s)", cfg.DBDriver, strings.Join(Drivers(), ", "))
+	}
+
+	dialector, err := driver.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if len(cfg.DBReplicas) > 0 {
+		if err := registerReplicas(db, driver, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to access connection pool: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	// Tuned after the replicas, whose pool settings also reach the primary,
+	// so that driver overrides such as SQLite's single in-memory connection win
+	if driver.Configure != nil {
+		driver.Configure(sqlDB, cfg)
+	}
+
 	return db, nil
 }
 
-func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.User{},
-		&models.Department{},
-		&models.Employee{},
-		&models.LeaveRequest{},
-		&models.Attendance{},
-		&models.PayrollRecord{},
-	)
+func registerReplicas(db *gorm.DB, driver Driver, cfg *config.Config) error {
+	if driver.OpenReplica == nil {
+		return fmt.Errorf("the %s driver does not support read replicas", cfg.DBDriver)
+	}
+
+	replicas := make([]gorm.Dialector, 0, len(cfg.DBReplicas))
+	for _, address := range cfg.DBReplicas {
+		replica, err := driver.OpenReplica(cfg, address)
+		if err != nil {
+			return err
+		}
+		replicas = append(replicas, replica)
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{Replicas: replicas}, Reports)
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("failed to connect to read replicas: %w", err)
+	}
+	// Replicas get the same pool limits as the primary
+	resolver.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	resolver.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	resolver.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	resolver.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	return nil
+}
+
+// splitHostPort splits a replica address, using defaultPort when it has none
+func splitHostPort(address, defaultPort string) (string, string) {
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		return host, port
+	}
+	return address, defaultPort
 }
@@ -0,0 +1,48 @@
+//go:build cgo
+
+package database
+
+import (
+	"hrms-backend/config"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/plugin/dbresolver"
+)
+
+func TestReportsReadFromReplicas(t *testing.T) {
+	dir := t.TempDir()
+	primaryPath := filepath.Join(dir, "primary.db")
+	replicaPath := filepath.Join(dir, "replica.db")
+
+	// Tell the two databases apart by what they hold
+	for path, name := range map[string]string{primaryPath: "primary", replicaPath: "replica"} {
+		db, err := InitDB(&config.Config{DBDriver: "sqlite", DBPath: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Exec("CREATE TABLE origin (name TEXT)").Error; err != nil {
+			t.Fatal(err)
+		}
+		db.Exec("INSERT INTO origin (name) VALUES (?)", name)
+	}
+
+	db, err := InitDB(&config.Config{DBDriver: "sqlite", DBPath: primaryPath, DBReplicas: []string{replicaPath}, DBMaxOpenConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report, ordinary string
+	if err := db.Clauses(dbresolver.Use(Reports)).Table("origin").Select("name").Scan(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Table("origin").Select("name").Scan(&ordinary).Error; err != nil {
+		t.Fatal(err)
+	}
+	if report != "replica" {
+		t.Errorf("report read from the %s, want the replica", report)
+	}
+	if ordinary != "primary" {
+		t.Errorf("ordinary read from the %s, want the primary", ordinary)
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"hrms-backend/config"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDriver("mysql", Driver{
+		Open: func(cfg *config.Config) (gorm.Dialector, error) {
+			return mysql.Open(mysqlDSN(cfg, cfg.DBHost, cfg.DBPort)), nil
+		},
+		OpenReplica: func(cfg *config.Config, address string) (gorm.Dialector, error) {
+			host, port := splitHostPort(address, cfg.DBPort)
+			return mysql.Open(mysqlDSN(cfg, host, port)), nil
+		},
+		Retryable: func(err error) bool {
+			var mysqlErr *mysqldriver.MySQLError
+			if !errors.As(err, &mysqlErr) {
+				return false
+			}
+			// ER_LOCK_DEADLOCK and ER_LOCK_WAIT_TIMEOUT
+			return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+		},
+	})
+}
+
+func mysqlDSN(cfg *config.Config, host, port string) string {
+	// parseTime scans DATETIME columns into time.Time; loc keeps them in UTC
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=true&loc=UTC",
+		cfg.DBUser, cfg.DBPassword, host, port, cfg.DBName)
+}
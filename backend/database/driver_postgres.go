@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"hrms-backend/config"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDriver("postgres", Driver{
+		Open: func(cfg *config.Config) (gorm.Dialector, error) {
+			return postgres.Open(postgresDSN(cfg, cfg.DBHost, cfg.DBPort)), nil
+		},
+		OpenReplica: func(cfg *config.Config, address string) (gorm.Dialector, error) {
+			host, port := splitHostPort(address, cfg.DBPort)
+			return postgres.Open(postgresDSN(cfg, host, port)), nil
+		},
+		Retryable: func(err error) bool {
+			var pgErr *pgconn.PgError
+			if !errors.As(err, &pgErr) {
+				return false
+			}
+			// serialization_failure and deadlock_detected
+			return pgErr.Code == "40001" || pgErr.Code == "40P01"
+		},
+	})
+}
+
+func postgresDSN(cfg *config.Config, host, port string) string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		host, cfg.DBUser, cfg.DBPassword, cfg.DBName, port, cfg.DBSSLMode)
+}
@@ -0,0 +1,59 @@
+//go:build cgo
+
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"hrms-backend/config"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registerDriver("sqlite", Driver{
+		Open: func(cfg *config.Config) (gorm.Dialector, error) {
+			if cfg.DBPath == "" {
+				return nil, errors.New("DB_PATH is required for the sqlite driver")
+			}
+			return sqlite.Open(sqliteDSN(cfg.DBPath)), nil
+		},
+		OpenReplica: func(_ *config.Config, path string) (gorm.Dialector, error) {
+			// A replica is a copy of the database file, for example one
+			// kept up to date by streaming replication, opened read-only
+			return sqlite.Open("file:" + path + "?mode=ro&_busy_timeout=5000"), nil
+		},
+		Configure: func(sqlDB *sql.DB, cfg *config.Config) {
+			if cfg.DBPath == ":memory:" {
+				// Every connection to :memory: opens a new, empty database,
+				// so keep exactly one open for the life of the process
+				sqlDB.SetMaxOpenConns(1)
+				sqlDB.SetMaxIdleConns(1)
+				sqlDB.SetConnMaxLifetime(0)
+				sqlDB.SetConnMaxIdleTime(0)
+			}
+		},
+		Retryable: func(err error) bool {
+			// Writers that outlast the busy timeout fail with SQLITE_BUSY
+			var sqliteErr sqlite3.Error
+			if !errors.As(err, &sqliteErr) {
+				return false
+			}
+			return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+		},
+	})
+}
+
+// sqliteDSN returns the connection string for a SQLite database file, or for
+// an in-memory database when path is ":memory:"
+func sqliteDSN(path string) string {
+	// SQLite leaves foreign keys unenforced unless asked, and a busy timeout
+	// makes concurrent writers wait for the lock instead of failing at once
+	options := "_foreign_keys=on&_busy_timeout=5000"
+	if path == ":memory:" {
+		return "file::memory:?" + options
+	}
+	return "file:" + path + "?_journal_mode=WAL&" + options
+}
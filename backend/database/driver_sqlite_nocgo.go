@@ -0,0 +1,18 @@
+//go:build !cgo
+
+package database
+
+import (
+	"errors"
+	"hrms-backend/config"
+
+	"gorm.io/gorm"
+)
+
+// The SQLite driver wraps the C library, so builds without cgo report a clear
+// error instead of failing on the first query
+func init() {
+	registerDriver("sqlite", Driver{Open: func(cfg *config.Config) (gorm.Dialector, error) {
+		return nil, errors.New("the sqlite driver requires a build with CGO_ENABLED=1")
+	}})
+}
@@ -0,0 +1,16 @@
+//go:build !cgo
+
+package database
+
+import (
+	"hrms-backend/config"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteDriverNeedsCgo(t *testing.T) {
+	_, err := InitDB(&config.Config{DBDriver: "sqlite", DBPath: ":memory:"})
+	if err == nil || !strings.Contains(err.Error(), "CGO_ENABLED=1") {
+		t.Errorf("InitDB = %v, want an error asking for cgo", err)
+	}
+}
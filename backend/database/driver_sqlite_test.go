@@ -0,0 +1,27 @@
+//go:build cgo
+
+package database
+
+import (
+	"hrms-backend/config"
+	"testing"
+)
+
+func TestSQLiteDriver(t *testing.T) {
+	db, err := InitDB(&config.Config{DBDriver: "sqlite", DBPath: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := db.Dialector.Name(); name != "sqlite" {
+		t.Errorf("dialector = %s, want sqlite", name)
+	}
+	var foreignKeys int
+	db.Raw("PRAGMA foreign_keys").Scan(&foreignKeys)
+	if foreignKeys != 1 {
+		t.Error("foreign keys are not enforced")
+	}
+
+	if _, err := InitDB(&config.Config{DBDriver: "sqlite"}); err == nil {
+		t.Error("opened SQLite without DB_PATH")
+	}
+}
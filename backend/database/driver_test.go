@@ -0,0 +1,63 @@
+package database
+
+import (
+	"hrms-backend/config"
+	"strings"
+	"testing"
+)
+
+func TestDriversRegisterThemselves(t *testing.T) {
+	cfg := &config.Config{DBHost: "db.internal", DBPort: "5432", DBUser: "hrms", DBName: "hrms", DBSSLMode: "disable", DBPath: "hrms.db"}
+	for _, name := range []string{"postgres", "mysql", "sqlite"} {
+		driver, ok := drivers[name]
+		if !ok {
+			t.Errorf("the %s driver is not registered (have %v)", name, Drivers())
+			continue
+		}
+		if driver.Open == nil {
+			t.Errorf("the %s driver has no Open", name)
+		}
+	}
+
+	// Opening a dialector does not connect, so the server drivers can be
+	// checked without a database
+	for _, name := range []string{"postgres", "mysql"} {
+		dialector, err := drivers[name].Open(cfg)
+		if err != nil {
+			t.Errorf("%s Open: %v", name, err)
+			continue
+		}
+		if dialector.Name() != name {
+			t.Errorf("%s Open returned a %s dialector", name, dialector.Name())
+		}
+		replica, err := drivers[name].OpenReplica(cfg, "replica.internal:6543")
+		if err != nil || replica.Name() != name {
+			t.Errorf("%s OpenReplica = %v, %v", name, replica, err)
+		}
+	}
+}
+
+func TestServerDSNs(t *testing.T) {
+	cfg := &config.Config{DBUser: "hrms", DBPassword: "secret", DBName: "people", DBSSLMode: "require"}
+
+	host, port := splitHostPort("replica.internal", "5432")
+	if got, want := postgresDSN(cfg, host, port), "host=replica.internal user=hrms password=secret dbname=people port=5432 sslmode=require"; got != want {
+		t.Errorf("postgresDSN = %q, want %q", got, want)
+	}
+	host, port = splitHostPort("replica.internal:3307", "3306")
+	if got, want := mysqlDSN(cfg, host, port), "hrms:secret@tcp(replica.internal:3307)/people?charset=utf8mb4&parseTime=true&loc=UTC"; got != want {
+		t.Errorf("mysqlDSN = %q, want %q", got, want)
+	}
+}
+
+func TestUnknownDriver(t *testing.T) {
+	_, err := InitDB(&config.Config{DBDriver: "oracle"})
+	if err == nil {
+		t.Fatal("InitDB accepted an unknown driver")
+	}
+	for _, want := range []string{`"oracle"`, "mysql, postgres, sqlite"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
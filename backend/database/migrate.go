@@ -0,0 +1,286 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned schema change. Up and Down each run in their own
+// transaction and must not reference the models package: migrations describe
+// the schema as it was when they were written, not as it is today.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// MigrationStatus describes a known migration and whether it has been applied
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+}
+
+// migrationLockID identifies the advisory lock held while migrating
+const migrationLockID = 4_815_162_342
+
+// Migrator applies and rolls back versioned migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Migrate applies all pending migrations
+func Migrate(db *gorm.DB) error {
+	return NewMigrator(db).Up()
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the given number of most recently applied migrations
+func (m *Migrator) Down(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+	return m.withLock(func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		versions := appliedVersions(applied)
+		if steps > len(versions) {
+			steps = len(versions)
+		}
+		for _, version := range versions[len(versions)-steps:] {
+			if err := m.rollback(conn, version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// To migrates up or down until exactly the migrations up to version are applied.
+// Version 0 rolls back everything.
+func (m *Migrator) To(version uint) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+			if err := m.rollback(conn, versions[i]); err != nil {
+				return err
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists every known migration, plus any applied migration this build
+// does not know about, in version order
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if record, ok := applied[migration.Version]; ok {
+				appliedAt := record.AppliedAt
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		for version, record := range applied {
+			if m.find(version) == nil {
+				appliedAt := record.AppliedAt
+				statuses = append(statuses, MigrationStatus{Version: version, Name: record.Name, AppliedAt: &appliedAt})
+			}
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// Version returns the highest applied migration version, or 0 on a fresh database
+func (m *Migrator) Version() (uint, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+	var version uint
+	err := m.db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Latest returns the highest known migration version
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(conn *gorm.DB, migration Migration) error {
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+func (m *Migrator) rollback(conn *gorm.DB, version uint) error {
+	migration := m.find(version)
+	if migration == nil {
+		return fmt.Errorf("cannot roll back migration %d: not known to this build", version)
+	}
+	if migration.Down == nil {
+		return fmt.Errorf("migration %d_%s is irreversible", migration.Version, migration.Name)
+	}
+
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	log.Printf("Rolled back migration %d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, so that
+// replicas booting at the same time apply migrations one after another
+func (m *Migrator) withLock(fn func(conn *gorm.DB) error) error {
+	return m.db.Connection(func(tx *gorm.DB) error {
+		conn := tx.Session(&gorm.Session{})
+		if err := lockMigrations(conn); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			if err := unlockMigrations(conn); err != nil {
+				log.Println("Warning: Failed to release migration lock:", err)
+			}
+		}()
+
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+		return fn(conn)
+	})
+}
+
+func lockMigrations(conn *gorm.DB) error {
+	switch conn.Dialector.Name() {
+	case "postgres":
+		return conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error
+	case "mysql":
+		var acquired int
+		if err := conn.Raw("SELECT GET_LOCK(?, -1)", fmt.Sprint(migrationLockID)).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if acquired != 1 {
+			return errors.New("GET_LOCK did not succeed")
+		}
+	}
+	// SQLite serialises writers on its own
+	return nil
+}
+
+func unlockMigrations(conn *gorm.DB) error {
+	switch conn.Dialector.Name() {
+	case "postgres":
+		return conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID).Error
+	case "mysql":
+		return conn.Exec("SELECT RELEASE_LOCK(?)", fmt.Sprint(migrationLockID)).Error
+	}
+	return nil
+}
+
+func appliedMigrations(conn *gorm.DB) (map[uint]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := conn.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	applied := make(map[uint]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// appliedVersions returns the applied versions in ascending order
+func appliedVersions(applied map[uint]SchemaMigration) []uint {
+	versions := make([]uint, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
@@ -0,0 +1,152 @@
+//go:build cgo
+
+package database
+
+import (
+	"hrms-backend/config"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func openMigrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := InitDB(&config.Config{DBDriver: "sqlite", DBPath: filepath.Join(t.TempDir(), "hrms.db"), DBMaxOpenConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func appliedCount(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&SchemaMigration{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestMigrateUpAndRollBack(t *testing.T) {
+	db := openMigrationDB(t)
+	migrator := NewMigrator(db)
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := migrator.Version(); version != migrator.Latest() {
+		t.Fatalf("version = %d after up, want %d", version, migrator.Latest())
+	}
+	for _, table := range []string{"users", "employees", "documents", "skills", "idempotency_keys"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing after up", table)
+		}
+	}
+	if !db.Migrator().HasColumn("idempotency_keys", "response_headers") {
+		t.Error("idempotency_keys.response_headers is missing after up")
+	}
+
+	if err := migrator.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := migrator.Version(); version != migrator.Latest()-1 {
+		t.Errorf("version = %d after down 1, want %d", version, migrator.Latest()-1)
+	}
+	if db.Migrator().HasColumn("idempotency_keys", "response_headers") {
+		t.Error("idempotency_keys.response_headers survived its rollback")
+	}
+
+	if err := migrator.To(0); err != nil {
+		t.Fatal(err)
+	}
+	if count := appliedCount(t, db); count != 0 {
+		t.Errorf("%d migrations recorded after rolling back everything", count)
+	}
+	for _, table := range []string{"users", "employees", "documents"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s survived rolling back everything", table)
+		}
+	}
+
+	// Every Down must leave the schema in a state its Up can rebuild
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("up after a full rollback: %v", err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	db := openMigrationDB(t)
+	migrator := NewMigrator(db)
+
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	var first SchemaMigration
+	db.First(&first, 1)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if err := migrator.To(migrator.Latest()); err != nil {
+		t.Fatalf("to the current version: %v", err)
+	}
+
+	if count := appliedCount(t, db); count != int64(len(migrations)) {
+		t.Errorf("%d migrations recorded, want %d", count, len(migrations))
+	}
+	var again SchemaMigration
+	db.First(&again, 1)
+	if !again.AppliedAt.Equal(first.AppliedAt) {
+		t.Errorf("migration 1 was reapplied at %s", again.AppliedAt)
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	db := openMigrationDB(t)
+	migrator := NewMigrator(db)
+
+	if err := migrator.To(5); err != nil {
+		t.Fatal(err)
+	}
+	// A migration applied by a newer build
+	if err := db.Create(&SchemaMigration{Version: 99, Name: "from_the_future", AppliedAt: time.Now()}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(migrations)+1 {
+		t.Fatalf("%d statuses, want %d", len(statuses), len(migrations)+1)
+	}
+	for i, status := range statuses[:len(migrations)] {
+		if status.Version != migrations[i].Version || status.Name != migrations[i].Name {
+			t.Errorf("status %d = %d_%s, want %d_%s", i, status.Version, status.Name, migrations[i].Version, migrations[i].Name)
+		}
+		if applied := status.AppliedAt != nil; applied != (status.Version <= 5) {
+			t.Errorf("migration %d applied = %v", status.Version, applied)
+		}
+	}
+	if unknown := statuses[len(statuses)-1]; unknown.Version != 99 || unknown.Name != "from_the_future" || unknown.AppliedAt == nil {
+		t.Errorf("unknown migration status = %+v", unknown)
+	}
+
+	// Rolling back past a migration this build does not know fails cleanly
+	if err := migrator.Down(1); err == nil {
+		t.Error("rolled back a migration this build does not know")
+	}
+}
+
+func TestMigrateRejectsBadArguments(t *testing.T) {
+	migrator := NewMigrator(openMigrationDB(t))
+
+	if err := migrator.To(migrator.Latest() + 1); err == nil {
+		t.Error("migrated to an unknown version")
+	}
+	if err := migrator.Down(0); err == nil {
+		t.Error("rolled back zero steps")
+	}
+}
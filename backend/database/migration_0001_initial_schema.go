@@ -0,0 +1,158 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tables as of the initial migration. These are frozen copies of the models;
+// later schema changes belong in new migrations, not here.
+
+type v1User struct {
+	gorm.Model
+	Email      string `gorm:"size:191;uniqueIndex;not null"`
+	Password   string `gorm:"not null"`
+	FirstName  string `gorm:"not null"`
+	LastName   string `gorm:"not null"`
+	Role       string `gorm:"not null;default:'employee'"`
+	IsActive   bool   `gorm:"default:true"`
+	EmployeeID *uint
+	Employee   *v1Employee `gorm:"foreignKey:EmployeeID"`
+	Version    uint        `gorm:"not null;default:1"`
+}
+
+func (v1User) TableName() string { return "users" }
+
+type v1Department struct {
+	gorm.Model
+	Name        string `gorm:"size:191;not null;uniqueIndex"`
+	Description string
+	ManagerID   *uint
+	Manager     *v1Employee  `gorm:"foreignKey:ManagerID"`
+	Employees   []v1Employee `gorm:"foreignKey:DepartmentID"`
+	Version     uint         `gorm:"not null;default:1"`
+}
+
+func (v1Department) TableName() string { return "departments" }
+
+type v1Employee struct {
+	gorm.Model
+	EmployeeCode      string `gorm:"size:191;uniqueIndex;not null"`
+	FirstName         string `gorm:"not null"`
+	LastName          string `gorm:"not null"`
+	Email             string `gorm:"size:191;uniqueIndex;not null"`
+	Phone             string
+	Address           string
+	DateOfBirth       *time.Time
+	HireDate          time.Time    `gorm:"not null"`
+	Salary            float64      `gorm:"not null"`
+	Position          string       `gorm:"not null"`
+	Status            string       `gorm:"not null;default:'active'"`
+	DepartmentID      uint         `gorm:"not null"`
+	Department        v1Department `gorm:"foreignKey:DepartmentID"`
+	ManagerID         *uint
+	Manager           *v1Employee       `gorm:"foreignKey:ManagerID"`
+	User              *v1User           `gorm:"foreignKey:EmployeeID"`
+	LeaveRequests     []v1LeaveRequest  `gorm:"foreignKey:EmployeeID"`
+	AttendanceRecords []v1Attendance    `gorm:"foreignKey:EmployeeID"`
+	PayrollRecords    []v1PayrollRecord `gorm:"foreignKey:EmployeeID"`
+	Version           uint              `gorm:"not null;default:1"`
+}
+
+func (v1Employee) TableName() string { return "employees" }
+
+type v1LeaveRequest struct {
+	gorm.Model
+	EmployeeID uint       `gorm:"not null"`
+	Employee   v1Employee `gorm:"foreignKey:EmployeeID"`
+	LeaveType  string     `gorm:"not null"`
+	StartDate  time.Time  `gorm:"not null"`
+	EndDate    time.Time  `gorm:"not null"`
+	Days       int        `gorm:"not null"`
+	Reason     string
+	Status     string `gorm:"not null;default:'pending'"`
+	ApprovedBy *uint
+	Approver   *v1Employee `gorm:"foreignKey:ApprovedBy"`
+	ApprovedAt *time.Time
+	Comments   string
+	Version    uint `gorm:"not null;default:1"`
+}
+
+func (v1LeaveRequest) TableName() string { return "leave_requests" }
+
+type v1Attendance struct {
+	gorm.Model
+	EmployeeID   uint       `gorm:"not null"`
+	Employee     v1Employee `gorm:"foreignKey:EmployeeID"`
+	Date         time.Time  `gorm:"not null"`
+	CheckIn      *time.Time
+	CheckOut     *time.Time
+	Status       string  `gorm:"not null;default:'present'"`
+	WorkingHours float64 `gorm:"default:0"`
+	Comments     string
+	Version      uint `gorm:"not null;default:1"`
+}
+
+func (v1Attendance) TableName() string { return "attendances" }
+
+type v1PayrollRecord struct {
+	gorm.Model
+	EmployeeID     uint       `gorm:"not null"`
+	Employee       v1Employee `gorm:"foreignKey:EmployeeID"`
+	PayPeriodStart time.Time  `gorm:"not null"`
+	PayPeriodEnd   time.Time  `gorm:"not null"`
+	BasicSalary    float64    `gorm:"not null"`
+	Allowances     float64    `gorm:"default:0"`
+	Deductions     float64    `gorm:"default:0"`
+	Overtime       float64    `gorm:"default:0"`
+	GrossPay       float64    `gorm:"not null"`
+	Tax            float64    `gorm:"default:0"`
+	NetPay         float64    `gorm:"not null"`
+	Status         string     `gorm:"not null;default:'draft'"`
+	ProcessedAt    *time.Time
+	PaidAt         *time.Time
+	Version        uint `gorm:"not null;default:1"`
+}
+
+func (v1PayrollRecord) TableName() string { return "payroll_records" }
+
+type v1IdempotencyKey struct {
+	ID           uint   `gorm:"primarykey"`
+	Key          string `gorm:"column:idempotency_key;size:255;not null;uniqueIndex:idx_idempotency_keys_key_user"`
+	UserID       uint   `gorm:"not null;uniqueIndex:idx_idempotency_keys_key_user"`
+	Method       string `gorm:"not null"`
+	Path         string `gorm:"not null"`
+	RequestHash  string `gorm:"not null"`
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+func (v1IdempotencyKey) TableName() string { return "idempotency_keys" }
+
+func initialSchemaTables() []interface{} {
+	return []interface{}{
+		&v1User{},
+		&v1Department{},
+		&v1Employee{},
+		&v1LeaveRequest{},
+		&v1Attendance{},
+		&v1PayrollRecord{},
+		&v1IdempotencyKey{},
+	}
+}
+
+// initialSchemaUp creates the schema previously maintained by AutoMigrate. It
+// uses AutoMigrate on the frozen tables so databases created before versioned
+// migrations are adopted in place: missing tables and columns are added and
+// existing data is left untouched.
+func initialSchemaUp(tx *gorm.DB) error {
+	return tx.Migrator().AutoMigrate(initialSchemaTables()...)
+}
+
+func initialSchemaDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(initialSchemaTables()...)
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// listIndex is a secondary index on a single column
+type listIndex struct {
+	table  string
+	column string
+}
+
+func (i listIndex) name() string {
+	return "idx_" + i.table + "_" + i.column
+}
+
+// listIndexes cover the foreign keys, dates and statuses that list, report
+// and scope queries filter, join and sort on
+var listIndexes = []listIndex{
+	{"employees", "department_id"},
+	{"employees", "status"},
+	{"leave_requests", "employee_id"},
+	{"leave_requests", "status"},
+	{"attendances", "employee_id"},
+	{"attendances", "date"},
+	{"payroll_records", "employee_id"},
+	{"payroll_records", "pay_period_start"},
+}
+
+func listIndexesUp(tx *gorm.DB) error {
+	for _, index := range listIndexes {
+		// Databases adopted from AutoMigrate may already have some of them
+		if tx.Migrator().HasIndex(index.table, index.name()) {
+			continue
+		}
+		err := tx.Exec(fmt.Sprintf("CREATE INDEX %s ON %s (%s)", index.name(), index.table, index.column)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listIndexesDown(tx *gorm.DB) error {
+	for _, index := range listIndexes {
+		if !tx.Migrator().HasIndex(index.table, index.name()) {
+			continue
+		}
+		if err := tx.Migrator().DropIndex(index.table, index.name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
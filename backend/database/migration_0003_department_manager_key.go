@@ -0,0 +1,59 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// The initial schema read Department.Manager as a has-one relation through
+// employees.manager_id, so it constrained every employee's manager_id to be a
+// department ID. Employees whose manager has a higher ID than the last
+// department could not be saved.
+const badManagerConstraint = "fk_departments_manager"
+
+// badManagerConstraintSQLite is the constraint as AutoMigrate wrote it into
+// the SQLite employees table
+const badManagerConstraintSQLite = ",CONSTRAINT `fk_departments_manager` FOREIGN KEY (`manager_id`) REFERENCES `departments`(`id`)"
+
+func departmentManagerKeyUp(tx *gorm.DB) error {
+	if tx.Dialector.Name() == "sqlite" {
+		return dropSQLiteManagerConstraint(tx)
+	}
+	if !tx.Migrator().HasConstraint("employees", badManagerConstraint) {
+		return nil
+	}
+	return tx.Migrator().DropConstraint("employees", badManagerConstraint)
+}
+
+// departmentManagerKeyDown leaves the constraint out: it was never correct,
+// and restoring it would reject existing data
+func departmentManagerKeyDown(tx *gorm.DB) error {
+	return nil
+}
+
+// dropSQLiteManagerConstraint removes the constraint from the stored table
+// definition. SQLite can only drop constraints by rebuilding the table, which
+// it refuses inside a transaction while other tables reference employees, so
+// this uses the writable_schema procedure SQLite documents for removing
+// foreign keys, which leaves the stored rows untouched.
+func dropSQLiteManagerConstraint(tx *gorm.DB) error {
+	var schemaVersion int
+	if err := tx.Raw("PRAGMA schema_version").Scan(&schemaVersion).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Exec("PRAGMA writable_schema = ON").Error; err != nil {
+		return err
+	}
+	err := tx.Exec("UPDATE sqlite_master SET sql = replace(sql, ?, '') WHERE type = 'table' AND name = 'employees'",
+		badManagerConstraintSQLite).Error
+	if err != nil {
+		return err
+	}
+	// Other connections reload the schema once its version changes
+	if err := tx.Exec(fmt.Sprintf("PRAGMA schema_version = %d", schemaVersion+1)).Error; err != nil {
+		return err
+	}
+	return tx.Exec("PRAGMA writable_schema = OFF").Error
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v4FeatureFlag is the feature_flags table as this migration creates it. The
+// targeting lists hold JSON arrays.
+type v4FeatureFlag struct {
+	ID            uint   `gorm:"primarykey"`
+	Key           string `gorm:"column:flag_key;size:191;uniqueIndex;not null"`
+	Description   string
+	Enabled       bool   `gorm:"not null;default:false"`
+	Roles         string `gorm:"type:text"`
+	DepartmentIDs string `gorm:"type:text"`
+	UserIDs       string `gorm:"type:text"`
+	Version       uint   `gorm:"not null;default:1"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (v4FeatureFlag) TableName() string { return "feature_flags" }
+
+func featureFlagsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&v4FeatureFlag{})
+}
+
+func featureFlagsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v4FeatureFlag{})
+}
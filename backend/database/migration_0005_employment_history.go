@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v5EmploymentChange is the employment_changes table as this migration creates it
+type v5EmploymentChange struct {
+	ID            uint      `gorm:"primarykey"`
+	EmployeeID    uint      `gorm:"not null;index:idx_employment_changes_employee_date"`
+	EffectiveDate time.Time `gorm:"not null;index:idx_employment_changes_employee_date"`
+	Kind          string    `gorm:"not null"`
+	Status        string    `gorm:"not null;default:'applied'"`
+	Reason        string    `gorm:"not null"`
+	Position      *string
+	Salary        *float64
+	DepartmentID  *uint
+	ManagerID     *uint
+	ClearManager  bool `gorm:"not null;default:false"`
+	RecordedBy    *uint
+	AppliedAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (v5EmploymentChange) TableName() string { return "employment_changes" }
+
+// employmentHistoryUp creates the history and gives every existing employee a
+// hire record holding their current job, effective on their hire date
+func employmentHistoryUp(tx *gorm.DB) error {
+	if err := tx.Migrator().CreateTable(&v5EmploymentChange{}); err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO employment_changes
+		(employee_id, effective_date, kind, status, reason, position, salary, department_id, manager_id,
+		 clear_manager, applied_at, created_at, updated_at)
+		SELECT id, hire_date, 'hire', 'applied', 'Hired', position, salary, department_id, manager_id,
+		 ?, created_at, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM employees`, false).Error
+}
+
+func employmentHistoryDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v5EmploymentChange{})
+}
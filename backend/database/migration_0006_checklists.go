@@ -0,0 +1,65 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v6ChecklistTemplate is the checklist_templates table as this migration creates it
+type v6ChecklistTemplate struct {
+	ID           uint   `gorm:"primarykey"`
+	Name         string `gorm:"not null"`
+	Kind         string `gorm:"not null;index"`
+	DepartmentID *uint
+	Position     string
+	Active       bool `gorm:"not null;default:true"`
+	Version      uint `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (v6ChecklistTemplate) TableName() string { return "checklist_templates" }
+
+// v6ChecklistTemplateTask is the checklist_template_tasks table as this migration creates it
+type v6ChecklistTemplateTask struct {
+	ID            uint   `gorm:"primarykey"`
+	TemplateID    uint   `gorm:"not null;index"`
+	SortOrder     int    `gorm:"not null;default:0"`
+	Title         string `gorm:"not null"`
+	Description   string
+	Assignee      string `gorm:"not null"`
+	DueOffsetDays int    `gorm:"not null;default:0"`
+}
+
+func (v6ChecklistTemplateTask) TableName() string { return "checklist_template_tasks" }
+
+// v6ChecklistTask is the checklist_tasks table as this migration creates it
+type v6ChecklistTask struct {
+	ID             uint   `gorm:"primarykey"`
+	EmployeeID     uint   `gorm:"not null;index"`
+	TemplateID     uint   `gorm:"not null;index"`
+	Kind           string `gorm:"not null"`
+	Title          string `gorm:"not null"`
+	Description    string
+	Assignee       string    `gorm:"not null"`
+	AssigneeUserID *uint     `gorm:"index"`
+	DueDate        time.Time `gorm:"not null;index"`
+	Status         string    `gorm:"not null;default:'pending';index"`
+	Notes          string
+	CompletedAt    *time.Time
+	CompletedBy    *uint
+	Version        uint `gorm:"not null;default:1"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (v6ChecklistTask) TableName() string { return "checklist_tasks" }
+
+func checklistsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&v6ChecklistTemplate{}, &v6ChecklistTemplateTask{}, &v6ChecklistTask{})
+}
+
+func checklistsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v6ChecklistTask{}, &v6ChecklistTemplateTask{}, &v6ChecklistTemplate{})
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v7Employee holds the employees columns this migration adds
+type v7Employee struct {
+	TerminationDate   *time.Time `gorm:"index"`
+	TerminationReason string
+}
+
+func (v7Employee) TableName() string { return "employees" }
+
+var v7Columns = []string{"TerminationDate", "TerminationReason"}
+
+func employeeTerminationUp(tx *gorm.DB) error {
+	for _, column := range v7Columns {
+		// Databases adopted from AutoMigrate may already have them
+		if tx.Migrator().HasColumn(&v7Employee{}, column) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&v7Employee{}, column); err != nil {
+			return err
+		}
+	}
+	if tx.Migrator().HasIndex(&v7Employee{}, "TerminationDate") {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(&v7Employee{}, "TerminationDate")
+}
+
+func employeeTerminationDown(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&v7Employee{}, "TerminationDate") {
+		if err := tx.Migrator().DropIndex(&v7Employee{}, "TerminationDate"); err != nil {
+			return err
+		}
+	}
+	// GORM drops SQLite columns by rebuilding the table, which other tables'
+	// foreign keys to employees prevent; DROP COLUMN works everywhere
+	for _, column := range []string{"termination_date", "termination_reason"} {
+		if !tx.Migrator().HasColumn(&v7Employee{}, column) {
+			continue
+		}
+		if err := tx.Exec("ALTER TABLE employees DROP COLUMN " + column).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
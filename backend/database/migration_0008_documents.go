@@ -0,0 +1,35 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v8Document is the documents table as this migration creates it
+type v8Document struct {
+	ID          uint       `gorm:"primarykey"`
+	EmployeeID  uint       `gorm:"not null;index"`
+	Category    string     `gorm:"not null"`
+	Name        string     `gorm:"not null"`
+	ContentType string     `gorm:"not null"`
+	Size        int64      `gorm:"not null"`
+	Checksum    string     `gorm:"not null"`
+	StorageKey  string     `gorm:"not null;uniqueIndex"`
+	Visibility  string     `gorm:"not null;default:'hr'"`
+	ExpiresAt   *time.Time `gorm:"index"`
+	UploadedBy  uint       `gorm:"not null"`
+	Version     uint       `gorm:"not null;default:1"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v8Document) TableName() string { return "documents" }
+
+func documentsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&v8Document{})
+}
+
+func documentsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v8Document{})
+}
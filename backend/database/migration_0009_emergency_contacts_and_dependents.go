@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v9EmergencyContact is the emergency_contacts table as this migration creates it
+type v9EmergencyContact struct {
+	ID           uint   `gorm:"primarykey"`
+	EmployeeID   uint   `gorm:"not null;index"`
+	Name         string `gorm:"not null"`
+	Relationship string `gorm:"not null"`
+	Phone        string `gorm:"not null"`
+	Email        string
+	Priority     int  `gorm:"not null;default:1"`
+	Version      uint `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (v9EmergencyContact) TableName() string { return "emergency_contacts" }
+
+// v9Dependent is the dependents table as this migration creates it
+type v9Dependent struct {
+	ID           uint      `gorm:"primarykey"`
+	EmployeeID   uint      `gorm:"not null;index"`
+	FirstName    string    `gorm:"not null"`
+	LastName     string    `gorm:"not null"`
+	Relationship string    `gorm:"not null"`
+	BirthDate    time.Time `gorm:"not null"`
+	Version      uint      `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (v9Dependent) TableName() string { return "dependents" }
+
+func emergencyContactsAndDependentsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&v9EmergencyContact{}, &v9Dependent{})
+}
+
+func emergencyContactsAndDependentsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v9Dependent{}, &v9EmergencyContact{})
+}
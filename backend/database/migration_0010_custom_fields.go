@@ -0,0 +1,88 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// v10JSON is a JSON document column as this migration creates it: JSONB on
+// PostgreSQL, JSON on MySQL and TEXT on SQLite
+type v10JSON string
+
+func (v10JSON) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "JSONB"
+	case "mysql":
+		return "JSON"
+	default:
+		return "TEXT"
+	}
+}
+
+// v10CustomField is the custom_fields table as this migration creates it
+type v10CustomField struct {
+	ID        uint   `gorm:"primarykey"`
+	Entity    string `gorm:"size:50;not null;uniqueIndex:idx_custom_fields_entity_key"`
+	Key       string `gorm:"column:field_key;size:50;not null;uniqueIndex:idx_custom_fields_entity_key"`
+	Label     string `gorm:"not null"`
+	Type      string `gorm:"not null"`
+	Required  bool   `gorm:"not null;default:false"`
+	Options   string `gorm:"type:text"` // JSON array
+	MaxLength *int
+	Pattern   string
+	Min       *float64
+	Max       *float64
+	Version   uint `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v10CustomField) TableName() string { return "custom_fields" }
+
+// v10Employee and v10Department hold the custom values column this
+// migration adds
+type v10Employee struct {
+	CustomFields v10JSON
+}
+
+func (v10Employee) TableName() string { return "employees" }
+
+type v10Department struct {
+	CustomFields v10JSON
+}
+
+func (v10Department) TableName() string { return "departments" }
+
+func customFieldsUp(tx *gorm.DB) error {
+	if err := tx.Migrator().CreateTable(&v10CustomField{}); err != nil {
+		return err
+	}
+	for _, model := range []interface{}{&v10Employee{}, &v10Department{}} {
+		// Databases adopted from AutoMigrate may already have it
+		if tx.Migrator().HasColumn(model, "CustomFields") {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(model, "CustomFields"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func customFieldsDown(tx *gorm.DB) error {
+	// GORM drops SQLite columns by rebuilding the table, which other tables'
+	// foreign keys prevent; DROP COLUMN works everywhere
+	for _, model := range []interface{}{&v10Employee{}, &v10Department{}} {
+		if !tx.Migrator().HasColumn(model, "custom_fields") {
+			continue
+		}
+		table := model.(schema.Tabler).TableName()
+		if err := tx.Exec("ALTER TABLE " + table + " DROP COLUMN custom_fields").Error; err != nil {
+			return err
+		}
+	}
+	return tx.Migrator().DropTable(&v10CustomField{})
+}
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v11CodeSequence is the code_sequences table as this migration creates it
+type v11CodeSequence struct {
+	Scope     string `gorm:"primaryKey;size:191"`
+	Value     uint64 `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (v11CodeSequence) TableName() string { return "code_sequences" }
+
+func codeSequencesUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&v11CodeSequence{})
+}
+
+func codeSequencesDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v11CodeSequence{})
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// v12Skill, v12Certification and v12Notification are the tables as this
+// migration creates them
+type v12Skill struct {
+	ID          uint   `gorm:"primarykey"`
+	Name        string `gorm:"size:191;not null;uniqueIndex"`
+	Category    string
+	Description string
+	Version     uint `gorm:"not null;default:1"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v12Skill) TableName() string { return "skills" }
+
+type v12Certification struct {
+	ID         uint       `gorm:"primarykey"`
+	EmployeeID uint       `gorm:"not null;index"`
+	SkillID    uint       `gorm:"not null;index"`
+	Name       string     `gorm:"not null"`
+	Issuer     string     `gorm:"not null"`
+	IssuedAt   time.Time  `gorm:"not null"`
+	ExpiresAt  *time.Time `gorm:"index"`
+	DocumentID *uint
+	WarnedAt   *time.Time
+	Version    uint `gorm:"not null;default:1"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (v12Certification) TableName() string { return "certifications" }
+
+type v12Notification struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"not null;index"`
+	Kind      string `gorm:"not null"`
+	Message   string `gorm:"not null"`
+	Link      string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (v12Notification) TableName() string { return "notifications" }
+
+func skillsAndCertificationsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&v12Skill{}, &v12Certification{}, &v12Notification{})
+}
+
+func skillsAndCertificationsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&v12Notification{}, &v12Certification{}, &v12Skill{})
+}
@@ -0,0 +1,26 @@
+package database
+
+import "gorm.io/gorm"
+
+// v13IdempotencyKey holds the response headers column this migration adds
+type v13IdempotencyKey struct {
+	Headers string `gorm:"column:response_headers;type:text"`
+}
+
+func (v13IdempotencyKey) TableName() string { return "idempotency_keys" }
+
+func idempotencyResponseHeadersUp(tx *gorm.DB) error {
+	// Databases adopted from AutoMigrate may already have it
+	if tx.Migrator().HasColumn(&v13IdempotencyKey{}, "Headers") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&v13IdempotencyKey{}, "Headers")
+}
+
+func idempotencyResponseHeadersDown(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&v13IdempotencyKey{}, "response_headers") {
+		return nil
+	}
+	// See customFieldsDown: DROP COLUMN avoids GORM's SQLite table rebuild
+	return tx.Exec("ALTER TABLE idempotency_keys DROP COLUMN response_headers").Error
+}
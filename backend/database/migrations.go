@@ -0,0 +1,19 @@
+package database
+
+// migrations lists every schema change in version order. Append new
+// migrations with the next version number; never edit one that has shipped.
+var migrations = []Migration{
+	{Version: 1, Name: "initial_schema", Up: initialSchemaUp, Down: initialSchemaDown},
+	{Version: 2, Name: "list_indexes", Up: listIndexesUp, Down: listIndexesDown},
+	{Version: 3, Name: "department_manager_key", Up: departmentManagerKeyUp, Down: departmentManagerKeyDown},
+	{Version: 4, Name: "feature_flags", Up: featureFlagsUp, Down: featureFlagsDown},
+	{Version: 5, Name: "employment_history", Up: employmentHistoryUp, Down: employmentHistoryDown},
+	{Version: 6, Name: "checklists", Up: checklistsUp, Down: checklistsDown},
+	{Version: 7, Name: "employee_termination", Up: employeeTerminationUp, Down: employeeTerminationDown},
+	{Version: 8, Name: "documents", Up: documentsUp, Down: documentsDown},
+	{Version: 9, Name: "emergency_contacts_and_dependents", Up: emergencyContactsAndDependentsUp, Down: emergencyContactsAndDependentsDown},
+	{Version: 10, Name: "custom_fields", Up: customFieldsUp, Down: customFieldsDown},
+	{Version: 11, Name: "code_sequences", Up: codeSequencesUp, Down: codeSequencesDown},
+	{Version: 12, Name: "skills_and_certifications", Up: skillsAndCertificationsUp, Down: skillsAndCertificationsDown},
+	{Version: 13, Name: "idempotency_response_headers", Up: idempotencyResponseHeadersUp, Down: idempotencyResponseHeadersDown},
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"hrms-backend/config"
+	"hrms-backend/controllers"
 	"hrms-backend/database"
+	"hrms-backend/middleware"
+	"hrms-backend/repositories"
 	"hrms-backend/routes"
-	"hrms-backend/seeds"
+	"hrms-backend/services"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,8 +26,14 @@ func main() {
 		log.Println("No .env file found, using system environment variables")
 	}
 
-	// Initialize configuration
-	cfg := config.Load()
+	// Initialize configuration from the config file, environment and flags
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
 
 	// Initialize database
 	db, err := database.InitDB(cfg)
@@ -29,14 +41,22 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Run database migrations
+	// Run an administrative command instead of the server
+	if len(args) > 0 {
+		if err := runCommand(db, cfg, args); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Apply pending database migrations
 	if err := database.Migrate(db); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
-	// Seed database with initial data
-	if err := seeds.SeedDatabase(db); err != nil {
-		log.Println("Warning: Failed to seed database:", err)
+	// Register request validation rules
+	if err := controllers.RegisterValidators(db); err != nil {
+		log.Fatal("Failed to register validators:", err)
 	}
 
 	// Set Gin mode
@@ -48,22 +68,59 @@ func main() {
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = strings.Split(cfg.AllowedOrigins, ",")
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", middleware.IdempotencyKeyHeader}
+	corsConfig.ExposeHeaders = []string{"ETag", "Idempotent-Replayed"}
 	corsConfig.AllowCredentials = true
 	router.Use(cors.New(corsConfig))
 
 	// Setup routes
-	routes.SetupRoutes(router, db)
+	routes.SetupRoutes(router, db, cfg)
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	// Periodically drop expired idempotency keys
+	go func() {
+		store := middleware.NewGormIdempotencyStore(db)
+		for range time.Tick(time.Hour) {
+			if _, err := store.PurgeExpired(); err != nil {
+				log.Println("Warning: Failed to purge idempotency keys:", err)
+			}
+		}
+	}()
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
+	// Apply scheduled employment changes as they take effect, complete
+	// terminations once the last working day is over and warn of expiring
+	// certifications. Every server runs this; nothing is ever applied or
+	// warned about twice.
+	go func() {
+		repos := repositories.NewGormRepositories(db)
+		uow := repositories.NewGormUnitOfWork(db, database.Retryable(db))
+		employment := services.NewEmploymentService(repos, uow)
+		termination := services.NewTerminationService(repos, uow, cfg.AnnualLeaveDays)
+		certifications := services.NewCertificationService(repos, uow, cfg.ExpiryWarningDays)
+		ticker := time.NewTicker(time.Hour)
+		for {
+			if applied, err := employment.ApplyDue(time.Now()); err != nil {
+				log.Println("Warning: Failed to apply employment changes:", err)
+			} else if applied > 0 {
+				log.Printf("Applied scheduled employment changes to %d employees", applied)
+			}
+			if terminated, err := termination.CompleteDue(time.Now()); err != nil {
+				log.Println("Warning: Failed to complete terminations:", err)
+			} else if terminated > 0 {
+				log.Printf("Terminated %d employees after their last working day", terminated)
+			}
+			if warned, err := certifications.WarnExpiring(time.Now()); err != nil {
+				log.Println("Warning: Failed to warn of expiring certifications:", err)
+			} else if warned > 0 {
+				log.Printf("Warned of %d expiring certifications", warned)
+			}
+			<-ticker.C
+		}
+	}()
+
+	// Start server
+	log.Printf("Server starting on port %s", cfg.Port)
+	if err := router.Run(":" + cfg.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
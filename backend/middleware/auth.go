@@ -2,14 +2,23 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// Accounts reports a user's current role and whether their account is still
+// active
+type Accounts interface {
+	AccountRole(userID uint) (role string, active bool, err error)
+}
+
+// AuthMiddleware accepts requests bearing a token signed with secret whose
+// account is still active, so deactivating an account revokes its tokens.
+// The caller's role is taken from their account rather than the token, so a
+// role change applies to tokens already issued.
+func AuthMiddleware(secret string, accounts Accounts) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,7 +37,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Parse and validate token
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(os.Getenv("JWT_SECRET")), nil
+			return []byte(secret), nil
 		})
 
 		if err != nil || !token.Valid {
@@ -41,13 +50,50 @@ func AuthMiddleware() gin.HandlerFunc {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
 			c.Set("userID", claims["sub"])
 			c.Set("userEmail", claims["email"])
-			c.Set("userRole", claims["role"])
 		} else {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
 
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+		role, active, err := accounts.AccountRole(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check account"})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Account is deactivated"})
+			c.Abort()
+			return
+		}
+		c.Set("userRole", role)
+
 		c.Next()
 	}
 }
+
+// CurrentUserID returns the ID of the authenticated user stored by AuthMiddleware
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+
+	switch v := userID.(type) {
+	case float64:
+		return uint(v), v > 0
+	case uint:
+		return v, v > 0
+	case int:
+		return uint(v), v > 0
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlags reports whether a feature flag is on for a user
+type FeatureFlags interface {
+	FeatureEnabled(userID uint, role, key string) (bool, error)
+}
+
+// RequireFeature hides a route unless the feature flag named key is on for the
+// authenticated user. Flag changes apply without restarting the server.
+func RequireFeature(flags FeatureFlags, key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := CurrentUserID(c)
+		enabled, err := flags.FeatureEnabled(userID, c.GetString("userRole"), key)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to check feature flag",
+			})
+			c.Abort()
+			return
+		}
+		if !enabled {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "This feature is not available",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hrms-backend/models"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make POSTs safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// replayedHeaders are the response headers stored with an idempotent response
+// and sent again, besides Content-Type, when it is replayed
+var replayedHeaders = []string{"ETag", "Location", "Content-Disposition"}
+
+// IdempotencyStore persists idempotency keys together with the stored response
+type IdempotencyStore interface {
+	// Reserve records a new in-flight key. If the key is already held by the
+	// same user and has not expired, the existing record is returned instead.
+	Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	// Complete stores the response for a reserved key
+	Complete(record *models.IdempotencyKey) error
+	// Release forgets a reserved key so the request can be retried
+	Release(record *models.IdempotencyKey) error
+	// PurgeExpired removes keys whose TTL has elapsed
+	PurgeExpired() (int64, error)
+}
+
+// GormIdempotencyStore keeps idempotency keys in the application database
+type GormIdempotencyStore struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyStore(db *gorm.DB) *GormIdempotencyStore {
+	return &GormIdempotencyStore{db: db}
+}
+
+func (s *GormIdempotencyStore) Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	var existing models.IdempotencyKey
+	err := s.db.Where("idempotency_key = ? AND user_id = ?", record.Key, record.UserID).First(&existing).Error
+	switch {
+	case err == nil && existing.ExpiresAt.After(time.Now()):
+		return &existing, nil
+	case err == nil:
+		// The previous use has expired, so the key is free again
+		if err := s.db.Delete(&existing).Error; err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	if err := s.db.Create(record).Error; err != nil {
+		// Lost a race with a concurrent request using the same key
+		if err := s.db.Where("idempotency_key = ? AND user_id = ?", record.Key, record.UserID).First(&existing).Error; err == nil {
+			return &existing, nil
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *GormIdempotencyStore) Complete(record *models.IdempotencyKey) error {
+	return s.db.Model(record).Updates(map[string]interface{}{
+		"status_code":      record.StatusCode,
+		"content_type":     record.ContentType,
+		"response_headers": record.Headers,
+		"response_body":    record.ResponseBody,
+	}).Error
+}
+
+func (s *GormIdempotencyStore) Release(record *models.IdempotencyKey) error {
+	return s.db.Delete(record).Error
+}
+
+func (s *GormIdempotencyStore) PurgeExpired() (int64, error) {
+	result := s.db.Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// responseRecorder tees the response body so it can be stored for replays
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency makes POST requests that carry an Idempotency-Key header safe to
+// retry. The first response for a key and user is stored for ttl and replayed
+// on retries; reusing a key with a different request body is rejected.
+// Bodies are read up to maxBody bytes. Multipart uploads may be too large to
+// read twice, so they are refused a key rather than matched on the path alone.
+// It must run after AuthMiddleware so the key can be scoped to the caller.
+func Idempotency(store IdempotencyStore, ttl time.Duration, maxBody int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Idempotency-Key must be at most 255 characters",
+			})
+			c.Abort()
+			return
+		}
+
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "User not found in context",
+			})
+			c.Abort()
+			return
+		}
+
+		if c.ContentType() == "multipart/form-data" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Idempotency-Key is not supported on multipart uploads",
+			})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		if err != nil {
+			status, message := http.StatusBadRequest, "Failed to read request body"
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status, message = http.StatusRequestEntityTooLarge, "Request body is too large"
+			}
+			c.JSON(status, gin.H{
+				"success": false,
+				"message": message,
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+
+		record := &models.IdempotencyKey{
+			Key:         key,
+			UserID:      userID,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: hex.EncodeToString(hash.Sum(nil)),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		existing, err := store.Reserve(record)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to check idempotency key",
+			})
+			c.Abort()
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{
+					"success": false,
+					"message": "Idempotency-Key has already been used with a different request",
+				})
+			case existing.StatusCode == 0:
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": "A request with this Idempotency-Key is still being processed",
+				})
+			default:
+				var headers map[string]string
+				if existing.Headers != "" {
+					if err := json.Unmarshal([]byte(existing.Headers), &headers); err != nil {
+						log.Printf("Failed to read stored headers for idempotency key %q: %v", key, err)
+					}
+				}
+				for name, value := range headers {
+					c.Header(name, value)
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		// Server errors and panics are not cached so the client can retry with the same key
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(record); err != nil {
+				log.Printf("Failed to release idempotency key %q: %v", key, err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		completed = true
+		record.StatusCode = recorder.Status()
+		record.ContentType = recorder.Header().Get("Content-Type")
+		headers := map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		encoded, _ := json.Marshal(headers) // a string map always encodes
+		record.Headers = string(encoded)
+		record.ResponseBody = recorder.body.Bytes()
+		if err := store.Complete(record); err != nil {
+			log.Printf("Failed to store idempotent response for key %q: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hrms-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryIdempotencyStore is an in-memory IdempotencyStore keyed like the
+// unique index on idempotency_keys
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyKey
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]models.IdempotencyKey{}}
+}
+
+func (s *memoryIdempotencyStore) id(record *models.IdempotencyKey) string {
+	return fmt.Sprintf("%d/%s", record.UserID, record.Key)
+}
+
+func (s *memoryIdempotencyStore) Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[s.id(record)]; ok && existing.ExpiresAt.After(time.Now()) {
+		return &existing, nil
+	}
+	s.records[s.id(record)] = *record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(record *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[s.id(record)] = *record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(record *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, s.id(record))
+	return nil
+}
+
+func (s *memoryIdempotencyStore) PurgeExpired() (int64, error) {
+	return 0, nil
+}
+
+// idempotentRouter serves POST /items for user 7 behind the middleware
+func idempotentRouter(store IdempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint(7))
+		c.Next()
+	})
+	router.Use(Idempotency(store, time.Hour, 1<<10))
+	router.POST("/items", handler)
+	return router
+}
+
+func postItem(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		calls++
+		c.Header("ETag", `"1"`)
+		c.Header("Location", "/items/1")
+		c.JSON(http.StatusCreated, gin.H{"success": true, "data": gin.H{"id": calls}})
+	})
+
+	first := postItem(router, "create-1", `{"name":"a"}`)
+	second := postItem(router, "create-1", `{"name":"a"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replay is missing Idempotent-Replayed")
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response is marked as replayed")
+	}
+	for _, name := range []string{"ETag", "Location", "Content-Type"} {
+		if got, want := second.Header().Get(name), first.Header().Get(name); got != want {
+			t.Errorf("replayed %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"success": true})
+	})
+
+	postItem(router, "create-1", `{"name":"a"}`)
+	w := postItem(router, "create-1", `{"name":"b"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotencyConflictsWhileInFlight(t *testing.T) {
+	var router *gin.Engine
+	var inFlight *httptest.ResponseRecorder
+	router = idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		if inFlight == nil {
+			// Retry while the original request is still being handled
+			inFlight = postItem(router, "create-1", `{"name":"a"}`)
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true})
+	})
+
+	postItem(router, "create-1", `{"name":"a"}`)
+
+	if inFlight.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", inFlight.Code, http.StatusConflict)
+	}
+}
+
+func TestIdempotencyReleasesKeyAfterServerError(t *testing.T) {
+	calls := 0
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true})
+	})
+
+	first := postItem(router, "create-1", `{"name":"a"}`)
+	second := postItem(router, "create-1", `{"name":"a"}`)
+
+	if first.Code != http.StatusInternalServerError {
+		t.Fatalf("first status = %d, want %d", first.Code, http.StatusInternalServerError)
+	}
+	if second.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("retry = %d after %d calls, want %d after 2", second.Code, calls, http.StatusCreated)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("retry after a server error was replayed")
+	}
+}
+
+func TestIdempotencyRejectsMultipart(t *testing.T) {
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		t.Error("handler ran for a multipart request with a key")
+	})
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "contract.pdf")
+	part.Write([]byte("%PDF-1.4"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/items", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set(IdempotencyKeyHeader, "upload-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		t.Error("handler ran for an oversized body")
+	})
+
+	w := postItem(router, "create-1", `{"name":"`+strings.Repeat("a", 2<<10)+`"}`)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -47,6 +47,11 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 	})
 }
 
+// RequireAdmin middleware - only system administrators can access
+func RequireAdmin() gin.HandlerFunc {
+	return RequireRole("admin")
+}
+
 // RequireHR middleware - only HR (admin) can access
 func RequireHR() gin.HandlerFunc {
 	return RequireRole("hr", "admin")
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CustomValues holds a record's custom field values by field key. Text, date
+// and enum values are strings, dates formatted as 2006-01-02, and numbers
+// are float64. They are stored as one JSON document per record.
+type CustomValues map[string]interface{}
+
+func (v CustomValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]interface{}(v))
+	return string(data), err
+}
+
+func (v *CustomValues) Scan(value interface{}) error {
+	values := CustomValues{}
+	var err error
+	switch data := value.(type) {
+	case nil:
+	case []byte:
+		err = json.Unmarshal(data, &values)
+	case string:
+		err = json.Unmarshal([]byte(data), &values)
+	default:
+		err = fmt.Errorf("cannot scan %T into custom values", value)
+	}
+	*v = values
+	return err
+}
+
+// MarshalJSON writes records without custom values as {} rather than null
+func (v CustomValues) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]interface{}(v))
+}
+
+// GormDBDataType stores the values as JSONB on PostgreSQL, so list filters
+// can query them, JSON on MySQL and TEXT on SQLite
+func (CustomValues) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "JSONB"
+	case "mysql":
+		return "JSON"
+	default:
+		return "TEXT"
+	}
+}
@@ -9,7 +9,7 @@ import (
 // User represents system users with authentication
 type User struct {
 	gorm.Model
-	Email      string    `json:"email" gorm:"uniqueIndex;not null"`
+	Email      string    `json:"email" gorm:"size:191;uniqueIndex;not null"`
 	Password   string    `json:"-" gorm:"not null"`
 	FirstName  string    `json:"firstName" gorm:"not null"`
 	LastName   string    `json:"lastName" gorm:"not null"`
@@ -17,33 +17,42 @@ type User struct {
 	IsActive   bool      `json:"isActive" gorm:"default:true"`
 	EmployeeID *uint     `json:"employeeId,omitempty"`
 	Employee   *Employee `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Version    uint      `json:"version" gorm:"not null;default:1"`
 }
 
 // Department represents company departments
 type Department struct {
 	gorm.Model
-	Name        string     `json:"name" gorm:"not null;uniqueIndex"`
-	Description string     `json:"description"`
-	ManagerID   *uint      `json:"managerId,omitempty"`
-	Manager     *Employee  `json:"manager,omitempty" gorm:"foreignKey:ManagerID"`
-	Employees   []Employee `json:"employees,omitempty" gorm:"foreignKey:DepartmentID"`
+	Name        string `json:"name" gorm:"size:191;not null;uniqueIndex"`
+	Description string `json:"description"`
+	ManagerID   *uint  `json:"managerId,omitempty"`
+	// CustomFields holds the values of the custom fields defined for departments
+	CustomFields CustomValues `json:"customFields"`
+	// Manager is loaded by the repository. It is not a GORM relation because
+	// GORM reads foreignKey:ManagerID as employees.manager_id, a has-one.
+	Manager   *Employee  `json:"manager,omitempty" gorm:"-"`
+	Employees []Employee `json:"employees,omitempty" gorm:"foreignKey:DepartmentID"`
+	Version   uint       `json:"version" gorm:"not null;default:1"`
 }
 
-// Employee represents employee records
+// Employee represents employee records. An employee with a termination date
+// becomes terminated, and their account deactivated, once that day is over.
 type Employee struct {
 	gorm.Model
-	EmployeeCode      string          `json:"employeeCode" gorm:"uniqueIndex;not null"`
+	EmployeeCode      string          `json:"employeeCode" gorm:"size:191;uniqueIndex;not null"`
 	FirstName         string          `json:"firstName" gorm:"not null"`
 	LastName          string          `json:"lastName" gorm:"not null"`
-	Email             string          `json:"email" gorm:"uniqueIndex;not null"`
+	Email             string          `json:"email" gorm:"size:191;uniqueIndex;not null"`
 	Phone             string          `json:"phone"`
 	Address           string          `json:"address"`
 	DateOfBirth       *time.Time      `json:"dateOfBirth"`
 	HireDate          time.Time       `json:"hireDate" gorm:"not null"`
 	Salary            float64         `json:"salary" gorm:"not null"`
 	Position          string          `json:"position" gorm:"not null"`
-	Status            string          `json:"status" gorm:"not null;default:'active'"` // active, inactive, terminated
-	DepartmentID      uint            `json:"departmentId" gorm:"not null"`
+	Status            string          `json:"status" gorm:"not null;default:'active';index"` // active, inactive, terminated
+	TerminationDate   *time.Time      `json:"terminationDate,omitempty" gorm:"index"`        // last working day
+	TerminationReason string          `json:"terminationReason,omitempty"`
+	DepartmentID      uint            `json:"departmentId" gorm:"not null;index"`
 	Department        Department      `json:"department" gorm:"foreignKey:DepartmentID"`
 	ManagerID         *uint           `json:"managerId,omitempty"`
 	Manager           *Employee       `json:"manager,omitempty" gorm:"foreignKey:ManagerID"`
@@ -51,44 +60,49 @@ type Employee struct {
 	LeaveRequests     []LeaveRequest  `json:"leaveRequests,omitempty" gorm:"foreignKey:EmployeeID"`
 	AttendanceRecords []Attendance    `json:"attendanceRecords,omitempty" gorm:"foreignKey:EmployeeID"`
 	PayrollRecords    []PayrollRecord `json:"payrollRecords,omitempty" gorm:"foreignKey:EmployeeID"`
+	// CustomFields holds the values of the custom fields defined for employees
+	CustomFields CustomValues `json:"customFields"`
+	Version      uint         `json:"version" gorm:"not null;default:1"`
 }
 
 // LeaveRequest represents employee leave requests
 type LeaveRequest struct {
 	gorm.Model
-	EmployeeID uint       `json:"employeeId" gorm:"not null"`
+	EmployeeID uint       `json:"employeeId" gorm:"not null;index"`
 	Employee   Employee   `json:"employee" gorm:"foreignKey:EmployeeID"`
 	LeaveType  string     `json:"leaveType" gorm:"not null"` // annual, sick, emergency, etc.
 	StartDate  time.Time  `json:"startDate" gorm:"not null"`
 	EndDate    time.Time  `json:"endDate" gorm:"not null"`
 	Days       int        `json:"days" gorm:"not null"`
 	Reason     string     `json:"reason"`
-	Status     string     `json:"status" gorm:"not null;default:'pending'"` // pending, approved, rejected
+	Status     string     `json:"status" gorm:"not null;default:'pending';index"` // pending, approved, rejected, cancelled
 	ApprovedBy *uint      `json:"approvedBy,omitempty"`
 	Approver   *Employee  `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
 	ApprovedAt *time.Time `json:"approvedAt,omitempty"`
 	Comments   string     `json:"comments"`
+	Version    uint       `json:"version" gorm:"not null;default:1"`
 }
 
 // Attendance represents daily attendance records
 type Attendance struct {
 	gorm.Model
-	EmployeeID   uint       `json:"employeeId" gorm:"not null"`
+	EmployeeID   uint       `json:"employeeId" gorm:"not null;index"`
 	Employee     Employee   `json:"employee" gorm:"foreignKey:EmployeeID"`
-	Date         time.Time  `json:"date" gorm:"not null"`
+	Date         time.Time  `json:"date" gorm:"not null;index"`
 	CheckIn      *time.Time `json:"checkIn"`
 	CheckOut     *time.Time `json:"checkOut"`
 	Status       string     `json:"status" gorm:"not null;default:'present'"` // present, absent, late, half-day
 	WorkingHours float64    `json:"workingHours" gorm:"default:0"`
 	Comments     string     `json:"comments"`
+	Version      uint       `json:"version" gorm:"not null;default:1"`
 }
 
 // PayrollRecord represents monthly payroll records
 type PayrollRecord struct {
 	gorm.Model
-	EmployeeID     uint       `json:"employeeId" gorm:"not null"`
+	EmployeeID     uint       `json:"employeeId" gorm:"not null;index"`
 	Employee       Employee   `json:"employee" gorm:"foreignKey:EmployeeID"`
-	PayPeriodStart time.Time  `json:"payPeriodStart" gorm:"not null"`
+	PayPeriodStart time.Time  `json:"payPeriodStart" gorm:"not null;index"`
 	PayPeriodEnd   time.Time  `json:"payPeriodEnd" gorm:"not null"`
 	BasicSalary    float64    `json:"basicSalary" gorm:"not null"`
 	Allowances     float64    `json:"allowances" gorm:"default:0"`
@@ -100,4 +114,237 @@ type PayrollRecord struct {
 	Status         string     `json:"status" gorm:"not null;default:'draft'"` // draft, processed, paid
 	ProcessedAt    *time.Time `json:"processedAt,omitempty"`
 	PaidAt         *time.Time `json:"paidAt,omitempty"`
+	Version        uint       `json:"version" gorm:"not null;default:1"`
+}
+
+// IdempotencyKey stores the outcome of a create request so that a retry with
+// the same Idempotency-Key header replays the original response
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	Key          string    `json:"key" gorm:"column:idempotency_key;size:255;not null;uniqueIndex:idx_idempotency_keys_key_user"`
+	UserID       uint      `json:"userId" gorm:"not null;uniqueIndex:idx_idempotency_keys_key_user"`
+	Method       string    `json:"method" gorm:"not null"`
+	Path         string    `json:"path" gorm:"not null"`
+	RequestHash  string    `json:"requestHash" gorm:"not null"`
+	StatusCode   int       `json:"statusCode"` // 0 while the original request is in flight
+	ContentType  string    `json:"contentType"`
+	Headers      string    `json:"-" gorm:"column:response_headers;type:text"` // replayed headers as a JSON object
+	ResponseBody []byte    `json:"-"`
+	ExpiresAt    time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// FeatureFlag switches a feature on at runtime. A disabled flag is off for
+// everyone. An enabled flag without targets is on for everyone; with targets
+// it is on for users matching any of its roles, departments or user IDs.
+type FeatureFlag struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	Key           string    `json:"key" gorm:"column:flag_key;size:191;uniqueIndex;not null"`
+	Description   string    `json:"description"`
+	Enabled       bool      `json:"enabled" gorm:"not null;default:false"`
+	Roles         []string  `json:"roles" gorm:"serializer:json"`
+	DepartmentIDs []uint    `json:"departmentIds" gorm:"serializer:json"`
+	UserIDs       []uint    `json:"userIds" gorm:"serializer:json"`
+	Version       uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// EmploymentChange records a change to an employee's job, pay or reporting
+// line that takes effect on EffectiveDate. A hire record holds the starting
+// job; later changes only set the fields they change. An employee's job on a
+// date is every change effective by then applied in order, so a back-dated
+// correction does not rewrite the changes after it.
+type EmploymentChange struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	EmployeeID    uint      `json:"employeeId" gorm:"not null;index:idx_employment_changes_employee_date"`
+	EffectiveDate time.Time `json:"effectiveDate" gorm:"not null;index:idx_employment_changes_employee_date"`
+	Kind          string    `json:"kind" gorm:"not null"`                     // hire, change
+	Status        string    `json:"status" gorm:"not null;default:'applied'"` // scheduled, applied, cancelled
+	Reason        string    `json:"reason" gorm:"not null"`
+	Position      *string   `json:"position,omitempty"`
+	Salary        *float64  `json:"salary,omitempty"`
+	DepartmentID  *uint     `json:"departmentId,omitempty"`
+	ManagerID     *uint     `json:"managerId,omitempty"`
+	// ClearManager removes the employee's manager; ManagerID is then unset
+	ClearManager bool       `json:"clearManager" gorm:"not null;default:false"`
+	RecordedBy   *uint      `json:"recordedBy,omitempty"` // user ID
+	AppliedAt    *time.Time `json:"appliedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// ChecklistTemplate lists the tasks to create when an employee joins
+// (onboarding) or leaves (offboarding). A template without a department or
+// position applies to everyone; otherwise only to employees matching both.
+type ChecklistTemplate struct {
+	ID           uint                    `json:"id" gorm:"primarykey"`
+	Name         string                  `json:"name" gorm:"not null"`
+	Kind         string                  `json:"kind" gorm:"not null;index"` // onboarding, offboarding
+	DepartmentID *uint                   `json:"departmentId,omitempty"`
+	Position     string                  `json:"position"`
+	Active       bool                    `json:"active" gorm:"not null;default:true"`
+	Tasks        []ChecklistTemplateTask `json:"tasks" gorm:"foreignKey:TemplateID"`
+	Version      uint                    `json:"version" gorm:"not null;default:1"`
+	CreatedAt    time.Time               `json:"createdAt"`
+	UpdatedAt    time.Time               `json:"updatedAt"`
+}
+
+// ChecklistTemplateTask is one task of a template. It is due DueOffsetDays
+// after the hire date for onboarding, or after the termination date for
+// offboarding; negative offsets fall before it.
+type ChecklistTemplateTask struct {
+	ID            uint   `json:"id" gorm:"primarykey"`
+	TemplateID    uint   `json:"templateId" gorm:"not null;index"`
+	SortOrder     int    `json:"sortOrder" gorm:"not null;default:0"`
+	Title         string `json:"title" gorm:"not null"`
+	Description   string `json:"description"`
+	Assignee      string `json:"assignee" gorm:"not null"` // hr, manager, employee
+	DueOffsetDays int    `json:"dueOffsetDays" gorm:"not null;default:0"`
+}
+
+// ChecklistTask is a task created for one employee from a template. Tasks
+// assigned to the employee or their manager name that person's account;
+// tasks for HR, or for people without an account, are open to every HR user.
+type ChecklistTask struct {
+	ID             uint       `json:"id" gorm:"primarykey"`
+	EmployeeID     uint       `json:"employeeId" gorm:"not null;index"`
+	TemplateID     uint       `json:"templateId" gorm:"not null;index"`
+	Kind           string     `json:"kind" gorm:"not null"` // onboarding, offboarding
+	Title          string     `json:"title" gorm:"not null"`
+	Description    string     `json:"description"`
+	Assignee       string     `json:"assignee" gorm:"not null"` // hr, manager, employee
+	AssigneeUserID *uint      `json:"assigneeUserId,omitempty" gorm:"index"`
+	DueDate        time.Time  `json:"dueDate" gorm:"not null;index"`
+	Status         string     `json:"status" gorm:"not null;default:'pending';index"` // pending, done, skipped
+	Notes          string     `json:"notes"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+	CompletedBy    *uint      `json:"completedBy,omitempty"` // user ID
+	Version        uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// Document is a file kept against an employee, such as a contract or a scan
+// of an ID. The contents live in the document store under StorageKey.
+// Visibility is the widest audience allowed to see it: hr means HR only,
+// manager adds managers of the employee's department and employee adds the
+// employee themself.
+type Document struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	EmployeeID  uint       `json:"employeeId" gorm:"not null;index"`
+	Category    string     `json:"category" gorm:"not null"` // contract, id, certificate, other
+	Name        string     `json:"name" gorm:"not null"`
+	ContentType string     `json:"contentType" gorm:"not null"` // sniffed from the contents
+	Size        int64      `json:"size" gorm:"not null"`
+	Checksum    string     `json:"checksum" gorm:"not null"` // hex SHA-256 of the contents
+	StorageKey  string     `json:"-" gorm:"not null;uniqueIndex"`
+	Visibility  string     `json:"visibility" gorm:"not null;default:'hr'"` // hr, manager, employee
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty" gorm:"index"`
+	UploadedBy  uint       `json:"uploadedBy" gorm:"not null"` // user ID
+	Version     uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// EmergencyContact is someone to call if something happens to an employee.
+// An employee's contacts are called in Priority order, lowest first.
+type EmergencyContact struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	EmployeeID   uint      `json:"employeeId" gorm:"not null;index"`
+	Name         string    `json:"name" gorm:"not null"`
+	Relationship string    `json:"relationship" gorm:"not null"`
+	Phone        string    `json:"phone" gorm:"not null"`
+	Email        string    `json:"email"`
+	Priority     int       `json:"priority" gorm:"not null;default:1"`
+	Version      uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Dependent is a family member of an employee, for benefits and tax
+type Dependent struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	EmployeeID   uint      `json:"employeeId" gorm:"not null;index"`
+	FirstName    string    `json:"firstName" gorm:"not null"`
+	LastName     string    `json:"lastName" gorm:"not null"`
+	Relationship string    `json:"relationship" gorm:"not null"` // spouse, partner, child, other
+	BirthDate    time.Time `json:"birthDate" gorm:"not null"`
+	Version      uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// CustomField is an attribute defined by an administrator for employees or
+// departments, such as a T-shirt size or badge number. Values are kept by Key
+// in the records' CustomFields. Text fields may limit their length and
+// require a Pattern, number fields a Min and Max, and enum fields take one
+// of Options.
+type CustomField struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Entity    string    `json:"entity" gorm:"size:50;not null;uniqueIndex:idx_custom_fields_entity_key"` // employee, department
+	Key       string    `json:"key" gorm:"column:field_key;size:50;not null;uniqueIndex:idx_custom_fields_entity_key"`
+	Label     string    `json:"label" gorm:"not null"`
+	Type      string    `json:"type" gorm:"not null"` // text, number, date, enum
+	Required  bool      `json:"required" gorm:"not null;default:false"`
+	Options   []string  `json:"options,omitempty" gorm:"serializer:json"`
+	MaxLength *int      `json:"maxLength,omitempty"`
+	Pattern   string    `json:"pattern,omitempty"`
+	Min       *float64  `json:"min,omitempty"`
+	Max       *float64  `json:"max,omitempty"`
+	Version   uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CodeSequence is the last number handed out for one scope of a code
+// pattern, such as ENG-2024-{SEQ:4}
+type CodeSequence struct {
+	Scope     string    `json:"scope" gorm:"primaryKey;size:191"`
+	Value     uint64    `json:"value" gorm:"not null"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Skill is an entry in the skills catalog, such as forklift operation or
+// first aid, that employees hold certifications for
+type Skill struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	Name        string    `json:"name" gorm:"size:191;not null;uniqueIndex"`
+	Category    string    `json:"category"` // free text, e.g. safety or technical
+	Description string    `json:"description"`
+	Version     uint      `json:"version" gorm:"not null;default:1"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Certification shows that an employee holds a skill, as issued by Issuer.
+// Its evidence is one of the employee's documents. Certifications without an
+// expiry date never expire; WarnedAt records when the employee and their
+// manager were warned of an expiry, so they are warned once per expiry date.
+type Certification struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	EmployeeID uint       `json:"employeeId" gorm:"not null;index"`
+	SkillID    uint       `json:"skillId" gorm:"not null;index"`
+	Skill      *Skill     `json:"skill,omitempty" gorm:"foreignKey:SkillID"`
+	Name       string     `json:"name" gorm:"not null"`
+	Issuer     string     `json:"issuer" gorm:"not null"`
+	IssuedAt   time.Time  `json:"issuedAt" gorm:"not null"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" gorm:"index"`
+	DocumentID *uint      `json:"documentId,omitempty"`
+	WarnedAt   *time.Time `json:"warnedAt,omitempty"`
+	Version    uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// Notification is a message for one user, such as a warning that a
+// certification is about to expire
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	Kind      string     `json:"kind" gorm:"not null"` // certification_expiring
+	Message   string     `json:"message" gorm:"not null"`
+	Link      string     `json:"link,omitempty"` // API path of the record it is about
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
 }
@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route documents one HTTP route registered on the router
+type Route struct {
+	Method      string
+	Path        string // gin path syntax, e.g. /api/v1/users/:id
+	Tag         string
+	Summary     string
+	Description string
+	// Public routes do not require a bearer token
+	Public bool
+	// Roles lists the roles allowed by the route's RBAC middleware, if any
+	Roles []string
+	Query []Parameter
+	// Request is a value of the request body type, or nil for no body
+	Request interface{}
+	// Patch marks request bodies that are applied as a JSON Merge Patch
+	Patch bool
+	// RequestContentType overrides the request media type, such as
+	// multipart/form-data for uploads
+	RequestContentType string
+	// Response is a value of the success response type, or nil for no body
+	Response interface{}
+	// Envelope wraps the response in the {success, data, message} envelope
+	Envelope bool
+	// Status is the success status code, defaulting to 200
+	Status int
+	// ETag marks responses that carry the record version in an ETag header
+	ETag bool
+	// ContentType overrides the success response media type
+	ContentType string
+}
+
+// Build assembles an OpenAPI 3.1 document describing the given routes
+func Build(info Info, routes []Route) *Document {
+	registry := newSchemaRegistry()
+	registry.schemas["Error"] = &Schema{
+		Type:        "object",
+		Description: "Error body. Handlers report either an error string or a success flag with a message.",
+		Properties: map[string]*Schema{
+			"error":   {Type: "string"},
+			"success": {Type: "boolean"},
+			"message": {Type: "string"},
+		},
+	}
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Servers: []Server{{URL: "/"}},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: registry.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearerAuth": {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "Token returned by POST /api/v1/auth/login",
+				},
+			},
+		},
+	}
+
+	tags := map[string]bool{}
+	for _, route := range routes {
+		path, params := PathFromGin(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = buildOperation(registry, route, params)
+		if route.Tag != "" {
+			tags[route.Tag] = true
+		}
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	return doc
+}
+
+// PathFromGin converts gin path parameters (:id) into OpenAPI templates ({id})
+func PathFromGin(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func buildOperation(registry *schemaRegistry, route Route, pathParams []string) *Operation {
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: operationID(route.Method, route.Path),
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+
+	for _, name := range pathParams {
+		minimum := 1.0
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "integer", Minimum: &minimum},
+		})
+	}
+	op.Parameters = append(op.Parameters, route.Query...)
+
+	method := strings.ToUpper(route.Method)
+	if method == http.MethodPut || method == http.MethodPatch {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        "If-Match",
+			In:          "header",
+			Description: "ETag of the version being edited; the update fails with 412 if the record changed since",
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	if method == http.MethodPost && !route.Public && route.RequestContentType != "multipart/form-data" {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:        "Idempotency-Key",
+			In:          "header",
+			Description: "Client-generated key that makes the request safe to retry; the first response, with its ETag and Location, is replayed",
+			Schema:      &Schema{Type: "string", MaxLength: intPtr(255)},
+		})
+	}
+
+	if route.Request != nil {
+		op.RequestBody = requestBody(registry, route)
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses[strconv.Itoa(status)] = successResponse(registry, route, status)
+
+	errorResponse := func(code int, description string) {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: description,
+			Content:     map[string]MediaType{"application/json": {Schema: componentRef("Error")}},
+		}
+	}
+	if route.Request != nil || len(pathParams) > 0 || len(route.Query) > 0 {
+		errorResponse(http.StatusBadRequest, "Invalid request")
+	}
+	if !route.Public {
+		errorResponse(http.StatusUnauthorized, "Missing or invalid bearer token")
+		op.Security = &[]SecurityRequirement{{"bearerAuth": {}}}
+	} else {
+		op.Security = &[]SecurityRequirement{}
+	}
+	if len(route.Roles) > 0 {
+		op.RequiredRoles = route.Roles
+		errorResponse(http.StatusForbidden, "Caller's role is not allowed: requires one of "+strings.Join(route.Roles, ", "))
+	}
+	if len(pathParams) > 0 {
+		errorResponse(http.StatusNotFound, "Record not found")
+	}
+	if method == http.MethodPut || method == http.MethodPatch {
+		errorResponse(http.StatusPreconditionFailed, "The record was modified by another request")
+		errorResponse(http.StatusRequestEntityTooLarge, "The patch is larger than 1 MiB")
+	}
+	if method == http.MethodPost && !route.Public && route.RequestContentType != "multipart/form-data" {
+		errorResponse(http.StatusConflict, "A request with the same Idempotency-Key is still in flight")
+		errorResponse(http.StatusUnprocessableEntity, "The Idempotency-Key was already used with a different body")
+		errorResponse(http.StatusRequestEntityTooLarge, "The body is too large to check against the Idempotency-Key")
+	}
+	errorResponse(http.StatusInternalServerError, "Unexpected server error")
+
+	return op
+}
+
+func requestBody(registry *schemaRegistry, route Route) *RequestBody {
+	t := reflect.TypeOf(route.Request)
+	if route.Patch {
+		schema := registry.patchSchemaFor(t)
+		return &RequestBody{
+			Description: "JSON Merge Patch (RFC 7396) applied to the current record. Null clears a field.",
+			Required:    true,
+			Content: map[string]MediaType{
+				"application/merge-patch+json": {Schema: schema},
+				"application/json":             {Schema: schema},
+			},
+		}
+	}
+
+	contentType := route.RequestContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{contentType: {Schema: registry.schemaFor(t)}},
+	}
+}
+
+func successResponse(registry *schemaRegistry, route Route, status int) *Response {
+	response := &Response{Description: http.StatusText(status)}
+	if route.ETag {
+		response.Headers = map[string]Header{
+			"ETag": {Description: "Current record version, for use in If-Match", Schema: &Schema{Type: "string"}},
+		}
+	}
+	if route.Response == nil && !route.Envelope {
+		return response
+	}
+
+	var schema *Schema
+	if route.Response != nil {
+		schema = registry.schemaFor(reflect.TypeOf(route.Response))
+	}
+	if route.Envelope {
+		envelope := &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"success": {Type: "boolean"},
+				"message": {Type: "string"},
+			},
+			Required: []string{"success"},
+		}
+		if route.Response != nil {
+			envelope.Properties["data"] = schema
+		}
+		schema = envelope
+	}
+
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	response.Content = map[string]MediaType{contentType: {Schema: schema}}
+	return response
+}
+
+// operationID derives a stable identifier such as getApiV1UsersById
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segment = "by-" + segment[1:]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,16 @@
+#!/bin/sh
+# Vendors the Swagger UI assets served at /docs from the swagger-ui-dist
+# package. Usage: fetch-swagger-ui.sh VERSION
+set -eu
+
+version=$1
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+# npm pack verifies the tarball against the registry's integrity hash
+(cd "$work" && npm pack --silent "swagger-ui-dist@$version" >/dev/null)
+tar -xzf "$work/swagger-ui-dist-$version.tgz" -C "$work"
+for file in swagger-ui.css swagger-ui-bundle.js LICENSE; do
+	cp "$work/package/$file" swagger-ui/
+done
+echo "Vendored swagger-ui-dist $version"
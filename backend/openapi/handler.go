@@ -0,0 +1,74 @@
+package openapi
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIVersion is the swagger-ui-dist release vendored into swagger-ui/
+//
+//go:generate sh fetch-swagger-ui.sh 5.17.14
+const swaggerUIVersion = "5.17.14"
+
+//go:embed docs.html
+var docsPage []byte
+
+// unbundledPage stands in for docsPage in builds whose Swagger UI assets have
+// not been vendored yet
+const unbundledPage = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8" /><title>HRMS API Documentation</title></head>
+<body>
+  <p>Swagger UI ` + swaggerUIVersion + ` is not bundled into this build; run
+  <code>go generate ./openapi</code> to vendor it.</p>
+  <p>The OpenAPI document is at <a href="/openapi.json">/openapi.json</a>.</p>
+</body>
+</html>
+`
+
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+// SpecHandler serves the OpenAPI document as JSON
+func SpecHandler(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// DocsHandler serves an interactive documentation page for /openapi.json
+func DocsHandler() gin.HandlerFunc {
+	return docsHandler(swaggerUIAssets())
+}
+
+// AssetsHandler serves the embedded Swagger UI files the docs page loads. It
+// must be mounted at /docs/assets/*filepath.
+func AssetsHandler() gin.HandlerFunc {
+	return assetsHandler(swaggerUIAssets())
+}
+
+func swaggerUIAssets() fs.FS {
+	assets, _ := fs.Sub(swaggerUI, "swagger-ui") // the directory is embedded, so this cannot fail
+	return assets
+}
+
+func docsHandler(assets fs.FS) gin.HandlerFunc {
+	page := docsPage
+	if _, err := fs.Stat(assets, "swagger-ui-bundle.js"); err != nil {
+		page = []byte(unbundledPage)
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
+
+func assetsHandler(assets fs.FS) gin.HandlerFunc {
+	files := http.StripPrefix("/docs/assets", http.FileServer(http.FS(assets)))
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=86400")
+		files.ServeHTTP(c.Writer, c.Request)
+	}
+}
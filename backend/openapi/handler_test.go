@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// docsRouter mounts the docs page and its assets the way routes.SetupRoutes does
+func docsRouter(assets fs.FS) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/docs", docsHandler(assets))
+	router.GET("/docs/assets/*filepath", assetsHandler(assets))
+	return router
+}
+
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestDocsServesSwaggerUI(t *testing.T) {
+	router := docsRouter(fstest.MapFS{
+		"swagger-ui-bundle.js": {Data: []byte("window.SwaggerUIBundle = function () {};")},
+		"swagger-ui.css":       {Data: []byte(".swagger-ui {}")},
+	})
+
+	page := get(router, "/docs")
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), `<script src="/docs/assets/swagger-ui-bundle.js">`) {
+		t.Fatalf("/docs = %d %s, want the Swagger UI page", page.Code, page.Body)
+	}
+
+	for _, asset := range []struct{ path, contentType string }{
+		{"/docs/assets/swagger-ui-bundle.js", "javascript"},
+		{"/docs/assets/swagger-ui.css", "text/css"},
+	} {
+		w := get(router, asset.path)
+		if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), asset.contentType) {
+			t.Errorf("%s = %d %q, want 200 %s", asset.path, w.Code, w.Header().Get("Content-Type"), asset.contentType)
+		}
+		if w.Header().Get("Cache-Control") == "" {
+			t.Errorf("%s is served without Cache-Control", asset.path)
+		}
+	}
+}
+
+func TestDocsWithoutBundleLinksToSpec(t *testing.T) {
+	router := docsRouter(fstest.MapFS{})
+
+	page := get(router, "/docs")
+	if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), `href="/openapi.json"`) {
+		t.Fatalf("/docs = %d %s, want a link to the OpenAPI document", page.Code, page.Body)
+	}
+	if strings.Contains(page.Body.String(), "swagger-ui-bundle.js") {
+		t.Error("/docs loads a bundle that is not embedded")
+	}
+	if w := get(router, "/docs/assets/swagger-ui-bundle.js"); w.Code != http.StatusNotFound {
+		t.Errorf("missing bundle = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestEmbeddedSwaggerUI(t *testing.T) {
+	if _, err := fs.Stat(swaggerUIAssets(), "swagger-ui-bundle.js"); err != nil {
+		t.Skip("Swagger UI " + swaggerUIVersion + " is not vendored; run go generate ./openapi")
+	}
+	router := docsRouter(swaggerUIAssets())
+
+	if w := get(router, "/docs"); !strings.Contains(w.Body.String(), "SwaggerUIBundle") {
+		t.Errorf("/docs = %s, want the Swagger UI page", w.Body)
+	}
+	for _, path := range []string{"/docs/assets/swagger-ui-bundle.js", "/docs/assets/swagger-ui.css"} {
+		if w := get(router, path); w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("%s = %d with %d bytes, want the vendored file", path, w.Code, w.Body.Len())
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package openapi
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	deletedAtType  = reflect.TypeOf(gorm.DeletedAt{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	// fileType is an uploaded file in a multipart/form-data body
+	fileType = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// schemaRegistry turns Go types into JSON schemas, storing named structs as
+// reusable components and referring to them by $ref
+type schemaRegistry struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: map[string]*Schema{}}
+}
+
+func componentRef(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// schemaFor returns the schema of t, registering named structs as components
+func (r *schemaRegistry) schemaFor(t reflect.Type) *Schema {
+	if t == fileType {
+		return &Schema{Type: "string", Format: "binary"}
+	}
+	if t.Kind() == reflect.Ptr {
+		return nullable(r.schemaFor(t.Elem()))
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case deletedAtType:
+		return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+		return &Schema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t, false)
+		}
+		name := t.Name()
+		if _, ok := r.schemas[name]; !ok {
+			// Reserve the name first so self-referencing types terminate
+			r.schemas[name] = &Schema{}
+			*r.schemas[name] = *r.structSchema(t, false)
+		}
+		return componentRef(name)
+	}
+
+	return &Schema{}
+}
+
+// patchSchemaFor registers a variant of a request struct for JSON Merge Patch
+// bodies, where every member is optional and may be null
+func (r *schemaRegistry) patchSchemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	name := t.Name() + "Patch"
+	if _, ok := r.schemas[name]; !ok {
+		r.schemas[name] = r.structSchema(t, true)
+	}
+	return componentRef(name)
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type, patch bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t, patch)
+	return schema
+}
+
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type, patch bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs without a JSON name are flattened, like encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded, patch)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := r.schemaFor(field.Type)
+		required := applyBindingRules(property, field.Type, field.Tag.Get("binding"))
+		if patch {
+			property = nullable(property)
+		} else if required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyBindingRules translates validator tags into schema constraints and
+// reports whether the field is required
+func applyBindingRules(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	// Constraints on a nullable or referenced schema are placed on a copy
+	target := schema
+	if len(schema.AnyOf) > 0 {
+		target = schema.AnyOf[0]
+	}
+	if target.Ref != "" {
+		return strings.Contains(tag, "required")
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	isString := t.Kind() == reflect.String
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			target.Format = "email"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, value)
+			}
+		case "exists":
+			target.References = param
+		case "min", "max", "gte", "gt", "lte", "lt":
+			number, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			applyBound(target, name, number, isString)
+		}
+	}
+
+	return required
+}
+
+func applyBound(schema *Schema, rule string, number float64, isString bool) {
+	if isString {
+		length := int(number)
+		switch rule {
+		case "min", "gte":
+			schema.MinLength = &length
+		case "max", "lte":
+			schema.MaxLength = &length
+		}
+		return
+	}
+
+	switch rule {
+	case "min", "gte":
+		schema.Minimum = &number
+	case "gt":
+		schema.ExclusiveMinimum = &number
+	case "max", "lte":
+		schema.Maximum = &number
+	case "lt":
+		schema.ExclusiveMaximum = &number
+	}
+}
+
+// nullable widens a schema so that null is also accepted
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" || schema.Type == nil {
+		return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+	}
+	if name, ok := schema.Type.(string); ok {
+		copied := *schema
+		copied.Type = []string{name, "null"}
+		return &copied
+	}
+	return schema
+}
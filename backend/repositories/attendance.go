@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AttendanceReportRow is one attendance record in a department report
+type AttendanceReportRow struct {
+	FirstName    string
+	LastName     string
+	Date         time.Time
+	CheckIn      *time.Time
+	CheckOut     *time.Time
+	WorkingHours float64
+	Status       string
+}
+
+// AttendanceSummary is an attendance record as listed, with its employee's name
+type AttendanceSummary struct {
+	ID                uint
+	EmployeeID        uint
+	EmployeeFirstName *string
+	EmployeeLastName  *string
+	Date              time.Time
+	CheckIn           *time.Time
+	CheckOut          *time.Time
+	WorkingHours      float64
+	Status            string
+	Version           uint
+}
+
+type AttendanceRepository interface {
+	// FindByID loads an attendance record with its employee
+	FindByID(id uint) (*models.Attendance, error)
+	List(scope Scope) ([]AttendanceSummary, error)
+	Create(attendance *models.Attendance) error
+	// Update writes columns if the record is still at attendance.Version and reloads it
+	Update(attendance *models.Attendance, columns map[string]interface{}) error
+	Delete(id uint) error
+	// DepartmentReport lists a department's attendance, most recent first. It
+	// may read from a replica and miss the latest check-ins.
+	DepartmentReport(departmentID uint) ([]AttendanceReportRow, error)
+}
+
+type GormAttendanceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAttendanceRepository(db *gorm.DB) *GormAttendanceRepository {
+	return &GormAttendanceRepository{db: db}
+}
+
+func (r *GormAttendanceRepository) FindByID(id uint) (*models.Attendance, error) {
+	var attendance models.Attendance
+	if err := first(r.db.Preload("Employee"), &attendance, id); err != nil {
+		return nil, err
+	}
+	return &attendance, nil
+}
+
+func (r *GormAttendanceRepository) List(scope Scope) ([]AttendanceSummary, error) {
+	var attendance []AttendanceSummary
+	query := r.db.Model(&models.Attendance{}).
+		Select("attendances.id, attendances.employee_id, " +
+			"employees.first_name AS employee_first_name, employees.last_name AS employee_last_name, " +
+			"attendances.date, attendances.check_in, attendances.check_out, attendances.working_hours, " +
+			"attendances.status, attendances.version").
+		Joins("LEFT JOIN employees ON employees.id = attendances.employee_id AND employees.deleted_at IS NULL").
+		Order("attendances.id")
+	err := applyJoinedScope(query, "attendances", scope).Scan(&attendance).Error
+	return attendance, err
+}
+
+func (r *GormAttendanceRepository) Create(attendance *models.Attendance) error {
+	if err := r.db.Create(attendance).Error; err != nil {
+		return err
+	}
+	return r.reload(attendance)
+}
+
+func (r *GormAttendanceRepository) Update(attendance *models.Attendance, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, attendance, attendance.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(attendance)
+}
+
+// reload refreshes attendance and its employee after a write
+func (r *GormAttendanceRepository) reload(attendance *models.Attendance) error {
+	var fresh models.Attendance
+	if err := first(r.db.Preload("Employee"), &fresh, attendance.ID); err != nil {
+		return err
+	}
+	*attendance = fresh
+	return nil
+}
+
+func (r *GormAttendanceRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Attendance{}, id).Error
+}
+
+func (r *GormAttendanceRepository) DepartmentReport(departmentID uint) ([]AttendanceReportRow, error) {
+	var rows []AttendanceReportRow
+	err := reporting(r.db).Model(&models.Attendance{}).
+		Select("employees.first_name, employees.last_name, attendances.date, attendances.check_in, attendances.check_out, attendances.working_hours, attendances.status").
+		Joins("JOIN employees ON attendances.employee_id = employees.id").
+		Where("employees.department_id = ?", departmentID).
+		Order("attendances.date DESC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,156 @@
+//go:build cgo
+
+package repositories_test
+
+import (
+	"hrms-backend/config"
+	"hrms-backend/database"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/seeds"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The list benchmarks compare the projection and aggregate queries with the
+// preloads they replaced, against the "load" seed profile:
+//
+//	go test -run '^$' -bench . -benchmem ./repositories
+var (
+	benchOnce sync.Once
+	benchDir  string
+	benchDB   *gorm.DB
+	benchErr  error
+)
+
+// TestMain removes the seeded database, which the benchmarks share
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+func seededDB(b *testing.B) *gorm.DB {
+	b.Helper()
+	benchOnce.Do(func() {
+		if benchDir, benchErr = os.MkdirTemp("", "hrms-bench-*"); benchErr != nil {
+			return
+		}
+		cfg := &config.Config{DBDriver: "sqlite", DBPath: filepath.Join(benchDir, "hrms.db"), DBMaxOpenConns: 4, DBMaxIdleConns: 4}
+		if benchDB, benchErr = database.InitDB(cfg); benchErr != nil {
+			return
+		}
+		benchDB.Logger = logger.Discard
+		if benchErr = database.Migrate(benchDB); benchErr != nil {
+			return
+		}
+		opts := seeds.Options{Employees: 2000, Months: 6, Seed: 1, Until: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)}
+		benchErr = seeds.Run(benchDB, "load", opts)
+	})
+	if benchErr != nil {
+		b.Fatal(benchErr)
+	}
+	return benchDB
+}
+
+func BenchmarkDepartmentList(b *testing.B) {
+	db := seededDB(b)
+	repo := repositories.NewGormDepartmentRepository(db)
+
+	b.Run("preload", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var departments []models.Department
+			if err := db.Preload("Employees").Find(&departments).Error; err != nil {
+				b.Fatal(err)
+			}
+			for _, department := range departments {
+				_ = len(department.Employees)
+			}
+		}
+	})
+	b.Run("aggregate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.List(nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEmployeeList(b *testing.B) {
+	db := seededDB(b)
+	repo := repositories.NewGormEmployeeRepository(db)
+
+	b.Run("preload", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var employees []models.Employee
+			if err := db.Preload("Department").Preload("Manager").Preload("User").Find(&employees).Error; err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("projection", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.List(nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkLeaveList(b *testing.B) {
+	db := seededDB(b)
+	repo := repositories.NewGormLeaveRepository(db)
+
+	b.Run("preload", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var leaves []models.LeaveRequest
+			err := db.Preload("Employee").Preload("Employee.Department").Preload("Approver").Find(&leaves).Error
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("projection", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.List(repositories.Scope{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkAttendanceList(b *testing.B) {
+	db := seededDB(b)
+	repo := repositories.NewGormAttendanceRepository(db)
+	// A manager's view, which filters on the department
+	scope := repositories.Scope{DepartmentID: 1}
+
+	b.Run("preload", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var attendance []models.Attendance
+			err := db.Preload("Employee").Preload("Employee.Department").
+				Joins("JOIN employees ON attendances.employee_id = employees.id").
+				Where("employees.department_id = ?", scope.DepartmentID).
+				Find(&attendance).Error
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("projection", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.List(scope); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChecklistTaskFilter narrows a task list. Zero fields match every task.
+type ChecklistTaskFilter struct {
+	EmployeeID     uint
+	AssigneeUserID uint
+	Status         string
+	// DueBefore keeps tasks due before this date
+	DueBefore *time.Time
+}
+
+// OverdueChecklistTask is a pending task past its due date, with the names of
+// the employee it is for and the person it is assigned to
+type OverdueChecklistTask struct {
+	ID                uint
+	EmployeeID        uint
+	EmployeeFirstName *string
+	EmployeeLastName  *string
+	DepartmentName    string
+	Kind              string
+	Title             string
+	Assignee          string
+	AssigneeUserID    *uint
+	AssigneeFirstName *string
+	AssigneeLastName  *string
+	DueDate           time.Time
+}
+
+type ChecklistRepository interface {
+	// ListTemplates lists the templates of a kind, or of every kind when kind
+	// is empty, with their tasks
+	ListTemplates(kind string) ([]models.ChecklistTemplate, error)
+	// FindTemplate loads a template with its tasks
+	FindTemplate(id uint) (*models.ChecklistTemplate, error)
+	CreateTemplate(template *models.ChecklistTemplate) error
+	// UpdateTemplate writes columns and replaces the tasks if the template is
+	// still at template.Version, then reloads it. Run it in a unit of work so
+	// the tasks are replaced atomically.
+	UpdateTemplate(template *models.ChecklistTemplate, columns map[string]interface{}, tasks []models.ChecklistTemplateTask) error
+	DeleteTemplate(id uint) error
+
+	// HasTasks reports whether a template already produced tasks for an employee
+	HasTasks(employeeID, templateID uint) (bool, error)
+	CreateTasks(tasks []models.ChecklistTask) error
+	FindTask(id uint) (*models.ChecklistTask, error)
+	// ListTasks lists tasks by due date
+	ListTasks(filter ChecklistTaskFilter) ([]models.ChecklistTask, error)
+	// UpdateTask writes columns if the task is still at task.Version and reloads it
+	UpdateTask(task *models.ChecklistTask, columns map[string]interface{}) error
+	// Overdue lists the pending tasks within scope due before date, oldest first
+	Overdue(scope Scope, date time.Time) ([]OverdueChecklistTask, error)
+}
+
+type GormChecklistRepository struct {
+	db *gorm.DB
+}
+
+func NewGormChecklistRepository(db *gorm.DB) *GormChecklistRepository {
+	return &GormChecklistRepository{db: db}
+}
+
+func (r *GormChecklistRepository) withTasks() *gorm.DB {
+	return r.db.Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order").Order("id")
+	})
+}
+
+func (r *GormChecklistRepository) ListTemplates(kind string) ([]models.ChecklistTemplate, error) {
+	query := r.withTasks().Order("id")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var templates []models.ChecklistTemplate
+	err := query.Find(&templates).Error
+	return templates, err
+}
+
+func (r *GormChecklistRepository) FindTemplate(id uint) (*models.ChecklistTemplate, error) {
+	var template models.ChecklistTemplate
+	if err := first(r.withTasks(), &template, id); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *GormChecklistRepository) CreateTemplate(template *models.ChecklistTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r *GormChecklistRepository) UpdateTemplate(template *models.ChecklistTemplate, columns map[string]interface{}, tasks []models.ChecklistTemplateTask) error {
+	if err := updateVersioned(r.db, template, template.Version, columns); err != nil {
+		return err
+	}
+	if err := r.db.Where("template_id = ?", template.ID).Delete(&models.ChecklistTemplateTask{}).Error; err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		for i := range tasks {
+			tasks[i].ID = 0
+			tasks[i].TemplateID = template.ID
+		}
+		if err := r.db.Create(&tasks).Error; err != nil {
+			return err
+		}
+	}
+
+	fresh, err := r.FindTemplate(template.ID)
+	if err != nil {
+		return err
+	}
+	*template = *fresh
+	return nil
+}
+
+func (r *GormChecklistRepository) DeleteTemplate(id uint) error {
+	if err := r.db.Where("template_id = ?", id).Delete(&models.ChecklistTemplateTask{}).Error; err != nil {
+		return err
+	}
+	return r.db.Delete(&models.ChecklistTemplate{}, id).Error
+}
+
+func (r *GormChecklistRepository) HasTasks(employeeID, templateID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ChecklistTask{}).
+		Where("employee_id = ? AND template_id = ?", employeeID, templateID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *GormChecklistRepository) CreateTasks(tasks []models.ChecklistTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	return r.db.Create(&tasks).Error
+}
+
+func (r *GormChecklistRepository) FindTask(id uint) (*models.ChecklistTask, error) {
+	var task models.ChecklistTask
+	if err := first(r.db, &task, id); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *GormChecklistRepository) ListTasks(filter ChecklistTaskFilter) ([]models.ChecklistTask, error) {
+	query := r.db.Order("due_date").Order("id")
+	if filter.EmployeeID != 0 {
+		query = query.Where("employee_id = ?", filter.EmployeeID)
+	}
+	if filter.AssigneeUserID != 0 {
+		query = query.Where("assignee_user_id = ?", filter.AssigneeUserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due_date < ?", *filter.DueBefore)
+	}
+	var tasks []models.ChecklistTask
+	err := query.Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *GormChecklistRepository) UpdateTask(task *models.ChecklistTask, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, task, task.Version, columns); err != nil {
+		return err
+	}
+	fresh, err := r.FindTask(task.ID)
+	if err != nil {
+		return err
+	}
+	*task = *fresh
+	return nil
+}
+
+func (r *GormChecklistRepository) Overdue(scope Scope, date time.Time) ([]OverdueChecklistTask, error) {
+	var tasks []OverdueChecklistTask
+	query := r.db.Model(&models.ChecklistTask{}).
+		Select("checklist_tasks.id, checklist_tasks.employee_id, "+
+			"employees.first_name AS employee_first_name, employees.last_name AS employee_last_name, "+
+			"departments.name AS department_name, checklist_tasks.kind, checklist_tasks.title, "+
+			"checklist_tasks.assignee, checklist_tasks.assignee_user_id, "+
+			"users.first_name AS assignee_first_name, users.last_name AS assignee_last_name, checklist_tasks.due_date").
+		Joins("JOIN employees ON employees.id = checklist_tasks.employee_id AND employees.deleted_at IS NULL").
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id AND departments.deleted_at IS NULL").
+		Joins("LEFT JOIN users ON users.id = checklist_tasks.assignee_user_id AND users.deleted_at IS NULL").
+		Where("checklist_tasks.status = ? AND checklist_tasks.due_date < ?", "pending", date).
+		Order("checklist_tasks.due_date").Order("checklist_tasks.id")
+	err := applyJoinedScope(query, "checklist_tasks", scope).Scan(&tasks).Error
+	return tasks, err
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CodeSequenceRepository interface {
+	// Next advances the sequence of scope, starting it at 1, and returns its
+	// new value. The row stays locked until the surrounding transaction ends,
+	// so concurrent creates in one scope take turns and a rolled back create
+	// gives its number back.
+	Next(scope string) (uint64, error)
+}
+
+type GormCodeSequenceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCodeSequenceRepository(db *gorm.DB) *GormCodeSequenceRepository {
+	return &GormCodeSequenceRepository{db: db}
+}
+
+func (r *GormCodeSequenceRepository) Next(scope string) (uint64, error) {
+	sequence := models.CodeSequence{Scope: scope, Value: 1}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"value":      gorm.Expr("code_sequences.value + 1"),
+			"updated_at": gorm.Expr("CURRENT_TIMESTAMP"),
+		}),
+	}).Create(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+	err = r.db.Where("scope = ?", scope).First(&sequence).Error
+	return sequence.Value, err
+}
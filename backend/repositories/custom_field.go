@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"hrms-backend/models"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// customFieldModels maps each entity that has custom fields to its model
+var customFieldModels = map[string]interface{}{
+	"employee":   &models.Employee{},
+	"department": &models.Department{},
+}
+
+type CustomFieldRepository interface {
+	// List lists the fields of an entity, or of every entity when entity is
+	// empty, in the order they were defined
+	List(entity string) ([]models.CustomField, error)
+	FindByID(id uint) (*models.CustomField, error)
+	// FindByKey finds an entity's field by key
+	FindByKey(entity, key string) (*models.CustomField, error)
+	Create(field *models.CustomField) error
+	// Update writes columns if the field is still at field.Version and reloads it
+	Update(field *models.CustomField, columns map[string]interface{}) error
+	// Delete removes a field and its value from every record of its entity
+	Delete(field *models.CustomField) error
+}
+
+type GormCustomFieldRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCustomFieldRepository(db *gorm.DB) *GormCustomFieldRepository {
+	return &GormCustomFieldRepository{db: db}
+}
+
+func (r *GormCustomFieldRepository) List(entity string) ([]models.CustomField, error) {
+	query := r.db.Order("entity").Order("id")
+	if entity != "" {
+		query = query.Where("entity = ?", entity)
+	}
+	var fields []models.CustomField
+	err := query.Find(&fields).Error
+	return fields, err
+}
+
+func (r *GormCustomFieldRepository) FindByID(id uint) (*models.CustomField, error) {
+	var field models.CustomField
+	if err := first(r.db, &field, id); err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *GormCustomFieldRepository) FindByKey(entity, key string) (*models.CustomField, error) {
+	var field models.CustomField
+	err := r.db.Where("entity = ? AND field_key = ?", entity, key).First(&field).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *GormCustomFieldRepository) Create(field *models.CustomField) error {
+	return r.db.Create(field).Error
+}
+
+func (r *GormCustomFieldRepository) Update(field *models.CustomField, columns map[string]interface{}) error {
+	// GORM only applies the JSON serializer to struct writes
+	if options, ok := columns["options"]; ok {
+		encoded, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		columns["options"] = string(encoded)
+	}
+	if err := updateVersioned(r.db, field, field.Version, columns); err != nil {
+		return err
+	}
+	fresh, err := r.FindByID(field.ID)
+	if err != nil {
+		return err
+	}
+	*field = *fresh
+	return nil
+}
+
+func (r *GormCustomFieldRepository) Delete(field *models.CustomField) error {
+	if model, ok := customFieldModels[field.Entity]; ok {
+		err := r.db.Unscoped().Model(model).
+			Where(jsonText(r.db, "custom_fields")+" IS NOT NULL", jsonPath(r.db, field.Key)).
+			Updates(map[string]interface{}{
+				"custom_fields": jsonRemove(r.db, "custom_fields", field.Key),
+				"version":       gorm.Expr("version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return r.db.Delete(&models.CustomField{}, field.ID).Error
+}
+
+// jsonText is the SQL for the value of a JSON column at a path, given as its
+// one parameter, as text; it is NULL when the document has no such member.
+// Numbers come out as written, so 42 and 42.5 read "42" and "42.5".
+func jsonText(db *gorm.DB, column string) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return column + " ->> ?"
+	case "mysql":
+		return "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", ?))"
+	default:
+		return "CAST(json_extract(" + column + ", ?) AS TEXT)"
+	}
+}
+
+// jsonPath is the path argument of jsonText for a top-level member
+func jsonPath(db *gorm.DB, key string) string {
+	if db.Dialector.Name() == "postgres" {
+		return key
+	}
+	return `$."` + key + `"`
+}
+
+// jsonRemove is an expression for a JSON column without a top-level member
+func jsonRemove(db *gorm.DB, column, key string) clause.Expr {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return gorm.Expr(column+" - ?", key)
+	case "mysql":
+		return gorm.Expr("JSON_REMOVE("+column+", ?)", jsonPath(db, key))
+	default:
+		return gorm.Expr("json_remove("+column+", ?)", jsonPath(db, key))
+	}
+}
+
+// whereCustomValues narrows query to records whose custom values equal
+// filters, compared as text. column is the JSON column, qualified if the
+// query joins other tables.
+func whereCustomValues(query *gorm.DB, column string, filters map[string]string) *gorm.DB {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query = query.Where(jsonText(query, column)+" = ?", jsonPath(query, key), filters[key])
+	}
+	return query
+}
@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"errors"
+	"hrms-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// DepartmentSummary is a department as listed, with its head and headcount
+type DepartmentSummary struct {
+	ID               uint
+	Name             string
+	Description      string
+	Version          uint
+	ManagerFirstName *string
+	ManagerLastName  *string
+	EmployeeCount    int
+	CustomFields     models.CustomValues
+}
+
+type DepartmentRepository interface {
+	// FindByID loads a department with its manager
+	FindByID(id uint) (*models.Department, error)
+	// List lists departments whose custom values equal custom, compared as text
+	List(custom map[string]string) ([]DepartmentSummary, error)
+	// Summary loads one department as List would
+	Summary(id uint) (*DepartmentSummary, error)
+	// CountEmployees counts the live employees of a department
+	CountEmployees(id uint) (int64, error)
+	Create(department *models.Department) error
+	// Update writes columns if the department is still at department.Version and reloads it
+	Update(department *models.Department, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormDepartmentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDepartmentRepository(db *gorm.DB) *GormDepartmentRepository {
+	return &GormDepartmentRepository{db: db}
+}
+
+// summaries selects departments with their head's name and a headcount
+// aggregated in the database, rather than loading every employee to count them
+func (r *GormDepartmentRepository) summaries() *gorm.DB {
+	headcounts := r.db.Model(&models.Employee{}).
+		Select("department_id, COUNT(*) AS employee_count").
+		Group("department_id")
+
+	return r.db.Model(&models.Department{}).
+		Select("departments.id, departments.name, departments.description, departments.version, "+
+			"managers.first_name AS manager_first_name, managers.last_name AS manager_last_name, "+
+			"COALESCE(headcounts.employee_count, 0) AS employee_count, departments.custom_fields").
+		Joins("LEFT JOIN employees managers ON managers.id = departments.manager_id AND managers.deleted_at IS NULL").
+		Joins("LEFT JOIN (?) headcounts ON headcounts.department_id = departments.id", headcounts)
+}
+
+func (r *GormDepartmentRepository) FindByID(id uint) (*models.Department, error) {
+	var department models.Department
+	if err := first(r.db, &department, id); err != nil {
+		return nil, err
+	}
+	if err := r.loadManager(&department); err != nil {
+		return nil, err
+	}
+	return &department, nil
+}
+
+// loadManager sets department.Manager from department.ManagerID
+func (r *GormDepartmentRepository) loadManager(department *models.Department) error {
+	department.Manager = nil
+	if department.ManagerID == nil {
+		return nil
+	}
+	var manager models.Employee
+	err := first(r.db, &manager, *department.ManagerID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	department.Manager = &manager
+	return nil
+}
+
+func (r *GormDepartmentRepository) List(custom map[string]string) ([]DepartmentSummary, error) {
+	var departments []DepartmentSummary
+	err := whereCustomValues(r.summaries(), "departments.custom_fields", custom).
+		Order("departments.id").
+		Scan(&departments).Error
+	return departments, err
+}
+
+func (r *GormDepartmentRepository) Summary(id uint) (*DepartmentSummary, error) {
+	var departments []DepartmentSummary
+	if err := r.summaries().Where("departments.id = ?", id).Scan(&departments).Error; err != nil {
+		return nil, err
+	}
+	if len(departments) == 0 {
+		return nil, ErrNotFound
+	}
+	return &departments[0], nil
+}
+
+func (r *GormDepartmentRepository) CountEmployees(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Employee{}).Where("department_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *GormDepartmentRepository) Create(department *models.Department) error {
+	return r.db.Create(department).Error
+}
+
+func (r *GormDepartmentRepository) Update(department *models.Department, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, department, department.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(department)
+}
+
+// reload refreshes department and its relations after a write
+func (r *GormDepartmentRepository) reload(department *models.Department) error {
+	fresh, err := r.FindByID(department.ID)
+	if err != nil {
+		return err
+	}
+	*department = *fresh
+	return nil
+}
+
+func (r *GormDepartmentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Department{}, id).Error
+}
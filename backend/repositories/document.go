@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpiringDocument is a document with an expiry date, with the name and
+// department of the employee it belongs to
+type ExpiringDocument struct {
+	ID                uint
+	EmployeeID        uint
+	EmployeeFirstName *string
+	EmployeeLastName  *string
+	DepartmentName    string
+	Category          string
+	Name              string
+	ExpiresAt         time.Time
+}
+
+type DocumentRepository interface {
+	Find(id uint) (*models.Document, error)
+	// ListByEmployee lists an employee's documents, newest first. A non-empty
+	// visibilities keeps only documents with one of those visibilities.
+	ListByEmployee(employeeID uint, visibilities []string) ([]models.Document, error)
+	Create(document *models.Document) error
+	// Update writes columns if the document is still at document.Version and reloads it
+	Update(document *models.Document, columns map[string]interface{}) error
+	Delete(id uint) error
+	// Expiring lists the documents within scope that expire before date,
+	// soonest first, filtered by visibility like ListByEmployee
+	Expiring(scope Scope, visibilities []string, date time.Time) ([]ExpiringDocument, error)
+}
+
+type GormDocumentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDocumentRepository(db *gorm.DB) *GormDocumentRepository {
+	return &GormDocumentRepository{db: db}
+}
+
+func (r *GormDocumentRepository) Find(id uint) (*models.Document, error) {
+	var document models.Document
+	if err := first(r.db, &document, id); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *GormDocumentRepository) ListByEmployee(employeeID uint, visibilities []string) ([]models.Document, error) {
+	query := r.db.Where("employee_id = ?", employeeID).Order("created_at DESC").Order("id DESC")
+	if len(visibilities) > 0 {
+		query = query.Where("visibility IN ?", visibilities)
+	}
+	var documents []models.Document
+	err := query.Find(&documents).Error
+	return documents, err
+}
+
+func (r *GormDocumentRepository) Create(document *models.Document) error {
+	return r.db.Create(document).Error
+}
+
+func (r *GormDocumentRepository) Update(document *models.Document, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, document, document.Version, columns); err != nil {
+		return err
+	}
+	fresh, err := r.Find(document.ID)
+	if err != nil {
+		return err
+	}
+	*document = *fresh
+	return nil
+}
+
+func (r *GormDocumentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Document{}, id).Error
+}
+
+func (r *GormDocumentRepository) Expiring(scope Scope, visibilities []string, date time.Time) ([]ExpiringDocument, error) {
+	var documents []ExpiringDocument
+	query := r.db.Model(&models.Document{}).
+		Select("documents.id, documents.employee_id, "+
+			"employees.first_name AS employee_first_name, employees.last_name AS employee_last_name, "+
+			"departments.name AS department_name, documents.category, documents.name, documents.expires_at").
+		Joins("JOIN employees ON employees.id = documents.employee_id AND employees.deleted_at IS NULL").
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id AND departments.deleted_at IS NULL").
+		Where("documents.expires_at IS NOT NULL AND documents.expires_at < ?", date).
+		Order("documents.expires_at").Order("documents.id")
+	if len(visibilities) > 0 {
+		query = query.Where("documents.visibility IN ?", visibilities)
+	}
+	err := applyJoinedScope(query, "documents", scope).Scan(&documents).Error
+	return documents, err
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmployeeSummary is an employee as listed, with their department's name
+type EmployeeSummary struct {
+	ID             uint
+	EmployeeCode   string
+	FirstName      string
+	LastName       string
+	Email          string
+	Phone          string
+	Position       string
+	HireDate       time.Time
+	Status         string
+	Salary         float64
+	Version        uint
+	DepartmentName string
+	CustomFields   models.CustomValues
+}
+
+// ReportingLine is an employee as placed in the org chart
+type ReportingLine struct {
+	ID             uint
+	FirstName      string
+	LastName       string
+	Position       string
+	DepartmentID   uint
+	DepartmentName string
+	ManagerID      *uint
+}
+
+type EmployeeRepository interface {
+	// FindByID loads an employee with their department, manager and user account
+	FindByID(id uint) (*models.Employee, error)
+	// List lists employees whose custom values equal custom, compared as text
+	List(custom map[string]string) ([]EmployeeSummary, error)
+	// ListActive loads the active employees of a department, or of every
+	// department when departmentID is 0, with their department
+	ListActive(departmentID uint) ([]models.Employee, error)
+	Create(employee *models.Employee) error
+	// CodeExists reports whether any employee, deleted ones included, has code
+	CodeExists(code string) (bool, error)
+	// Update writes columns if the employee is still at employee.Version and reloads it
+	Update(employee *models.Employee, columns map[string]interface{}) error
+	// ReportingLines lists every employee who has not been terminated with
+	// their manager, ordered by ID
+	ReportingLines() ([]ReportingLine, error)
+	// ManagerOf returns the ID of an employee's manager, or nil if they have none
+	ManagerOf(id uint) (*uint, error)
+	// DueTerminationIDs lists the employees whose last working day is before
+	// date but who are not terminated yet
+	DueTerminationIDs(date time.Time) ([]uint, error)
+	// ReassignDepartment moves every employee of one department to another
+	ReassignDepartment(fromID, toID uint) error
+	Delete(id uint) error
+}
+
+type GormEmployeeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEmployeeRepository(db *gorm.DB) *GormEmployeeRepository {
+	return &GormEmployeeRepository{db: db}
+}
+
+func (r *GormEmployeeRepository) withRelations() *gorm.DB {
+	return r.db.Preload("Department").Preload("Manager").Preload("User")
+}
+
+func (r *GormEmployeeRepository) FindByID(id uint) (*models.Employee, error) {
+	var employee models.Employee
+	if err := first(r.withRelations(), &employee, id); err != nil {
+		return nil, err
+	}
+	return &employee, nil
+}
+
+func (r *GormEmployeeRepository) List(custom map[string]string) ([]EmployeeSummary, error) {
+	var employees []EmployeeSummary
+	query := r.db.Model(&models.Employee{}).
+		Select("employees.id, employees.employee_code, employees.first_name, employees.last_name, employees.email, employees.phone, " +
+			"employees.position, employees.hire_date, employees.status, employees.salary, employees.version, " +
+			"COALESCE(departments.name, '') AS department_name, employees.custom_fields").
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id AND departments.deleted_at IS NULL")
+	err := whereCustomValues(query, "employees.custom_fields", custom).
+		Order("employees.id").
+		Scan(&employees).Error
+	return employees, err
+}
+
+func (r *GormEmployeeRepository) ListActive(departmentID uint) ([]models.Employee, error) {
+	query := r.db.Preload("Department").Where("status = ?", "active")
+	if departmentID != 0 {
+		query = query.Where("department_id = ?", departmentID)
+	}
+	var employees []models.Employee
+	err := query.Order("id").Find(&employees).Error
+	return employees, err
+}
+
+func (r *GormEmployeeRepository) Create(employee *models.Employee) error {
+	if err := r.db.Create(employee).Error; err != nil {
+		return err
+	}
+	return r.reload(employee)
+}
+
+func (r *GormEmployeeRepository) CodeExists(code string) (bool, error) {
+	// Deleted employees keep their code in the unique index
+	var count int64
+	err := r.db.Unscoped().Model(&models.Employee{}).Where("employee_code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *GormEmployeeRepository) Update(employee *models.Employee, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, employee, employee.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(employee)
+}
+
+// reload refreshes employee and its relations after a write
+func (r *GormEmployeeRepository) reload(employee *models.Employee) error {
+	var fresh models.Employee
+	if err := first(r.withRelations(), &fresh, employee.ID); err != nil {
+		return err
+	}
+	*employee = fresh
+	return nil
+}
+
+func (r *GormEmployeeRepository) DueTerminationIDs(date time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Employee{}).
+		Where("termination_date < ? AND status <> ?", date, "terminated").
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *GormEmployeeRepository) ReportingLines() ([]ReportingLine, error) {
+	var lines []ReportingLine
+	err := r.db.Model(&models.Employee{}).
+		Select("employees.id, employees.employee_code, employees.first_name, employees.last_name, employees.position, "+
+			"employees.department_id, COALESCE(departments.name, '') AS department_name, employees.manager_id").
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id AND departments.deleted_at IS NULL").
+		Where("employees.status <> ?", "terminated").
+		Order("employees.id").
+		Scan(&lines).Error
+	return lines, err
+}
+
+func (r *GormEmployeeRepository) ManagerOf(id uint) (*uint, error) {
+	var employee models.Employee
+	if err := first(r.db.Select("id", "manager_id"), &employee, id); err != nil {
+		return nil, err
+	}
+	return employee.ManagerID, nil
+}
+
+func (r *GormEmployeeRepository) ReassignDepartment(fromID, toID uint) error {
+	return r.db.Model(&models.Employee{}).Where("department_id = ?", fromID).Updates(map[string]interface{}{
+		"department_id": toID,
+		"version":       gorm.Expr("version + 1"),
+	}).Error
+}
+
+func (r *GormEmployeeRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Employee{}, id).Error
+}
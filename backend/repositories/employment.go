@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EmploymentChangeRepository interface {
+	FindByID(id uint) (*models.EmploymentChange, error)
+	// ListByEmployee lists every change of an employee, cancelled ones
+	// included, in the order they take effect
+	ListByEmployee(employeeID uint) ([]models.EmploymentChange, error)
+	// ListEffective lists the changes of an employee that are not cancelled
+	// and take effect on or before date, in the order they take effect
+	ListEffective(employeeID uint, date time.Time) ([]models.EmploymentChange, error)
+	// DueEmployeeIDs lists the employees with scheduled changes taking effect
+	// on or before date
+	DueEmployeeIDs(date time.Time) ([]uint, error)
+	Create(change *models.EmploymentChange) error
+	// MarkApplied marks an employee's scheduled changes taking effect on or
+	// before date as applied at appliedAt
+	MarkApplied(employeeID uint, date, appliedAt time.Time) error
+	// Cancel cancels a scheduled change, returning ErrStale if it is no
+	// longer scheduled
+	Cancel(id uint) error
+	// MoveHire moves an employee's hire record to a new hire date
+	MoveHire(employeeID uint, hireDate time.Time) error
+}
+
+type GormEmploymentChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEmploymentChangeRepository(db *gorm.DB) *GormEmploymentChangeRepository {
+	return &GormEmploymentChangeRepository{db: db}
+}
+
+// before returns the start of the day after date, so that "on or before
+// date" also matches effective dates carrying a time of day, as hire dates
+// copied from employee records may
+func before(date time.Time) time.Time {
+	return date.AddDate(0, 0, 1)
+}
+
+// inOrder sorts changes by effective date, and changes on the same date in
+// the order they were recorded
+func inOrder(query *gorm.DB) *gorm.DB {
+	return query.Order("effective_date").Order("id")
+}
+
+func (r *GormEmploymentChangeRepository) FindByID(id uint) (*models.EmploymentChange, error) {
+	var change models.EmploymentChange
+	if err := first(r.db, &change, id); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *GormEmploymentChangeRepository) ListByEmployee(employeeID uint) ([]models.EmploymentChange, error) {
+	var changes []models.EmploymentChange
+	err := inOrder(r.db.Where("employee_id = ?", employeeID)).Find(&changes).Error
+	return changes, err
+}
+
+func (r *GormEmploymentChangeRepository) ListEffective(employeeID uint, date time.Time) ([]models.EmploymentChange, error) {
+	var changes []models.EmploymentChange
+	err := inOrder(r.db.Where("employee_id = ? AND effective_date < ? AND status <> ?", employeeID, before(date), "cancelled")).
+		Find(&changes).Error
+	return changes, err
+}
+
+func (r *GormEmploymentChangeRepository) DueEmployeeIDs(date time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.EmploymentChange{}).
+		Where("status = ? AND effective_date < ?", "scheduled", before(date)).
+		Distinct().Order("employee_id").Pluck("employee_id", &ids).Error
+	return ids, err
+}
+
+func (r *GormEmploymentChangeRepository) Create(change *models.EmploymentChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *GormEmploymentChangeRepository) MarkApplied(employeeID uint, date, appliedAt time.Time) error {
+	return r.db.Model(&models.EmploymentChange{}).
+		Where("employee_id = ? AND status = ? AND effective_date < ?", employeeID, "scheduled", before(date)).
+		Updates(map[string]interface{}{"status": "applied", "applied_at": appliedAt}).Error
+}
+
+func (r *GormEmploymentChangeRepository) Cancel(id uint) error {
+	result := r.db.Model(&models.EmploymentChange{}).
+		Where("id = ? AND status = ?", id, "scheduled").
+		Update("status", "cancelled")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+func (r *GormEmploymentChangeRepository) MoveHire(employeeID uint, hireDate time.Time) error {
+	return r.db.Model(&models.EmploymentChange{}).
+		Where("employee_id = ? AND kind = ?", employeeID, "hire").
+		Update("effective_date", hireDate).Error
+}
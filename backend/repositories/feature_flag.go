@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"hrms-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// featureFlagJSONColumns hold JSON arrays. GORM only applies the model's JSON
+// serializer to struct writes, so map updates encode them here.
+var featureFlagJSONColumns = []string{"roles", "department_ids", "user_ids"}
+
+type FeatureFlagRepository interface {
+	List() ([]models.FeatureFlag, error)
+	FindByKey(key string) (*models.FeatureFlag, error)
+	Create(flag *models.FeatureFlag) error
+	// Update writes columns if the flag is still at flag.Version and reloads it
+	Update(flag *models.FeatureFlag, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormFeatureFlagRepository struct {
+	db *gorm.DB
+}
+
+func NewGormFeatureFlagRepository(db *gorm.DB) *GormFeatureFlagRepository {
+	return &GormFeatureFlagRepository{db: db}
+}
+
+func (r *GormFeatureFlagRepository) List() ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	err := r.db.Order("flag_key").Find(&flags).Error
+	return flags, err
+}
+
+func (r *GormFeatureFlagRepository) FindByKey(key string) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	err := r.db.Where("flag_key = ?", key).First(&flag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+func (r *GormFeatureFlagRepository) Create(flag *models.FeatureFlag) error {
+	return r.db.Create(flag).Error
+}
+
+func (r *GormFeatureFlagRepository) Update(flag *models.FeatureFlag, columns map[string]interface{}) error {
+	for _, column := range featureFlagJSONColumns {
+		if value, ok := columns[column]; ok {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			columns[column] = string(encoded)
+		}
+	}
+	if err := updateVersioned(r.db, flag, flag.Version, columns); err != nil {
+		return err
+	}
+	var fresh models.FeatureFlag
+	if err := first(r.db, &fresh, flag.ID); err != nil {
+		return err
+	}
+	*flag = fresh
+	return nil
+}
+
+func (r *GormFeatureFlagRepository) Delete(id uint) error {
+	return r.db.Delete(&models.FeatureFlag{}, id).Error
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeaveSummary is a leave request as listed, with the names of its employee
+// and approver
+type LeaveSummary struct {
+	ID                uint
+	EmployeeID        uint
+	EmployeeFirstName *string
+	EmployeeLastName  *string
+	LeaveType         string
+	StartDate         time.Time
+	EndDate           time.Time
+	Days              int
+	Reason            string
+	Status            string
+	CreatedAt         time.Time
+	ApproverFirstName *string
+	ApproverLastName  *string
+	ApprovedAt        *time.Time
+	Comments          string
+	Version           uint
+}
+
+type LeaveRepository interface {
+	// FindByID loads a leave request with its employee, department and approver
+	FindByID(id uint) (*models.LeaveRequest, error)
+	List(scope Scope) ([]LeaveSummary, error)
+	// ListByEmployee lists an employee's leave requests ending on or after
+	// from, by start date
+	ListByEmployee(employeeID uint, from time.Time) ([]models.LeaveRequest, error)
+	Create(leave *models.LeaveRequest) error
+	// Update writes columns if the request is still at leave.Version and reloads it
+	Update(leave *models.LeaveRequest, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormLeaveRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaveRepository(db *gorm.DB) *GormLeaveRepository {
+	return &GormLeaveRepository{db: db}
+}
+
+func (r *GormLeaveRepository) withRelations() *gorm.DB {
+	return r.db.Preload("Employee").Preload("Employee.Department").Preload("Approver")
+}
+
+func (r *GormLeaveRepository) FindByID(id uint) (*models.LeaveRequest, error) {
+	var leave models.LeaveRequest
+	if err := first(r.withRelations(), &leave, id); err != nil {
+		return nil, err
+	}
+	return &leave, nil
+}
+
+func (r *GormLeaveRepository) List(scope Scope) ([]LeaveSummary, error) {
+	var leaves []LeaveSummary
+	query := r.db.Model(&models.LeaveRequest{}).
+		Select("leave_requests.id, leave_requests.employee_id, " +
+			"employees.first_name AS employee_first_name, employees.last_name AS employee_last_name, " +
+			"leave_requests.leave_type, leave_requests.start_date, leave_requests.end_date, leave_requests.days, " +
+			"leave_requests.reason, leave_requests.status, leave_requests.created_at, " +
+			"approvers.first_name AS approver_first_name, approvers.last_name AS approver_last_name, " +
+			"leave_requests.approved_at, leave_requests.comments, leave_requests.version").
+		Joins("LEFT JOIN employees ON employees.id = leave_requests.employee_id AND employees.deleted_at IS NULL").
+		Joins("LEFT JOIN employees approvers ON approvers.id = leave_requests.approved_by AND approvers.deleted_at IS NULL").
+		Order("leave_requests.id")
+	err := applyJoinedScope(query, "leave_requests", scope).Scan(&leaves).Error
+	return leaves, err
+}
+
+func (r *GormLeaveRepository) Create(leave *models.LeaveRequest) error {
+	if err := r.db.Create(leave).Error; err != nil {
+		return err
+	}
+	return r.reload(leave)
+}
+
+func (r *GormLeaveRepository) ListByEmployee(employeeID uint, from time.Time) ([]models.LeaveRequest, error) {
+	var leaves []models.LeaveRequest
+	err := r.db.Where("employee_id = ? AND end_date >= ?", employeeID, from).
+		Order("start_date").Order("id").
+		Find(&leaves).Error
+	return leaves, err
+}
+
+func (r *GormLeaveRepository) Update(leave *models.LeaveRequest, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, leave, leave.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(leave)
+}
+
+// reload refreshes leave and its relations after a write
+func (r *GormLeaveRepository) reload(leave *models.LeaveRequest) error {
+	var fresh models.LeaveRequest
+	if err := first(r.withRelations(), &fresh, leave.ID); err != nil {
+		return err
+	}
+	*leave = fresh
+	return nil
+}
+
+func (r *GormLeaveRepository) Delete(id uint) error {
+	return r.db.Delete(&models.LeaveRequest{}, id).Error
+}
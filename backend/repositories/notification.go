@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	// ListByUser lists a user's notifications, newest first; only unread ones
+	// when unread is set
+	ListByUser(userID uint, unread bool) ([]models.Notification, error)
+	FindByID(id uint) (*models.Notification, error)
+	Create(notification *models.Notification) error
+	// MarkRead marks a notification read at, unless it already is, and reloads it
+	MarkRead(notification *models.Notification, at time.Time) error
+}
+
+type GormNotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNotificationRepository(db *gorm.DB) *GormNotificationRepository {
+	return &GormNotificationRepository{db: db}
+}
+
+func (r *GormNotificationRepository) ListByUser(userID uint, unread bool) ([]models.Notification, error) {
+	query := r.db.Where("user_id = ?", userID).Order("created_at DESC").Order("id DESC")
+	if unread {
+		query = query.Where("read_at IS NULL")
+	}
+	var notifications []models.Notification
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *GormNotificationRepository) FindByID(id uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := first(r.db, &notification, id); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *GormNotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *GormNotificationRepository) MarkRead(notification *models.Notification, at time.Time) error {
+	err := r.db.Model(&models.Notification{}).
+		Where("id = ? AND read_at IS NULL", notification.ID).
+		UpdateColumn("read_at", at).Error
+	if err != nil {
+		return err
+	}
+	fresh, err := r.FindByID(notification.ID)
+	if err != nil {
+		return err
+	}
+	*notification = *fresh
+	return nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PayrollFilter narrows a payroll listing. Zero values are ignored.
+type PayrollFilter struct {
+	Scope
+	// PeriodFrom and PeriodTo bound the pay period start, [from, to)
+	PeriodFrom time.Time
+	PeriodTo   time.Time
+}
+
+type PayrollRepository interface {
+	// FindByID loads a payroll record with its employee and department
+	FindByID(id uint) (*models.PayrollRecord, error)
+	List(filter PayrollFilter) ([]models.PayrollRecord, error)
+	// Report lists like List but may read from a replica, so it can lag behind
+	// recent writes
+	Report(filter PayrollFilter) ([]models.PayrollRecord, error)
+	Create(record *models.PayrollRecord) error
+	// Update writes columns if the record is still at record.Version and reloads it
+	Update(record *models.PayrollRecord, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormPayrollRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPayrollRepository(db *gorm.DB) *GormPayrollRepository {
+	return &GormPayrollRepository{db: db}
+}
+
+func (r *GormPayrollRepository) withRelations() *gorm.DB {
+	return r.db.Preload("Employee").Preload("Employee.Department")
+}
+
+func (r *GormPayrollRepository) FindByID(id uint) (*models.PayrollRecord, error) {
+	var record models.PayrollRecord
+	if err := first(r.withRelations(), &record, id); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *GormPayrollRepository) List(filter PayrollFilter) ([]models.PayrollRecord, error) {
+	return r.list(r.joinRelations(), filter)
+}
+
+func (r *GormPayrollRepository) Report(filter PayrollFilter) ([]models.PayrollRecord, error) {
+	return r.list(reporting(r.joinRelations()), filter)
+}
+
+// joinRelations loads the employee and department in the same query as each
+// record, instead of one preload query per relation
+func (r *GormPayrollRepository) joinRelations() *gorm.DB {
+	return r.db.Joins("Employee").Joins("Employee.Department").Order("payroll_records.id")
+}
+
+func (r *GormPayrollRepository) list(query *gorm.DB, filter PayrollFilter) ([]models.PayrollRecord, error) {
+	var records []models.PayrollRecord
+	query = applyScope(query, "payroll_records", filter.Scope)
+	// A date range rather than EXTRACT keeps the filter portable and indexable
+	if !filter.PeriodFrom.IsZero() {
+		query = query.Where("payroll_records.pay_period_start >= ?", filter.PeriodFrom)
+	}
+	if !filter.PeriodTo.IsZero() {
+		query = query.Where("payroll_records.pay_period_start < ?", filter.PeriodTo)
+	}
+	err := query.Find(&records).Error
+	return records, err
+}
+
+func (r *GormPayrollRepository) Create(record *models.PayrollRecord) error {
+	if err := r.db.Create(record).Error; err != nil {
+		return err
+	}
+	return r.reload(record)
+}
+
+func (r *GormPayrollRepository) Update(record *models.PayrollRecord, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, record, record.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(record)
+}
+
+// reload refreshes record and its relations after a write
+func (r *GormPayrollRepository) reload(record *models.PayrollRecord) error {
+	var fresh models.PayrollRecord
+	if err := first(r.withRelations(), &fresh, record.ID); err != nil {
+		return err
+	}
+	*record = fresh
+	return nil
+}
+
+func (r *GormPayrollRepository) Delete(id uint) error {
+	return r.db.Delete(&models.PayrollRecord{}, id).Error
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"hrms-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type EmergencyContactRepository interface {
+	// ListByEmployee lists an employee's contacts in priority order
+	ListByEmployee(employeeID uint) ([]models.EmergencyContact, error)
+	FindByID(id uint) (*models.EmergencyContact, error)
+	Create(contact *models.EmergencyContact) error
+	// Update writes columns if the contact is still at contact.Version and reloads it
+	Update(contact *models.EmergencyContact, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormEmergencyContactRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEmergencyContactRepository(db *gorm.DB) *GormEmergencyContactRepository {
+	return &GormEmergencyContactRepository{db: db}
+}
+
+func (r *GormEmergencyContactRepository) ListByEmployee(employeeID uint) ([]models.EmergencyContact, error) {
+	var contacts []models.EmergencyContact
+	err := r.db.Where("employee_id = ?", employeeID).Order("priority").Order("id").Find(&contacts).Error
+	return contacts, err
+}
+
+func (r *GormEmergencyContactRepository) FindByID(id uint) (*models.EmergencyContact, error) {
+	var contact models.EmergencyContact
+	if err := first(r.db, &contact, id); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (r *GormEmergencyContactRepository) Create(contact *models.EmergencyContact) error {
+	return r.db.Create(contact).Error
+}
+
+func (r *GormEmergencyContactRepository) Update(contact *models.EmergencyContact, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, contact, contact.Version, columns); err != nil {
+		return err
+	}
+	fresh, err := r.FindByID(contact.ID)
+	if err != nil {
+		return err
+	}
+	*contact = *fresh
+	return nil
+}
+
+func (r *GormEmergencyContactRepository) Delete(id uint) error {
+	return r.db.Delete(&models.EmergencyContact{}, id).Error
+}
+
+type DependentRepository interface {
+	// ListByEmployee lists an employee's dependents, oldest first
+	ListByEmployee(employeeID uint) ([]models.Dependent, error)
+	FindByID(id uint) (*models.Dependent, error)
+	Create(dependent *models.Dependent) error
+	// Update writes columns if the dependent is still at dependent.Version and reloads it
+	Update(dependent *models.Dependent, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormDependentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDependentRepository(db *gorm.DB) *GormDependentRepository {
+	return &GormDependentRepository{db: db}
+}
+
+func (r *GormDependentRepository) ListByEmployee(employeeID uint) ([]models.Dependent, error) {
+	var dependents []models.Dependent
+	err := r.db.Where("employee_id = ?", employeeID).Order("birth_date").Order("id").Find(&dependents).Error
+	return dependents, err
+}
+
+func (r *GormDependentRepository) FindByID(id uint) (*models.Dependent, error) {
+	var dependent models.Dependent
+	if err := first(r.db, &dependent, id); err != nil {
+		return nil, err
+	}
+	return &dependent, nil
+}
+
+func (r *GormDependentRepository) Create(dependent *models.Dependent) error {
+	return r.db.Create(dependent).Error
+}
+
+func (r *GormDependentRepository) Update(dependent *models.Dependent, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, dependent, dependent.Version, columns); err != nil {
+		return err
+	}
+	fresh, err := r.FindByID(dependent.ID)
+	if err != nil {
+		return err
+	}
+	*dependent = *fresh
+	return nil
+}
+
+func (r *GormDependentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Dependent{}, id).Error
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"errors"
+	"hrms-backend/database"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+var (
+	// ErrNotFound is returned when no live record matches the lookup
+	ErrNotFound = errors.New("record not found")
+	// ErrStale is returned when a versioned update finds the row has changed
+	ErrStale = errors.New("record was modified by another request")
+)
+
+// Scope limits a list to one employee's or one department's records. The zero
+// value matches every record.
+type Scope struct {
+	EmployeeID   uint
+	DepartmentID uint
+}
+
+// Repositories bundles the repository of every aggregate
+type Repositories struct {
+	Users             UserRepository
+	Employees         EmployeeRepository
+	Departments       DepartmentRepository
+	Leaves            LeaveRepository
+	Attendance        AttendanceRepository
+	Payroll           PayrollRepository
+	FeatureFlags      FeatureFlagRepository
+	Employment        EmploymentChangeRepository
+	Checklists        ChecklistRepository
+	Documents         DocumentRepository
+	EmergencyContacts EmergencyContactRepository
+	Dependents        DependentRepository
+	CustomFields      CustomFieldRepository
+	CodeSequences     CodeSequenceRepository
+	Skills            SkillRepository
+	Certifications    CertificationRepository
+	Notifications     NotificationRepository
+}
+
+// NewGormRepositories returns GORM-backed repositories sharing db
+func NewGormRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:             NewGormUserRepository(db),
+		Employees:         NewGormEmployeeRepository(db),
+		Departments:       NewGormDepartmentRepository(db),
+		Leaves:            NewGormLeaveRepository(db),
+		Attendance:        NewGormAttendanceRepository(db),
+		Payroll:           NewGormPayrollRepository(db),
+		FeatureFlags:      NewGormFeatureFlagRepository(db),
+		Employment:        NewGormEmploymentChangeRepository(db),
+		Checklists:        NewGormChecklistRepository(db),
+		Documents:         NewGormDocumentRepository(db),
+		EmergencyContacts: NewGormEmergencyContactRepository(db),
+		Dependents:        NewGormDependentRepository(db),
+		CustomFields:      NewGormCustomFieldRepository(db),
+		CodeSequences:     NewGormCodeSequenceRepository(db),
+		Skills:            NewGormSkillRepository(db),
+		Certifications:    NewGormCertificationRepository(db),
+		Notifications:     NewGormNotificationRepository(db),
+	}
+}
+
+// first loads one record into dest, translating a miss into ErrNotFound
+func first(query *gorm.DB, dest interface{}, id uint) error {
+	err := query.First(dest, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// reporting routes query to a read replica when one is configured. Only use it
+// for reports, which can tolerate replication lag; inside a unit of work it has
+// no effect and the query runs in the transaction.
+func reporting(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(database.Reports))
+}
+
+// updateVersioned writes columns to the record held in model only if its row
+// still carries the expected version, bumping the version in the same
+// statement. It returns ErrStale when another request changed the row first.
+func updateVersioned(db *gorm.DB, model interface{}, version uint, columns map[string]interface{}) error {
+	columns["version"] = gorm.Expr("version + 1")
+
+	result := db.Model(model).Where("version = ?", version).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// applyScope restricts query to scope, joining employees when the scope is a department
+func applyScope(query *gorm.DB, table string, scope Scope) *gorm.DB {
+	if scope.EmployeeID != 0 {
+		query = query.Where(table+".employee_id = ?", scope.EmployeeID)
+	}
+	if scope.DepartmentID != 0 {
+		query = query.Joins("JOIN employees ON "+table+".employee_id = employees.id").
+			Where("employees.department_id = ?", scope.DepartmentID)
+	}
+	return query
+}
+
+// applyJoinedScope is applyScope for queries that already join employees
+func applyJoinedScope(query *gorm.DB, table string, scope Scope) *gorm.DB {
+	if scope.EmployeeID != 0 {
+		query = query.Where(table+".employee_id = ?", scope.EmployeeID)
+	}
+	if scope.DepartmentID != 0 {
+		query = query.Where("employees.department_id = ?", scope.DepartmentID)
+	}
+	return query
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DueCertification is a certification whose holder and manager are due a
+// warning that it expires, with the names needed to write the warning
+type DueCertification struct {
+	ID                uint
+	EmployeeID        uint
+	EmployeeFirstName string
+	EmployeeLastName  string
+	SkillName         string
+	Name              string
+	ExpiresAt         time.Time
+}
+
+type SkillRepository interface {
+	// List lists the catalog by name
+	List() ([]models.Skill, error)
+	FindByID(id uint) (*models.Skill, error)
+	FindByName(name string) (*models.Skill, error)
+	Create(skill *models.Skill) error
+	// Update writes columns if the skill is still at skill.Version and reloads it
+	Update(skill *models.Skill, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormSkillRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSkillRepository(db *gorm.DB) *GormSkillRepository {
+	return &GormSkillRepository{db: db}
+}
+
+func (r *GormSkillRepository) List() ([]models.Skill, error) {
+	var skills []models.Skill
+	err := r.db.Order("name").Find(&skills).Error
+	return skills, err
+}
+
+func (r *GormSkillRepository) FindByID(id uint) (*models.Skill, error) {
+	var skill models.Skill
+	if err := first(r.db, &skill, id); err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+func (r *GormSkillRepository) FindByName(name string) (*models.Skill, error) {
+	var skill models.Skill
+	err := r.db.Where("name = ?", name).First(&skill).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+func (r *GormSkillRepository) Create(skill *models.Skill) error {
+	return r.db.Create(skill).Error
+}
+
+func (r *GormSkillRepository) Update(skill *models.Skill, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, skill, skill.Version, columns); err != nil {
+		return err
+	}
+	fresh, err := r.FindByID(skill.ID)
+	if err != nil {
+		return err
+	}
+	*skill = *fresh
+	return nil
+}
+
+func (r *GormSkillRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Skill{}, id).Error
+}
+
+type CertificationRepository interface {
+	// ListByEmployee lists an employee's certifications with their skill,
+	// those expiring soonest first and those that never expire last
+	ListByEmployee(employeeID uint) ([]models.Certification, error)
+	// ListByDepartment lists the certifications of a department's active
+	// employees, like ListByEmployee
+	ListByDepartment(departmentID uint) ([]models.Certification, error)
+	// FindByID loads a certification with its skill
+	FindByID(id uint) (*models.Certification, error)
+	Create(certification *models.Certification) error
+	// Update writes columns if the certification is still at
+	// certification.Version and reloads it
+	Update(certification *models.Certification, columns map[string]interface{}) error
+	Delete(id uint) error
+	// CountBySkill counts the certifications held for a skill
+	CountBySkill(skillID uint) (int64, error)
+	// DueWarnings lists the certifications of employees who are not
+	// terminated that expire before date and have not been warned about
+	DueWarnings(date time.Time) ([]DueCertification, error)
+	// MarkWarned records that a certification's warning was sent at. It
+	// returns false when it was already marked, so only one caller sends it.
+	MarkWarned(id uint, at time.Time) (bool, error)
+}
+
+type GormCertificationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCertificationRepository(db *gorm.DB) *GormCertificationRepository {
+	return &GormCertificationRepository{db: db}
+}
+
+// ordered sorts certifications by expiry, those that never expire last
+func (r *GormCertificationRepository) ordered() *gorm.DB {
+	return r.db.Preload("Skill").
+		Order("CASE WHEN certifications.expires_at IS NULL THEN 1 ELSE 0 END").
+		Order("certifications.expires_at").Order("certifications.id")
+}
+
+func (r *GormCertificationRepository) ListByEmployee(employeeID uint) ([]models.Certification, error) {
+	var certifications []models.Certification
+	err := r.ordered().Where("employee_id = ?", employeeID).Find(&certifications).Error
+	return certifications, err
+}
+
+func (r *GormCertificationRepository) ListByDepartment(departmentID uint) ([]models.Certification, error) {
+	var certifications []models.Certification
+	err := r.ordered().
+		Joins("JOIN employees ON employees.id = certifications.employee_id AND employees.deleted_at IS NULL").
+		Where("employees.department_id = ? AND employees.status = ?", departmentID, "active").
+		Find(&certifications).Error
+	return certifications, err
+}
+
+func (r *GormCertificationRepository) FindByID(id uint) (*models.Certification, error) {
+	var certification models.Certification
+	if err := first(r.db.Preload("Skill"), &certification, id); err != nil {
+		return nil, err
+	}
+	return &certification, nil
+}
+
+func (r *GormCertificationRepository) Create(certification *models.Certification) error {
+	if err := r.db.Create(certification).Error; err != nil {
+		return err
+	}
+	return r.reload(certification)
+}
+
+func (r *GormCertificationRepository) Update(certification *models.Certification, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, certification, certification.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(certification)
+}
+
+// reload refreshes certification and its skill after a write
+func (r *GormCertificationRepository) reload(certification *models.Certification) error {
+	fresh, err := r.FindByID(certification.ID)
+	if err != nil {
+		return err
+	}
+	*certification = *fresh
+	return nil
+}
+
+func (r *GormCertificationRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Certification{}, id).Error
+}
+
+func (r *GormCertificationRepository) CountBySkill(skillID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Certification{}).Where("skill_id = ?", skillID).Count(&count).Error
+	return count, err
+}
+
+func (r *GormCertificationRepository) DueWarnings(date time.Time) ([]DueCertification, error) {
+	var due []DueCertification
+	err := r.db.Model(&models.Certification{}).
+		Select("certifications.id, certifications.employee_id, "+
+			"employees.first_name AS employee_first_name, employees.last_name AS employee_last_name, "+
+			"skills.name AS skill_name, certifications.name, certifications.expires_at").
+		Joins("JOIN employees ON employees.id = certifications.employee_id AND employees.deleted_at IS NULL").
+		Joins("JOIN skills ON skills.id = certifications.skill_id").
+		Where("certifications.expires_at IS NOT NULL AND certifications.expires_at < ?", date).
+		Where("certifications.warned_at IS NULL AND employees.status <> ?", "terminated").
+		Order("certifications.expires_at").Order("certifications.id").
+		Scan(&due).Error
+	return due, err
+}
+
+func (r *GormCertificationRepository) MarkWarned(id uint, at time.Time) (bool, error) {
+	result := r.db.Model(&models.Certification{}).
+		Where("id = ? AND warned_at IS NULL", id).
+		UpdateColumn("warned_at", at)
+	return result.RowsAffected == 1, result.Error
+}
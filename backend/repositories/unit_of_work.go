@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"database/sql"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UnitOfWork runs multi-step writes atomically. fn receives repositories
+// bound to a single transaction, which commits when fn returns nil and rolls
+// back otherwise. fn may run more than once when the database asks for a
+// retry, so it must build its records afresh rather than mutate its inputs.
+type UnitOfWork interface {
+	Do(isolation sql.IsolationLevel, fn func(repos *Repositories) error) error
+}
+
+// maxTransactionAttempts bounds how often a transaction is retried after a
+// serialization failure or deadlock
+const maxTransactionAttempts = 3
+
+type GormUnitOfWork struct {
+	db        *gorm.DB
+	retryable func(err error) bool
+}
+
+// NewGormUnitOfWork returns a unit of work on db. retryable reports which
+// errors the backend raises for transactions that may succeed when re-run.
+func NewGormUnitOfWork(db *gorm.DB, retryable func(err error) bool) *GormUnitOfWork {
+	return &GormUnitOfWork{db: db, retryable: retryable}
+}
+
+func (u *GormUnitOfWork) Do(isolation sql.IsolationLevel, fn func(repos *Repositories) error) error {
+	options := &sql.TxOptions{Isolation: isolation}
+	for attempt := 1; ; attempt++ {
+		err := u.db.Transaction(func(tx *gorm.DB) error {
+			return fn(NewGormRepositories(tx))
+		}, options)
+		if err == nil || attempt == maxTransactionAttempts || u.retryable == nil || !u.retryable(err) {
+			return err
+		}
+		time.Sleep(retryDelay(attempt))
+	}
+}
+
+// retryDelay backs off exponentially with jitter so that the transactions
+// which collided do not collide again
+func retryDelay(attempt int) time.Duration {
+	base := 10 * time.Millisecond << (attempt - 1)
+	return base + time.Duration(rand.Int63n(int64(base)))
+}
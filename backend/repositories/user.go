@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"errors"
+	"hrms-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	// FindByID loads a user together with their employee record
+	FindByID(id uint) (*models.User, error)
+	// FindByEmail loads a user by login email together with their employee record
+	FindByEmail(email string) (*models.User, error)
+	List() ([]models.User, error)
+	Create(user *models.User) error
+	// Update writes columns if the user is still at user.Version and reloads it
+	Update(user *models.User, columns map[string]interface{}) error
+	Delete(id uint) error
+}
+
+type GormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{db: db}
+}
+
+func (r *GormUserRepository) FindByID(id uint) (*models.User, error) {
+	var user models.User
+	if err := first(r.db.Preload("Employee"), &user, id); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Preload("Employee").Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *GormUserRepository) List() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Find(&users).Error
+	return users, err
+}
+
+func (r *GormUserRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *GormUserRepository) Update(user *models.User, columns map[string]interface{}) error {
+	if err := updateVersioned(r.db, user, user.Version, columns); err != nil {
+		return err
+	}
+	return r.reload(user)
+}
+
+// reload refreshes user and its relations after a write
+func (r *GormUserRepository) reload(user *models.User) error {
+	var fresh models.User
+	if err := first(r.db.Preload("Employee"), &fresh, user.ID); err != nil {
+		return err
+	}
+	*user = fresh
+	return nil
+}
+
+func (r *GormUserRepository) Delete(id uint) error {
+	return r.db.Delete(&models.User{}, id).Error
+}
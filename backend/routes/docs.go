@@ -0,0 +1,403 @@
+package routes
+
+import (
+	"hrms-backend/controllers"
+	"hrms-backend/models"
+	"hrms-backend/openapi"
+)
+
+var (
+	hrRoles       = []string{"hr", "admin"}
+	managerRoles  = []string{"hr", "admin", "manager"}
+	employeeRoles = []string{"hr", "admin", "manager", "employee"}
+	adminRoles    = []string{"admin"}
+)
+
+// MessageResponse documents handlers that reply with a bare {"message": "..."}
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// HealthResponse documents the health check body
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func queryParam(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: "string"}}
+}
+
+// customFilterParam documents the custom[key]=value filters of list endpoints
+func customFilterParam(entity string) openapi.Parameter {
+	explode := true
+	return openapi.Parameter{
+		Name: "custom", In: "query", Style: "deepObject", Explode: &explode,
+		Description: "Only list " + entity + " whose custom fields hold these values, as custom[key]=value",
+		Schema:      &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{Type: "string"}},
+	}
+}
+
+// apiRoutes documents every route registered by SetupRoutes. The routes test
+// fails when a registered route is missing here, so keep the two in step.
+func apiRoutes() []openapi.Route {
+	return []openapi.Route{
+		// System
+		{Method: "GET", Path: "/health", Tag: "System", Summary: "Health check", Public: true, Response: HealthResponse{}},
+		{Method: "GET", Path: "/openapi.json", Tag: "System", Summary: "OpenAPI document", Public: true, Response: map[string]interface{}{}},
+		{Method: "GET", Path: "/docs", Tag: "System", Summary: "Interactive API documentation", Public: true, Response: "", ContentType: "text/html"},
+		{Method: "GET", Path: "/docs/assets/*filepath", Tag: "System", Summary: "Swagger UI files for the documentation page", Public: true, Response: ""},
+
+		// Authentication
+		{Method: "POST", Path: "/api/v1/auth/login", Tag: "Auth", Summary: "Log in and obtain a JWT", Public: true,
+			Request: controllers.LoginRequest{}, Response: controllers.LoginResponse{}},
+		{Method: "POST", Path: "/api/v1/auth/logout", Tag: "Auth", Summary: "Log out", Public: true, Response: MessageResponse{}},
+
+		// Users
+		{Method: "GET", Path: "/api/v1/users/me", Tag: "Users", Summary: "Get the current user",
+			Response: controllers.UserResponse{}, ETag: true},
+		{Method: "PUT", Path: "/api/v1/users/me", Tag: "Users", Summary: "Update the current user's profile",
+			Request: controllers.UpdateCurrentUserRequest{}, Response: models.User{}, ETag: true},
+		{Method: "GET", Path: "/api/v1/users/me/features", Tag: "Users", Summary: "List the feature flags that are on for the current user",
+			Roles: employeeRoles, Response: []string{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/users/", Tag: "Users", Summary: "List users", Roles: hrRoles,
+			Response: []controllers.UserResponse{}},
+		{Method: "POST", Path: "/api/v1/users/", Tag: "Users", Summary: "Create a user",
+			Description: "Only admins may create admin accounts.", Roles: hrRoles,
+			Request: controllers.CreateUserRequest{}, Response: models.User{}, Status: 201},
+		{Method: "GET", Path: "/api/v1/users/:id", Tag: "Users", Summary: "Get a user",
+			Description: "Employees and managers may only read their own account.", Roles: employeeRoles,
+			Response: models.User{}, ETag: true},
+		{Method: "PUT", Path: "/api/v1/users/:id", Tag: "Users", Summary: "Update a user",
+			Description: "Non-HR callers may only edit their own name and password. Only admins may change a role or edit an admin's account.", Roles: employeeRoles,
+			Request: controllers.UpdateUserRequest{}, Patch: true, Response: models.User{}, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/users/:id", Tag: "Users", Summary: "Patch a user",
+			Description: "Non-HR callers may only edit their own name and password. Only admins may change a role or edit an admin's account.", Roles: employeeRoles,
+			Request: controllers.UpdateUserRequest{}, Patch: true, Response: models.User{}, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/users/:id", Tag: "Users", Summary: "Delete a user",
+			Description: "Only admins may delete admin accounts.", Roles: hrRoles, Response: MessageResponse{}},
+
+		// Employees
+		{Method: "GET", Path: "/api/v1/employees/", Tag: "Employees", Summary: "List employees", Roles: employeeRoles,
+			Query: []openapi.Parameter{customFilterParam("employees")}, Response: []controllers.EmployeeResponse{}},
+		{Method: "POST", Path: "/api/v1/employees/", Tag: "Employees", Summary: "Create an employee",
+			Description: "With an account, the employee's login is created in the same transaction. " +
+				"Without an employeeCode, one is generated from EMPLOYEE_CODE_PATTERN; without a pattern it is required.",
+			Roles:   hrRoles,
+			Request: controllers.CreateEmployeeRequest{}, Response: models.Employee{}, Status: 201, ETag: true},
+		{Method: "GET", Path: "/api/v1/employees/code-check", Tag: "Employees", Summary: "List employees whose codes do not fit the code pattern",
+			Description: "Codes entered by hand or before EMPLOYEE_CODE_PATTERN was set may not fit it. Lists none when no pattern is set.",
+			Roles:       hrRoles, Response: controllers.CodeCheckResponse{}},
+		{Method: "GET", Path: "/api/v1/employees/:id", Tag: "Employees", Summary: "Get an employee", Roles: employeeRoles,
+			Response: controllers.EmployeeResponse{}, ETag: true},
+		{Method: "PUT", Path: "/api/v1/employees/:id", Tag: "Employees", Summary: "Update an employee",
+			Description: "The status cannot be changed to or from terminated; use the terminate endpoint.", Roles: hrRoles,
+			Request: controllers.UpdateEmployeeRequest{}, Patch: true, Response: models.Employee{}, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/employees/:id", Tag: "Employees", Summary: "Patch an employee",
+			Description: "The status cannot be changed to or from terminated; use the terminate endpoint.", Roles: hrRoles,
+			Request: controllers.UpdateEmployeeRequest{}, Patch: true, Response: models.Employee{}, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/employees/:id", Tag: "Employees", Summary: "Delete an employee", Roles: hrRoles,
+			Response: MessageResponse{}},
+		{Method: "GET", Path: "/api/v1/employees/:id/history", Tag: "Employees", Summary: "List an employee's employment history",
+			Description: "Job, pay and reporting changes in the order they take effect, scheduled and cancelled ones included.",
+			Roles:       hrRoles, Response: []models.EmploymentChange{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/history", Tag: "Employees", Summary: "Record an employment change",
+			Description: "Omitted fields keep their value. A change effective today or earlier updates the employee at once; " +
+				"a later one is scheduled and applied when it takes effect.",
+			Roles: hrRoles, Request: controllers.RecordEmploymentChangeRequest{}, Response: models.EmploymentChange{},
+			Envelope: true, Status: 201},
+		{Method: "DELETE", Path: "/api/v1/employees/:id/history/:changeId", Tag: "Employees", Summary: "Cancel a scheduled employment change",
+			Description: "Changes that have taken effect return 409; record a correcting change instead.",
+			Roles:       hrRoles, Envelope: true},
+		{Method: "GET", Path: "/api/v1/employees/:id/employment", Tag: "Employees", Summary: "Get an employee's job as of a date",
+			Roles:    hrRoles,
+			Query:    []openapi.Parameter{queryParam("asOf", "Date as YYYY-MM-DD; defaults to today, and future dates include scheduled changes")},
+			Response: controllers.EmploymentSnapshotResponse{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/terminate", Tag: "Employees", Summary: "Terminate an employee",
+			Description: "Records the last working day. Pending and approved leave after it is cancelled or cut short, final pay " +
+				"(prorated salary plus unused annual leave) is drafted as a payroll record and the offboarding checklist starts. " +
+				"The employee is terminated and their account deactivated once the last working day is over.",
+			Roles:   hrRoles,
+			Request: controllers.TerminateEmployeeRequest{}, Response: controllers.TerminationResponse{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/employees/:id/reports", Tag: "Org Chart", Summary: "List an employee's reports",
+			Description: "Direct reports by default; with transitive=true everyone below the employee, level by level.",
+			Roles:       employeeRoles,
+			Query:       []openapi.Parameter{queryParam("transitive", "true to include indirect reports")},
+			Response:    []controllers.OrgMemberResponse{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/employees/:id/chain", Tag: "Org Chart", Summary: "List an employee's management chain",
+			Description: "Managers from the direct manager up to the top of the chart.",
+			Roles:       employeeRoles, Response: []controllers.OrgMemberResponse{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/employees/:id/checklists", Tag: "Checklists", Summary: "Get an employee's checklist progress",
+			Description: "One entry per onboarding or offboarding checklist with its tasks. Managers may read their department's employees, employees their own.",
+			Roles:       employeeRoles, Response: []controllers.ChecklistProgressResponse{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/checklists", Tag: "Checklists", Summary: "Start an employee's checklist",
+			Description: "Creates tasks from matching templates not yet applied to the employee. Onboarding tasks are due relative to the hire date, offboarding tasks relative to today.",
+			Roles:       hrRoles, Request: controllers.StartChecklistRequest{}, Response: []models.ChecklistTask{}, Envelope: true, Status: 201},
+		{Method: "GET", Path: "/api/v1/employees/:id/documents", Tag: "Documents", Summary: "List an employee's documents",
+			Description: "Newest first. HR sees every document; managers their department's documents visible to managers; employees their own documents visible to them.",
+			Roles:       employeeRoles, Response: []models.Document{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/documents", Tag: "Documents", Summary: "Upload a document",
+			Description: "The type is detected from the contents: PDF, PNG, JPEG, WebP, plain text, Word and Excel files are accepted, " +
+				"anything else is rejected with 415. Files over DOCUMENT_MAX_SIZE are rejected with 413. " +
+				"HR may upload for any employee; employees upload their own documents, which are always visible to them.",
+			Roles:   employeeRoles,
+			Request: controllers.UploadDocumentRequest{}, RequestContentType: "multipart/form-data",
+			Response: models.Document{}, Envelope: true, Status: 201, ETag: true},
+
+		// Org chart
+		{Method: "GET", Path: "/api/v1/org-chart/", Tag: "Org Chart", Summary: "Get the org chart",
+			Description: "A tree per employee without a manager on the chart. Terminated employees are left out.",
+			Roles:       employeeRoles,
+			Query:       []openapi.Parameter{queryParam("root", "Only return the tree under this employee")},
+			Response:    []controllers.OrgChartNode{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/org-chart/export", Tag: "Org Chart", Summary: "Download the org chart",
+			Description: "format=json (default) downloads the tree as JSON; format=dot as a Graphviz digraph.",
+			Roles:       employeeRoles,
+			Query: []openapi.Parameter{
+				queryParam("format", "json or dot"),
+				queryParam("root", "Only export the tree under this employee"),
+			},
+			Response: "", ContentType: "text/vnd.graphviz"},
+
+		// Checklists
+		{Method: "GET", Path: "/api/v1/checklists/templates/", Tag: "Checklists", Summary: "List checklist templates", Roles: hrRoles,
+			Query:    []openapi.Parameter{queryParam("kind", "onboarding or offboarding")},
+			Response: []models.ChecklistTemplate{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/checklists/templates/", Tag: "Checklists", Summary: "Create a checklist template",
+			Description: "Applies to employees hired or terminated from now on. A template without a department or position applies to everyone.",
+			Roles:       hrRoles,
+			Request:     controllers.ChecklistTemplateRequest{}, Response: models.ChecklistTemplate{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "GET", Path: "/api/v1/checklists/templates/:id", Tag: "Checklists", Summary: "Get a checklist template", Roles: hrRoles,
+			Response: models.ChecklistTemplate{}, Envelope: true, ETag: true},
+		{Method: "PUT", Path: "/api/v1/checklists/templates/:id", Tag: "Checklists", Summary: "Update a checklist template",
+			Description: "The tasks replace the template's tasks. Tasks already created from the template are not changed.", Roles: hrRoles,
+			Request: controllers.ChecklistTemplateRequest{}, Patch: true, Response: models.ChecklistTemplate{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/checklists/templates/:id", Tag: "Checklists", Summary: "Patch a checklist template",
+			Description: "The tasks replace the template's tasks. Tasks already created from the template are not changed.", Roles: hrRoles,
+			Request: controllers.ChecklistTemplateRequest{}, Patch: true, Response: models.ChecklistTemplate{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/checklists/templates/:id", Tag: "Checklists", Summary: "Delete a checklist template",
+			Description: "Tasks already created from the template are kept.", Roles: hrRoles, Envelope: true},
+		{Method: "GET", Path: "/api/v1/checklists/tasks/", Tag: "Checklists", Summary: "List checklist tasks",
+			Description: "HR sees every task; everyone else the tasks assigned to them.",
+			Roles:       employeeRoles,
+			Query: []openapi.Parameter{
+				queryParam("employeeId", "Only tasks for this employee"),
+				queryParam("status", "pending, done or skipped"),
+			},
+			Response: []models.ChecklistTask{}, Envelope: true},
+		{Method: "PATCH", Path: "/api/v1/checklists/tasks/:id", Tag: "Checklists", Summary: "Complete, skip or reopen a checklist task",
+			Description: "Open to the assignee and HR. Only HR may change the due date.", Roles: employeeRoles,
+			Request: controllers.UpdateChecklistTaskRequest{}, Patch: true, Response: models.ChecklistTask{}, Envelope: true, ETag: true},
+		{Method: "GET", Path: "/api/v1/checklists/overdue", Tag: "Checklists", Summary: "List overdue checklist tasks",
+			Description: "Pending tasks past their due date, oldest first. Managers see their department's employees.",
+			Roles:       managerRoles, Response: []controllers.OverdueChecklistTaskResponse{}, Envelope: true},
+
+		// Personal details
+		{Method: "GET", Path: "/api/v1/employees/:id/emergency-contacts", Tag: "Personal Details", Summary: "List an employee's emergency contacts",
+			Description: "In priority order. Open to HR, the employee and managers the employee reports to, directly or through others.",
+			Roles:       employeeRoles, Response: []models.EmergencyContact{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/emergency-contacts", Tag: "Personal Details", Summary: "Add an emergency contact",
+			Description: "Employees add their own contacts; HR may add anyone's. Without a priority the contact is added last.",
+			Roles:       employeeRoles,
+			Request:     controllers.EmergencyContactRequest{}, Response: models.EmergencyContact{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/employees/:id/emergency-contacts/:contactId", Tag: "Personal Details", Summary: "Patch an emergency contact",
+			Description: "Employees edit their own contacts; HR may edit anyone's.", Roles: employeeRoles,
+			Request: controllers.EmergencyContactRequest{}, Patch: true, Response: models.EmergencyContact{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/employees/:id/emergency-contacts/:contactId", Tag: "Personal Details", Summary: "Delete an emergency contact",
+			Description: "Employees delete their own contacts; HR may delete anyone's.", Roles: employeeRoles, Envelope: true},
+		{Method: "GET", Path: "/api/v1/employees/:id/dependents", Tag: "Personal Details", Summary: "List an employee's dependents",
+			Description: "Oldest first. Open to HR and the employee only.",
+			Roles:       employeeRoles, Response: []models.Dependent{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/dependents", Tag: "Personal Details", Summary: "Add a dependent",
+			Description: "Employees add their own dependents; HR may add anyone's.",
+			Roles:       employeeRoles,
+			Request:     controllers.DependentRequest{}, Response: models.Dependent{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/employees/:id/dependents/:dependentId", Tag: "Personal Details", Summary: "Patch a dependent",
+			Description: "Employees edit their own dependents; HR may edit anyone's.", Roles: employeeRoles,
+			Request: controllers.DependentRequest{}, Patch: true, Response: models.Dependent{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/employees/:id/dependents/:dependentId", Tag: "Personal Details", Summary: "Delete a dependent",
+			Description: "Employees delete their own dependents; HR may delete anyone's.", Roles: employeeRoles, Envelope: true},
+
+		// Certifications
+		{Method: "GET", Path: "/api/v1/employees/:id/certifications", Tag: "Skills", Summary: "List an employee's certifications",
+			Description: "Soonest expiry first, those that never expire last. Open to HR, the employee and managers the employee reports to, directly or through others.",
+			Roles:       employeeRoles, Response: []models.Certification{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/employees/:id/certifications", Tag: "Skills", Summary: "Record a certification",
+			Description: "documentId must be one of the employee's documents. Without expiresAt the certification never expires.",
+			Roles:       hrRoles,
+			Request:     controllers.CertificationRequest{}, Response: models.Certification{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/employees/:id/certifications/:certificationId", Tag: "Skills", Summary: "Patch a certification",
+			Description: "A new expiry date, such as after a renewal, is warned about again.", Roles: hrRoles,
+			Request: controllers.CertificationRequest{}, Patch: true, Response: models.Certification{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/employees/:id/certifications/:certificationId", Tag: "Skills", Summary: "Delete a certification",
+			Roles: hrRoles, Envelope: true},
+
+		// Documents
+		{Method: "GET", Path: "/api/v1/documents/expiring", Tag: "Documents", Summary: "List expiring documents",
+			Description: "Documents that have expired or expire within the given number of days, soonest first. Managers see their department's documents visible to managers.",
+			Roles:       managerRoles,
+			Query:       []openapi.Parameter{queryParam("days", "Look-ahead in days, 30 by default")},
+			Response:    []controllers.ExpiringDocumentResponse{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/documents/:id", Tag: "Documents", Summary: "Get a document's details",
+			Description: "Documents the caller may not see are reported as not found.",
+			Roles:       employeeRoles, Response: models.Document{}, Envelope: true, ETag: true},
+		{Method: "GET", Path: "/api/v1/documents/:id/download", Tag: "Documents", Summary: "Download a document",
+			Description: "The contents as an attachment, with the type detected at upload.",
+			Roles:       employeeRoles, Response: "", ContentType: "application/octet-stream"},
+		{Method: "PATCH", Path: "/api/v1/documents/:id", Tag: "Documents", Summary: "Patch a document's details",
+			Description: "The contents cannot be replaced; upload a new document instead.", Roles: hrRoles,
+			Request: controllers.UpdateDocumentRequest{}, Patch: true, Response: models.Document{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/documents/:id", Tag: "Documents", Summary: "Delete a document",
+			Description: "HR may delete any document; others the documents they uploaded.", Roles: employeeRoles, Envelope: true},
+
+		// Departments
+		{Method: "GET", Path: "/api/v1/departments/", Tag: "Departments", Summary: "List departments", Roles: employeeRoles,
+			Query: []openapi.Parameter{customFilterParam("departments")}, Response: []controllers.DepartmentResponse{}},
+		{Method: "POST", Path: "/api/v1/departments/", Tag: "Departments", Summary: "Create a department", Roles: hrRoles,
+			Request: controllers.CreateDepartmentRequest{}, Response: models.Department{}, Status: 201},
+		{Method: "GET", Path: "/api/v1/departments/:id", Tag: "Departments", Summary: "Get a department", Roles: employeeRoles,
+			Response: controllers.DepartmentResponse{}, ETag: true},
+		{Method: "PUT", Path: "/api/v1/departments/:id", Tag: "Departments", Summary: "Update a department", Roles: hrRoles,
+			Request: controllers.UpdateDepartmentRequest{}, Patch: true, Response: models.Department{}, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/departments/:id", Tag: "Departments", Summary: "Patch a department", Roles: hrRoles,
+			Request: controllers.UpdateDepartmentRequest{}, Patch: true, Response: models.Department{}, ETag: true},
+		{Method: "GET", Path: "/api/v1/departments/:id/skill-matrix", Tag: "Skills", Summary: "Get a department's skill matrix",
+			Description: "Every skill in the catalog against the department's active employees, with each employee's longest-lasting " +
+				"certification per skill: valid, expiring within CERTIFICATION_WARNING_DAYS or expired. Managers see their own department.",
+			Roles: managerRoles, Response: controllers.SkillMatrixResponse{}, Envelope: true},
+		{Method: "DELETE", Path: "/api/v1/departments/:id", Tag: "Departments", Summary: "Delete a department",
+			Description: "A department that still has employees returns 409 unless reassignTo names the department to move them to.",
+			Roles:       hrRoles,
+			Query:       []openapi.Parameter{queryParam("reassignTo", "Department to move the remaining employees to")},
+			Response:    MessageResponse{}},
+
+		// Attendance
+		{Method: "GET", Path: "/api/v1/attendance/", Tag: "Attendance", Summary: "List attendance records",
+			Description: "HR sees all records, managers their department and employees their own.", Roles: employeeRoles,
+			Response: []controllers.AttendanceResponse{}},
+		{Method: "POST", Path: "/api/v1/attendance/", Tag: "Attendance", Summary: "Log attendance",
+			Description: "Employees always log their own attendance; HR may log for anyone.", Roles: employeeRoles,
+			Request: controllers.CreateAttendanceRequest{}, Response: models.Attendance{}, Envelope: true, Status: 201},
+		{Method: "GET", Path: "/api/v1/attendance/report", Tag: "Attendance", Summary: "Department attendance report", Roles: managerRoles,
+			Response: []controllers.AttendanceReportEntry{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/attendance/:id", Tag: "Attendance", Summary: "Get an attendance record",
+			Description: "HR sees any record, managers their department's and employees their own.", Roles: employeeRoles,
+			Response: models.Attendance{}, Envelope: true, ETag: true},
+		{Method: "PUT", Path: "/api/v1/attendance/:id", Tag: "Attendance", Summary: "Update an attendance record", Roles: hrRoles,
+			Request: controllers.UpdateAttendanceRequest{}, Patch: true, Response: models.Attendance{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/attendance/:id", Tag: "Attendance", Summary: "Patch an attendance record", Roles: hrRoles,
+			Request: controllers.UpdateAttendanceRequest{}, Patch: true, Response: models.Attendance{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/attendance/:id", Tag: "Attendance", Summary: "Delete an attendance record", Roles: hrRoles,
+			Envelope: true},
+
+		// Leave
+		{Method: "GET", Path: "/api/v1/leaves/", Tag: "Leave", Summary: "List leave requests",
+			Description: "HR sees all requests, managers their department and employees their own.", Roles: employeeRoles,
+			Response: []controllers.LeaveResponse{}},
+		{Method: "POST", Path: "/api/v1/leaves/", Tag: "Leave", Summary: "Apply for leave",
+			Description: "Employees always apply for themselves; HR may apply on behalf of anyone.", Roles: employeeRoles,
+			Request: controllers.CreateLeaveRequestPayload{}, Response: models.LeaveRequest{}, Envelope: true, Status: 201},
+		{Method: "GET", Path: "/api/v1/leaves/:id", Tag: "Leave", Summary: "Get a leave request",
+			Description: "HR sees any request, managers their department's and employees their own.", Roles: employeeRoles,
+			Response: models.LeaveRequest{}, Envelope: true, ETag: true},
+		{Method: "PUT", Path: "/api/v1/leaves/:id", Tag: "Leave", Summary: "Update a leave request",
+			Description: "Employees may only edit their own pending requests.", Roles: employeeRoles,
+			Request: controllers.UpdateLeaveRequestPayload{}, Patch: true, Response: models.LeaveRequest{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/leaves/:id", Tag: "Leave", Summary: "Patch a leave request",
+			Description: "Employees may only edit their own pending requests.", Roles: employeeRoles,
+			Request: controllers.UpdateLeaveRequestPayload{}, Patch: true, Response: models.LeaveRequest{}, Envelope: true, ETag: true},
+		{Method: "POST", Path: "/api/v1/leaves/:id/approve", Tag: "Leave", Summary: "Approve or reject a leave request",
+			Description: "Only pending requests can be decided; a concurrent decision returns 409. " +
+				"While the manager_leave_approval flag is on for them, managers may decide requests from their own department.",
+			Roles:   managerRoles,
+			Request: controllers.ApproveLeaveRequestPayload{}, Response: models.LeaveRequest{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/leaves/:id", Tag: "Leave", Summary: "Delete a leave request",
+			Description: "Employees may only delete their own pending requests.", Roles: employeeRoles,
+			Envelope: true},
+
+		// Payroll
+		{Method: "GET", Path: "/api/v1/payroll/", Tag: "Payroll", Summary: "List payroll records",
+			Description: "HR sees all records, managers their department and employees their own.", Roles: employeeRoles,
+			Response: []models.PayrollRecord{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/payroll/", Tag: "Payroll", Summary: "Create a payroll record", Roles: hrRoles,
+			Request: controllers.CreatePayrollRecordRequest{}, Response: models.PayrollRecord{}, Envelope: true, Status: 201},
+		{Method: "GET", Path: "/api/v1/payroll/download", Tag: "Payroll", Summary: "Payroll report", Roles: hrRoles,
+			Query: []openapi.Parameter{
+				queryParam("month", "Month of the pay period start (1-12); requires year"),
+				queryParam("year", "Year of the pay period start; requires month"),
+				queryParam("department_id", "Only include employees of this department"),
+			},
+			Response: []models.PayrollRecord{}, Envelope: true},
+		{Method: "GET", Path: "/api/v1/payroll/:id", Tag: "Payroll", Summary: "Get a payroll record",
+			Description: "HR sees any record, managers their department's and employees their own.", Roles: employeeRoles,
+			Response: models.PayrollRecord{}, Envelope: true, ETag: true},
+		{Method: "PUT", Path: "/api/v1/payroll/:id", Tag: "Payroll", Summary: "Update a payroll record", Roles: hrRoles,
+			Request: controllers.UpdatePayrollRecordRequest{}, Patch: true, Response: models.PayrollRecord{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/payroll/:id", Tag: "Payroll", Summary: "Patch a payroll record", Roles: hrRoles,
+			Request: controllers.UpdatePayrollRecordRequest{}, Patch: true, Response: models.PayrollRecord{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/payroll/:id", Tag: "Payroll", Summary: "Delete a payroll record", Roles: hrRoles,
+			Envelope: true},
+
+		// Feature flags
+		{Method: "GET", Path: "/api/v1/feature-flags/", Tag: "Feature Flags", Summary: "List feature flags", Roles: adminRoles,
+			Response: []models.FeatureFlag{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/feature-flags/", Tag: "Feature Flags", Summary: "Create a feature flag",
+			Description: "An enabled flag without roles, departments or users is on for everyone.", Roles: adminRoles,
+			Request: controllers.CreateFeatureFlagRequest{}, Response: models.FeatureFlag{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "GET", Path: "/api/v1/feature-flags/:key", Tag: "Feature Flags", Summary: "Get a feature flag", Roles: adminRoles,
+			Response: models.FeatureFlag{}, Envelope: true, ETag: true},
+		{Method: "PUT", Path: "/api/v1/feature-flags/:key", Tag: "Feature Flags", Summary: "Update a feature flag",
+			Description: "Changes apply to the next request without a restart.", Roles: adminRoles,
+			Request: controllers.UpdateFeatureFlagRequest{}, Patch: true, Response: models.FeatureFlag{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/feature-flags/:key", Tag: "Feature Flags", Summary: "Patch a feature flag",
+			Description: "Changes apply to the next request without a restart.", Roles: adminRoles,
+			Request: controllers.UpdateFeatureFlagRequest{}, Patch: true, Response: models.FeatureFlag{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/feature-flags/:key", Tag: "Feature Flags", Summary: "Delete a feature flag", Roles: adminRoles,
+			Envelope: true},
+
+		// Custom fields
+		{Method: "GET", Path: "/api/v1/custom-fields/", Tag: "Custom Fields", Summary: "List custom fields", Roles: employeeRoles,
+			Query:    []openapi.Parameter{queryParam("entity", "employee or department; every entity by default")},
+			Response: []models.CustomField{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/custom-fields/", Tag: "Custom Fields", Summary: "Define a custom field",
+			Description: "maxLength and pattern apply to text fields, min and max to number fields and options to enum fields.",
+			Roles:       adminRoles,
+			Request:     controllers.CreateCustomFieldRequest{}, Response: models.CustomField{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "GET", Path: "/api/v1/custom-fields/:id", Tag: "Custom Fields", Summary: "Get a custom field", Roles: employeeRoles,
+			Response: models.CustomField{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/custom-fields/:id", Tag: "Custom Fields", Summary: "Patch a custom field",
+			Description: "Stored values are checked against the new rules when their record is next saved.", Roles: adminRoles,
+			Request: controllers.UpdateCustomFieldRequest{}, Patch: true, Response: models.CustomField{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/custom-fields/:id", Tag: "Custom Fields", Summary: "Delete a custom field",
+			Description: "Also removes the field's value from every record.", Roles: adminRoles, Envelope: true},
+
+		// Skills
+		{Method: "GET", Path: "/api/v1/skills/", Tag: "Skills", Summary: "List the skills catalog", Roles: employeeRoles,
+			Response: []models.Skill{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/skills/", Tag: "Skills", Summary: "Add a skill", Roles: hrRoles,
+			Request: controllers.SkillRequest{}, Response: models.Skill{}, Envelope: true, Status: 201, ETag: true},
+		{Method: "GET", Path: "/api/v1/skills/:id", Tag: "Skills", Summary: "Get a skill", Roles: employeeRoles,
+			Response: models.Skill{}, Envelope: true, ETag: true},
+		{Method: "PATCH", Path: "/api/v1/skills/:id", Tag: "Skills", Summary: "Patch a skill", Roles: hrRoles,
+			Request: controllers.SkillRequest{}, Patch: true, Response: models.Skill{}, Envelope: true, ETag: true},
+		{Method: "DELETE", Path: "/api/v1/skills/:id", Tag: "Skills", Summary: "Delete a skill",
+			Description: "A skill employees hold certifications for returns 409.", Roles: hrRoles, Envelope: true},
+
+		// Notifications
+		{Method: "GET", Path: "/api/v1/notifications/", Tag: "Notifications", Summary: "List your notifications",
+			Description: "Newest first, such as warnings that a certification expires soon.", Roles: employeeRoles,
+			Query:    []openapi.Parameter{queryParam("unread", "true to list unread notifications only")},
+			Response: []models.Notification{}, Envelope: true},
+		{Method: "POST", Path: "/api/v1/notifications/:id/read", Tag: "Notifications", Summary: "Mark a notification read",
+			Roles: employeeRoles, Response: models.Notification{}, Envelope: true},
+	}
+}
+
+// OpenAPIDocument describes the HRMS API as an OpenAPI 3.1 document
+func OpenAPIDocument() *openapi.Document {
+	return openapi.Build(openapi.Info{
+		Title:       "HRMS API",
+		Version:     "1.0.0",
+		Description: "Human resource management API for employees, departments, attendance, leave and payroll.",
+	}, apiRoutes())
+}
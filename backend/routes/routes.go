@@ -1,28 +1,64 @@
 package routes
 
 import (
+	"hrms-backend/config"
 	"hrms-backend/controllers"
+	"hrms-backend/database"
 	"hrms-backend/middleware"
+	"hrms-backend/openapi"
+	"hrms-backend/repositories"
+	"hrms-backend/services"
+	"hrms-backend/storage"
+	"hrms-backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB) {
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repos := repositories.NewGormRepositories(db)
+	var uow repositories.UnitOfWork
+	// db is nil when the routes are only listed, as in the OpenAPI test
+	if db != nil {
+		uow = repositories.NewGormUnitOfWork(db, database.Retryable(db))
+	}
+
+	// Feature flags are cached per server, so every consumer shares one service
+	features := services.NewFeatureService(repos, cfg.FeatureFlagRefresh)
+
+	authService := services.NewAuthService(repos, cfg.JWTSecret, cfg.JWTExpiresIn)
+
 	// Initialize controllers
-	authController := controllers.NewAuthController(db)
-	userController := controllers.NewUserController(db)
-	employeeController := controllers.NewEmployeeController(db)
-	departmentController := controllers.NewDepartmentController(db)
-	attendanceController := controllers.NewAttendanceController(db)
-	leaveController := controllers.NewLeaveController(db)
-	payrollController := controllers.NewPayrollController(db)
+	authController := controllers.NewAuthController(authService)
+	userController := controllers.NewUserController(services.NewUserService(repos))
+	employeeController := controllers.NewEmployeeController(services.NewEmployeeService(repos, uow, utils.MustParseCodePattern(cfg.EmployeeCodePattern)))
+	employmentController := controllers.NewEmploymentController(services.NewEmploymentService(repos, uow))
+	terminationController := controllers.NewTerminationController(services.NewTerminationService(repos, uow, cfg.AnnualLeaveDays))
+	orgChartController := controllers.NewOrgChartController(services.NewOrgChartService(repos))
+	checklistController := controllers.NewChecklistController(services.NewChecklistService(repos, uow))
+	personalController := controllers.NewPersonalController(services.NewPersonalService(repos))
+	documentController := controllers.NewDocumentController(services.NewDocumentService(repos, storage.New(cfg), int64(cfg.DocumentMaxSize)))
+	departmentController := controllers.NewDepartmentController(services.NewDepartmentService(repos, uow))
+	attendanceController := controllers.NewAttendanceController(services.NewAttendanceService(repos))
+	leaveController := controllers.NewLeaveController(services.NewLeaveService(repos, uow, features))
+	payrollController := controllers.NewPayrollController(services.NewPayrollService(repos, uow))
+	featureFlagController := controllers.NewFeatureFlagController(features)
+	customFieldController := controllers.NewCustomFieldController(services.NewCustomFieldService(repos, uow))
+	skillController := controllers.NewSkillController(services.NewSkillService(repos))
+	certificationController := controllers.NewCertificationController(services.NewCertificationService(repos, uow, cfg.ExpiryWarningDays))
+	notificationController := controllers.NewNotificationController(services.NewNotificationService(repos))
+	idempotencyStore := middleware.NewGormIdempotencyStore(db)
 
 	// Health check route
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "message": "HRMS API is running"})
 	})
 
+	// API documentation
+	router.GET("/openapi.json", openapi.SpecHandler(OpenAPIDocument()))
+	router.GET("/docs", openapi.DocsHandler())
+	router.GET("/docs/assets/*filepath", openapi.AssetsHandler())
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 
@@ -34,19 +70,23 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	}
 
 	// Protected routes (authentication required)
+	// PUT and PATCH on a resource both take a JSON Merge Patch (RFC 7396) body
+	// and every POST accepts an Idempotency-Key header for safe retries
 	protected := v1.Group("/")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret, authService), middleware.Idempotency(idempotencyStore, cfg.IdempotencyTTL, int64(cfg.IdempotencyMaxBody)))
 	{
 		// User routes - Different access levels
 		users := protected.Group("/users")
 		{
 			users.GET("/me", userController.GetCurrentUser)
+			users.GET("/me/features", featureFlagController.GetMyFeatures)
 			users.PUT("/me", userController.UpdateCurrentUser)
-			users.GET("/", middleware.RequireHR(), userController.GetUsers)            // HR only
-			users.POST("/", middleware.RequireHR(), userController.CreateUser)         // HR only
-			users.GET("/:id", middleware.RequireSelfOrHR(), userController.GetUser)    // Self or HR
-			users.PUT("/:id", middleware.RequireSelfOrHR(), userController.UpdateUser) // Self or HR
-			users.DELETE("/:id", middleware.RequireHR(), userController.DeleteUser)    // HR only
+			users.GET("/", middleware.RequireHR(), userController.GetUsers)              // HR only
+			users.POST("/", middleware.RequireHR(), userController.CreateUser)           // HR only
+			users.GET("/:id", middleware.RequireSelfOrHR(), userController.GetUser)      // Self or HR
+			users.PUT("/:id", middleware.RequireSelfOrHR(), userController.UpdateUser)   // Self or HR
+			users.PATCH("/:id", middleware.RequireSelfOrHR(), userController.UpdateUser) // Self or HR
+			users.DELETE("/:id", middleware.RequireHR(), userController.DeleteUser)      // HR only
 		}
 
 		// Employee routes - HR can manage all, Managers can view department, Employees can view own
@@ -56,7 +96,78 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			employees.POST("/", middleware.RequireHR(), employeeController.CreateEmployee)      // HR only
 			employees.GET("/:id", middleware.RequireEmployee(), employeeController.GetEmployee) // All roles with access control
 			employees.PUT("/:id", middleware.RequireHR(), employeeController.UpdateEmployee)    // HR only
+			employees.PATCH("/:id", middleware.RequireHR(), employeeController.UpdateEmployee)  // HR only
 			employees.DELETE("/:id", middleware.RequireHR(), employeeController.DeleteEmployee) // HR only
+
+			// Employee codes - HR only; codes that do not fit EMPLOYEE_CODE_PATTERN
+			employees.GET("/code-check", middleware.RequireHR(), employeeController.GetCodeCheck)
+
+			// Employment history - HR only; job, pay and reporting changes with effective dates
+			employees.GET("/:id/history", middleware.RequireHR(), employmentController.GetEmploymentHistory)
+			employees.POST("/:id/history", middleware.RequireHR(), employmentController.RecordEmploymentChange)
+			employees.DELETE("/:id/history/:changeId", middleware.RequireHR(), employmentController.CancelEmploymentChange)
+			employees.GET("/:id/employment", middleware.RequireHR(), employmentController.GetEmploymentAsOf)
+			employees.POST("/:id/terminate", middleware.RequireHR(), terminationController.TerminateEmployee)
+
+			// Reporting lines - All roles; names and positions only
+			employees.GET("/:id/reports", middleware.RequireEmployee(), orgChartController.GetReports)
+			employees.GET("/:id/chain", middleware.RequireEmployee(), orgChartController.GetChain)
+
+			// Checklists - Progress for HR, the employee's managers and the employee; HR starts them
+			employees.GET("/:id/checklists", middleware.RequireEmployee(), checklistController.GetEmployeeChecklists)
+			employees.POST("/:id/checklists", middleware.RequireHR(), checklistController.StartChecklist)
+
+			// Documents - Visibility set per document; HR uploads for anyone, employees for themselves
+			employees.GET("/:id/documents", middleware.RequireEmployee(), documentController.GetEmployeeDocuments)
+			employees.POST("/:id/documents", middleware.RequireEmployee(), documentController.UploadDocument)
+
+			// Personal details - Employees manage their own, HR everyone's; managers read their reports' emergency contacts
+			employees.GET("/:id/emergency-contacts", middleware.RequireEmployee(), personalController.GetEmergencyContacts)
+			employees.POST("/:id/emergency-contacts", middleware.RequireEmployee(), personalController.CreateEmergencyContact)
+			employees.PATCH("/:id/emergency-contacts/:contactId", middleware.RequireEmployee(), personalController.UpdateEmergencyContact)
+			employees.DELETE("/:id/emergency-contacts/:contactId", middleware.RequireEmployee(), personalController.DeleteEmergencyContact)
+			employees.GET("/:id/dependents", middleware.RequireEmployee(), personalController.GetDependents)
+			employees.POST("/:id/dependents", middleware.RequireEmployee(), personalController.CreateDependent)
+			employees.PATCH("/:id/dependents/:dependentId", middleware.RequireEmployee(), personalController.UpdateDependent)
+			employees.DELETE("/:id/dependents/:dependentId", middleware.RequireEmployee(), personalController.DeleteDependent)
+
+			// Certifications - HR keeps them; employees and the managers they report to can read them
+			employees.GET("/:id/certifications", middleware.RequireEmployee(), certificationController.GetCertifications)
+			employees.POST("/:id/certifications", middleware.RequireHR(), certificationController.CreateCertification)
+			employees.PATCH("/:id/certifications/:certificationId", middleware.RequireHR(), certificationController.UpdateCertification)
+			employees.DELETE("/:id/certifications/:certificationId", middleware.RequireHR(), certificationController.DeleteCertification)
+		}
+
+		// Document routes - Access follows each document's visibility
+		documents := protected.Group("/documents")
+		{
+			documents.GET("/expiring", middleware.RequireManager(), documentController.GetExpiringDocuments) // Managers see their department
+			documents.GET("/:id", middleware.RequireEmployee(), documentController.GetDocument)
+			documents.GET("/:id/download", middleware.RequireEmployee(), documentController.DownloadDocument)
+			documents.PATCH("/:id", middleware.RequireHR(), documentController.UpdateDocument)        // HR only
+			documents.DELETE("/:id", middleware.RequireEmployee(), documentController.DeleteDocument) // Uploader or HR
+		}
+
+		// Checklist routes - HR manages templates, assignees work through their tasks
+		checklists := protected.Group("/checklists")
+		{
+			checklists.GET("/templates/", middleware.RequireHR(), checklistController.GetChecklistTemplates)
+			checklists.POST("/templates/", middleware.RequireHR(), checklistController.CreateChecklistTemplate)
+			checklists.GET("/templates/:id", middleware.RequireHR(), checklistController.GetChecklistTemplate)
+			checklists.PUT("/templates/:id", middleware.RequireHR(), checklistController.UpdateChecklistTemplate)
+			checklists.PATCH("/templates/:id", middleware.RequireHR(), checklistController.UpdateChecklistTemplate)
+			checklists.DELETE("/templates/:id", middleware.RequireHR(), checklistController.DeleteChecklistTemplate)
+			checklists.GET("/tasks/", middleware.RequireEmployee(), checklistController.GetChecklistTasks)        // HR sees all, others their own
+			checklists.PATCH("/tasks/:id", middleware.RequireEmployee(), checklistController.UpdateChecklistTask) // Assignee or HR
+			checklists.GET("/overdue", middleware.RequireManager(), checklistController.GetOverdueChecklistTasks) // Managers see their department
+		}
+
+		// Org chart routes - All roles; built from employees' reporting lines
+		orgChart := protected.Group("/org-chart")
+		orgChart.Use(middleware.RequireEmployee())
+		{
+			orgChart.GET("/", orgChartController.GetOrgChart)
+			orgChart.GET("/export", orgChartController.ExportOrgChart)
 		}
 
 		// Department routes - HR can manage all, others can view
@@ -66,7 +177,11 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			departments.POST("/", middleware.RequireHR(), departmentController.CreateDepartment)      // HR only
 			departments.GET("/:id", middleware.RequireEmployee(), departmentController.GetDepartment) // All can view
 			departments.PUT("/:id", middleware.RequireHR(), departmentController.UpdateDepartment)    // HR only
+			departments.PATCH("/:id", middleware.RequireHR(), departmentController.UpdateDepartment)  // HR only
 			departments.DELETE("/:id", middleware.RequireHR(), departmentController.DeleteDepartment) // HR only
+
+			// Skill matrix - HR for any department, managers for their own
+			departments.GET("/:id/skill-matrix", middleware.RequireManager(), certificationController.GetSkillMatrix)
 		}
 
 		// Attendance routes - Role-based access within controller
@@ -75,18 +190,22 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			attendance.GET("/", middleware.RequireEmployee(), attendanceController.GetAttendance)                      // All roles with filtered data
 			attendance.POST("/", middleware.RequireEmployee(), attendanceController.CreateAttendance)                  // Employees log own, HR can create any
 			attendance.GET("/report", middleware.RequireManager(), attendanceController.GetDepartmentAttendanceReport) // Managers get department report
+			attendance.GET("/:id", middleware.RequireEmployee(), attendanceController.GetAttendanceRecord)             // Own records for employees, department for managers
 			attendance.PUT("/:id", middleware.RequireHR(), attendanceController.UpdateAttendance)                      // HR only
+			attendance.PATCH("/:id", middleware.RequireHR(), attendanceController.UpdateAttendance)                    // HR only
 			attendance.DELETE("/:id", middleware.RequireHR(), attendanceController.DeleteAttendance)                   // HR only
 		}
 
 		// Leave routes - Role-based access within controller
 		leaves := protected.Group("/leaves")
 		{
-			leaves.GET("/", middleware.RequireEmployee(), leaveController.GetLeaveRequests)          // All roles with filtered data
-			leaves.POST("/", middleware.RequireEmployee(), leaveController.CreateLeaveRequest)       // Employees create own, HR can create any
-			leaves.PUT("/:id", middleware.RequireEmployee(), leaveController.UpdateLeaveRequest)     // Own requests for employees, any for HR
-			leaves.POST("/:id/approve", middleware.RequireHR(), leaveController.ApproveLeaveRequest) // HR only
-			leaves.DELETE("/:id", middleware.RequireEmployee(), leaveController.DeleteLeaveRequest)  // Own pending requests for employees, any for HR
+			leaves.GET("/", middleware.RequireEmployee(), leaveController.GetLeaveRequests)               // All roles with filtered data
+			leaves.POST("/", middleware.RequireEmployee(), leaveController.CreateLeaveRequest)            // Employees create own, HR can create any
+			leaves.GET("/:id", middleware.RequireEmployee(), leaveController.GetLeaveRequest)             // Own requests for employees, department for managers
+			leaves.PUT("/:id", middleware.RequireEmployee(), leaveController.UpdateLeaveRequest)          // Own requests for employees, any for HR
+			leaves.PATCH("/:id", middleware.RequireEmployee(), leaveController.UpdateLeaveRequest)        // Own requests for employees, any for HR
+			leaves.POST("/:id/approve", middleware.RequireManager(), leaveController.ApproveLeaveRequest) // HR, or managers with manager_leave_approval
+			leaves.DELETE("/:id", middleware.RequireEmployee(), leaveController.DeleteLeaveRequest)       // Own pending requests for employees, any for HR
 		}
 
 		// Payroll routes - HR manages all, Employees see own
@@ -95,8 +214,49 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			payroll.GET("/", middleware.RequireEmployee(), payrollController.GetPayrollRecords)       // All roles with filtered data
 			payroll.POST("/", middleware.RequireHR(), payrollController.CreatePayrollRecord)          // HR only
 			payroll.GET("/download", middleware.RequireHR(), payrollController.DownloadPayrollReport) // HR only
+			payroll.GET("/:id", middleware.RequireEmployee(), payrollController.GetPayrollRecord)     // Own records for employees, department for managers
 			payroll.PUT("/:id", middleware.RequireHR(), payrollController.UpdatePayrollRecord)        // HR only
+			payroll.PATCH("/:id", middleware.RequireHR(), payrollController.UpdatePayrollRecord)      // HR only
 			payroll.DELETE("/:id", middleware.RequireHR(), payrollController.DeletePayrollRecord)     // HR only
 		}
+
+		// Feature flag routes - Admin only; changes apply without a restart
+		featureFlags := protected.Group("/feature-flags")
+		featureFlags.Use(middleware.RequireAdmin())
+		{
+			featureFlags.GET("/", featureFlagController.GetFeatureFlags)
+			featureFlags.POST("/", featureFlagController.CreateFeatureFlag)
+			featureFlags.GET("/:key", featureFlagController.GetFeatureFlag)
+			featureFlags.PUT("/:key", featureFlagController.UpdateFeatureFlag)
+			featureFlags.PATCH("/:key", featureFlagController.UpdateFeatureFlag)
+			featureFlags.DELETE("/:key", featureFlagController.DeleteFeatureFlag)
+		}
+
+		// Custom field routes - All roles read the definitions; admins manage them
+		customFields := protected.Group("/custom-fields")
+		{
+			customFields.GET("/", middleware.RequireEmployee(), customFieldController.GetCustomFields)
+			customFields.POST("/", middleware.RequireAdmin(), customFieldController.CreateCustomField)
+			customFields.GET("/:id", middleware.RequireEmployee(), customFieldController.GetCustomField)
+			customFields.PATCH("/:id", middleware.RequireAdmin(), customFieldController.UpdateCustomField)
+			customFields.DELETE("/:id", middleware.RequireAdmin(), customFieldController.DeleteCustomField)
+		}
+
+		// Skills catalog - All roles can view, HR maintains it
+		skills := protected.Group("/skills")
+		{
+			skills.GET("/", middleware.RequireEmployee(), skillController.GetSkills)
+			skills.POST("/", middleware.RequireHR(), skillController.CreateSkill)
+			skills.GET("/:id", middleware.RequireEmployee(), skillController.GetSkill)
+			skills.PATCH("/:id", middleware.RequireHR(), skillController.UpdateSkill)
+			skills.DELETE("/:id", middleware.RequireHR(), skillController.DeleteSkill)
+		}
+
+		// Notifications - Every user reads their own
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("/", middleware.RequireEmployee(), notificationController.GetNotifications)
+			notifications.POST("/:id/read", middleware.RequireEmployee(), notificationController.MarkNotificationRead)
+		}
 	}
 }
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"hrms-backend/config"
+	"hrms-backend/openapi"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router, nil, &config.Config{})
+
+	doc := OpenAPIDocument()
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		path, _ := openapi.PathFromGin(route.Path)
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		if doc.Paths[path][method] == nil {
+			t.Errorf("route %s %s is missing from the OpenAPI document", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !registered[method+" "+path] {
+				t.Errorf("OpenAPI document describes %s %s, which is not a registered route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
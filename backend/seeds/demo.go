@@ -0,0 +1,128 @@
+package seeds
+
+import (
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// demoAccount is an employee together with their demo login
+type demoAccount struct {
+	employee   models.Employee
+	department string
+	role       string
+	password   string
+}
+
+// seedDemo creates the departments and the well-known accounts used by the
+// frontend demo. Never run it against a production database.
+func seedDemo(tx *gorm.DB, _ Options) error {
+	departments := []models.Department{
+		{Name: "Human Resources", Description: "Manages employee relations and company policies"},
+		{Name: "Engineering", Description: "Software development and technical operations"},
+		{Name: "Sales", Description: "Customer acquisition and revenue generation"},
+		{Name: "Marketing", Description: "Brand promotion and customer engagement"},
+		{Name: "Finance", Description: "Financial planning and accounting"},
+	}
+
+	departmentIDs := map[string]uint{}
+	for _, dept := range departments {
+		created, err := ensureDepartment(tx, dept.Name, dept.Description)
+		if err != nil {
+			return err
+		}
+		departmentIDs[dept.Name] = created.ID
+	}
+
+	// Accounts matching the frontend demo users
+	accounts := []demoAccount{
+		{
+			employee: models.Employee{
+				EmployeeCode: "EMP001",
+				FirstName:    "John",
+				LastName:     "Doe",
+				Email:        "admin@hrms.com",
+				Phone:        "+1234567890",
+				Address:      "123 Main St, City, State",
+				HireDate:     time.Now().AddDate(-2, 0, 0),
+				Salary:       90000,
+				Position:     "System Administrator",
+			},
+			department: "Human Resources",
+			role:       "admin",
+			password:   "admin123",
+		},
+		{
+			employee: models.Employee{
+				EmployeeCode: "EMP002",
+				FirstName:    "Jane",
+				LastName:     "Smith",
+				Email:        "manager@hrms.com",
+				Phone:        "+1234567891",
+				Address:      "456 Oak Ave, City, State",
+				HireDate:     time.Now().AddDate(-1, -6, 0),
+				Salary:       75000,
+				Position:     "Engineering Manager",
+			},
+			department: "Engineering",
+			role:       "manager",
+			password:   "manager123",
+		},
+		{
+			employee: models.Employee{
+				EmployeeCode: "EMP003",
+				FirstName:    "Bob",
+				LastName:     "Johnson",
+				Email:        "employee@hrms.com",
+				Phone:        "+1234567892",
+				Address:      "789 Pine St, City, State",
+				HireDate:     time.Now().AddDate(0, -3, 0),
+				Salary:       60000,
+				Position:     "Software Developer",
+			},
+			department: "Engineering",
+			role:       "employee",
+			password:   "employee123",
+		},
+		{
+			employee: models.Employee{
+				EmployeeCode: "EMP004",
+				FirstName:    "Alice",
+				LastName:     "Brown",
+				Email:        "hr@hrms.com",
+				Phone:        "+1234567893",
+				Address:      "321 Elm St, City, State",
+				HireDate:     time.Now().AddDate(-1, 0, 0),
+				Salary:       70000,
+				Position:     "HR Specialist",
+			},
+			department: "Human Resources",
+			role:       "hr",
+			password:   "hr123",
+		},
+	}
+
+	for _, account := range accounts {
+		account.employee.Status = "active"
+		account.employee.DepartmentID = departmentIDs[account.department]
+		employee, err := ensureEmployee(tx, account.employee)
+		if err != nil {
+			return err
+		}
+
+		err = ensureUser(tx, models.User{
+			Email:      employee.Email,
+			FirstName:  employee.FirstName,
+			LastName:   employee.LastName,
+			Role:       account.role,
+			IsActive:   true,
+			EmployeeID: &employee.ID,
+		}, account.password)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
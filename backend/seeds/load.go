@@ -0,0 +1,365 @@
+package seeds
+
+import (
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/services"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loadCodePrefix marks generated employees, so a second load run is refused
+// instead of colliding on unique columns
+const loadCodePrefix = "LOAD"
+
+// loadBatchSize is how many employees are generated and written at a time
+const loadBatchSize = 200
+
+var (
+	loadDepartments = []struct{ name, description, title string }{
+		{"Engineering", "Software development and technical operations", "Software Engineer"},
+		{"Sales", "Customer acquisition and revenue generation", "Account Executive"},
+		{"Marketing", "Brand promotion and customer engagement", "Marketing Specialist"},
+		{"Finance", "Financial planning and accounting", "Accountant"},
+		{"Human Resources", "Manages employee relations and company policies", "HR Specialist"},
+		{"Operations", "Facilities, procurement and logistics", "Operations Analyst"},
+		{"Customer Support", "Helps customers succeed with the product", "Support Specialist"},
+		{"Legal", "Contracts, compliance and corporate governance", "Legal Counsel"},
+	}
+
+	firstNames = []string{
+		"Amara", "Ben", "Chen", "Dana", "Elif", "Farid", "Grace", "Hiro", "Ines", "Jonas",
+		"Kemi", "Liam", "Maya", "Nikos", "Olga", "Priya", "Quinn", "Rafael", "Sana", "Tomas",
+		"Uma", "Victor", "Wen", "Ximena", "Yusuf", "Zoe",
+	}
+	lastNames = []string{
+		"Abara", "Becker", "Costa", "Dubois", "Eriksen", "Fischer", "Garcia", "Haddad", "Ivanova", "Jensen",
+		"Kato", "Lindqvist", "Mensah", "Novak", "Okafor", "Patel", "Quispe", "Rossi", "Silva", "Tanaka",
+		"Umar", "Varga", "Weber", "Xu", "Yilmaz", "Zielinski",
+	}
+	leaveTypes = []string{"annual", "annual", "annual", "sick", "sick", "emergency", "unpaid"}
+)
+
+// loadGenerator produces a deterministic data set: every random choice comes
+// from rng, consumed in a fixed order
+type loadGenerator struct {
+	tx       *gorm.DB
+	rng      *rand.Rand
+	start    time.Time // first day of generated history
+	until    time.Time // last day of generated history
+	password string    // shared password hash, empty for no accounts
+}
+
+// seedLoad generates opts.Employees employees spread over eight departments,
+// with opts.Months months of attendance, leave and payroll history ending on
+// opts.Until
+func seedLoad(tx *gorm.DB, opts Options) error {
+	if opts.Employees < len(loadDepartments) {
+		return fmt.Errorf("the load profile needs at least %d employees", len(loadDepartments))
+	}
+	if opts.Months < 1 {
+		return errors.New("the load profile needs at least one month of history")
+	}
+
+	var existing int64
+	if err := tx.Model(&models.Employee{}).Where("employee_code LIKE ?", loadCodePrefix+"%").Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return errors.New("load data is already present; seed an empty database instead")
+	}
+
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+
+	g := &loadGenerator{
+		tx:    tx,
+		rng:   rand.New(rand.NewSource(opts.Seed)),
+		start: time.Date(until.Year(), until.Month()-time.Month(opts.Months-1), 1, 0, 0, 0, 0, time.UTC),
+		until: until,
+	}
+	if opts.Password != "" {
+		// One hash for every account; hashing thousands would take minutes
+		hashed, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		g.password = string(hashed)
+	}
+
+	managers, err := g.createManagers()
+	if err != nil {
+		return err
+	}
+
+	for first := len(managers); first < opts.Employees; first += loadBatchSize {
+		last := first + loadBatchSize
+		if last > opts.Employees {
+			last = opts.Employees
+		}
+		if err := g.createEmployees(first, last, managers); err != nil {
+			return err
+		}
+		log.Printf("Generated %d of %d employees", last, opts.Employees)
+	}
+	return nil
+}
+
+// createManagers creates one manager per department and makes them its head
+func (g *loadGenerator) createManagers() ([]models.Employee, error) {
+	managers := make([]models.Employee, len(loadDepartments))
+	for i, dept := range loadDepartments {
+		department, err := ensureDepartment(g.tx, dept.name, dept.description)
+		if err != nil {
+			return nil, err
+		}
+		managers[i] = g.employee(i, department.ID, dept.name+" Manager", nil)
+	}
+
+	if err := g.insert(&managers); err != nil {
+		return nil, err
+	}
+	for _, manager := range managers {
+		err := g.tx.Model(&models.Department{}).Where("id = ?", manager.DepartmentID).
+			Updates(map[string]interface{}{"manager_id": manager.ID, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return managers, g.createHistory(managers, nil, "manager")
+}
+
+// createEmployees creates employees first..last-1 together with their history
+func (g *loadGenerator) createEmployees(first, last int, managers []models.Employee) error {
+	employees := make([]models.Employee, 0, last-first)
+	for i := first; i < last; i++ {
+		manager := managers[i%len(managers)]
+		title := loadDepartments[i%len(managers)].title
+		employees = append(employees, g.employee(i, manager.DepartmentID, title, &manager.ID))
+	}
+
+	if err := g.insert(&employees); err != nil {
+		return err
+	}
+
+	approvers := make([]uint, len(employees))
+	for i, employee := range employees {
+		approvers[i] = *employee.ManagerID
+	}
+	return g.createHistory(employees, approvers, "employee")
+}
+
+// employee builds the i-th generated employee
+func (g *loadGenerator) employee(i int, departmentID uint, position string, managerID *uint) models.Employee {
+	firstName := firstNames[g.rng.Intn(len(firstNames))]
+	lastName := lastNames[g.rng.Intn(len(lastNames))]
+	salary := 40000 + float64(g.rng.Intn(1100))*100
+	if managerID == nil {
+		salary += 30000
+	}
+	// Everyone is hired before the generated history starts
+	hireDate := g.start.AddDate(0, 0, -1-g.rng.Intn(3650))
+
+	return models.Employee{
+		EmployeeCode: fmt.Sprintf("%s%06d", loadCodePrefix, i+1),
+		FirstName:    firstName,
+		LastName:     lastName,
+		Email:        fmt.Sprintf("%s.%s.%d@load.hrms.test", strings.ToLower(firstName), strings.ToLower(lastName), i+1),
+		Phone:        fmt.Sprintf("+1555%07d", g.rng.Intn(10000000)),
+		HireDate:     hireDate,
+		Salary:       salary,
+		Position:     position,
+		Status:       "active",
+		DepartmentID: departmentID,
+		ManagerID:    managerID,
+	}
+}
+
+// createHistory writes hire records, accounts, leave, attendance and payroll for employees.
+// approvers holds each employee's leave approver, or is nil for managers,
+// whose leave is approved by HR outside the generated data.
+func (g *loadGenerator) createHistory(employees []models.Employee, approvers []uint, role string) error {
+	var (
+		hires      []models.EmploymentChange
+		users      []models.User
+		leaves     []models.LeaveRequest
+		attendance []models.Attendance
+		payroll    []models.PayrollRecord
+	)
+
+	for i, employee := range employees {
+		hires = append(hires, hireRecord(employee))
+		if g.password != "" {
+			employeeID := employee.ID
+			users = append(users, models.User{
+				Email:      employee.Email,
+				Password:   g.password,
+				FirstName:  employee.FirstName,
+				LastName:   employee.LastName,
+				Role:       role,
+				IsActive:   true,
+				EmployeeID: &employeeID,
+			})
+		}
+
+		var approver *uint
+		if approvers != nil {
+			approver = &approvers[i]
+		}
+		employeeLeaves := g.leaves(employee, approver)
+		leaves = append(leaves, employeeLeaves...)
+		attendance = append(attendance, g.attendance(employee, employeeLeaves)...)
+		payroll = append(payroll, g.payroll(employee)...)
+	}
+
+	for _, rows := range []interface{}{&hires, &users, &leaves, &attendance, &payroll} {
+		if err := g.insert(rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leaves generates roughly one leave request every four months
+func (g *loadGenerator) leaves(employee models.Employee, approver *uint) []models.LeaveRequest {
+	var leaves []models.LeaveRequest
+	for month := g.start; !month.After(g.until); month = month.AddDate(0, 1, 0) {
+		if g.rng.Intn(4) != 0 {
+			continue
+		}
+
+		start := month.AddDate(0, 0, g.rng.Intn(daysIn(month)))
+		for isWeekend(start) {
+			start = start.AddDate(0, 0, 1)
+		}
+		end := start.AddDate(0, 0, g.rng.Intn(5))
+		leaveType := leaveTypes[g.rng.Intn(len(leaveTypes))]
+		outcome := g.rng.Intn(10)
+		if start.After(g.until) {
+			continue
+		}
+
+		leave := models.LeaveRequest{
+			EmployeeID: employee.ID,
+			LeaveType:  leaveType,
+			StartDate:  start,
+			EndDate:    end,
+			Days:       services.LeaveDays(start, end),
+			Reason:     "Generated " + leaveType + " leave",
+			Status:     "pending",
+		}
+		// Requests that have started are decided: mostly approved, some rejected
+		if !start.After(g.until.AddDate(0, 0, -7)) {
+			leave.Status = "approved"
+			if outcome == 0 {
+				leave.Status = "rejected"
+			}
+			decidedAt := start.AddDate(0, 0, -3)
+			leave.ApprovedAt = &decidedAt
+			leave.ApprovedBy = approver
+		}
+		leaves = append(leaves, leave)
+	}
+	return leaves
+}
+
+// attendance generates a record for every working day not covered by approved leave
+func (g *loadGenerator) attendance(employee models.Employee, leaves []models.LeaveRequest) []models.Attendance {
+	var records []models.Attendance
+	for day := g.start; !day.After(g.until); day = day.AddDate(0, 0, 1) {
+		if isWeekend(day) || onLeave(day, leaves) {
+			continue
+		}
+
+		record := models.Attendance{EmployeeID: employee.ID, Date: day, Status: "present"}
+		roll := g.rng.Intn(100)
+		checkIn := day.Add(8*time.Hour + 30*time.Minute + time.Duration(g.rng.Intn(40))*time.Minute)
+		shift := 7*time.Hour + 30*time.Minute + time.Duration(g.rng.Intn(120))*time.Minute
+		switch {
+		case roll < 2:
+			record.Status = "absent"
+			records = append(records, record)
+			continue
+		case roll < 4:
+			record.Status = "half-day"
+			shift = 4 * time.Hour
+		case roll < 12:
+			record.Status = "late"
+			checkIn = day.Add(9*time.Hour + 15*time.Minute + time.Duration(g.rng.Intn(75))*time.Minute)
+		}
+		checkOut := checkIn.Add(shift)
+		record.CheckIn = &checkIn
+		record.CheckOut = &checkOut
+		record.WorkingHours = services.WorkingHours(&checkIn, &checkOut)
+		records = append(records, record)
+	}
+	return records
+}
+
+// payroll generates a paid record for every month that has ended
+func (g *loadGenerator) payroll(employee models.Employee) []models.PayrollRecord {
+	var records []models.PayrollRecord
+	for month := g.start; ; month = month.AddDate(0, 1, 0) {
+		periodEnd := month.AddDate(0, 1, -1)
+		if periodEnd.After(g.until) {
+			break
+		}
+
+		basic := roundCents(employee.Salary / 12)
+		processedAt := periodEnd.AddDate(0, 0, 1)
+		paidAt := periodEnd.AddDate(0, 0, 3)
+		record := models.PayrollRecord{
+			EmployeeID:     employee.ID,
+			PayPeriodStart: month,
+			PayPeriodEnd:   periodEnd,
+			BasicSalary:    basic,
+			Allowances:     roundCents(basic * 0.05),
+			Overtime:       float64(g.rng.Intn(4)) * 75,
+			Deductions:     float64(g.rng.Intn(3)) * 50,
+			Status:         "paid",
+			ProcessedAt:    &processedAt,
+			PaidAt:         &paidAt,
+		}
+		record.Tax = roundCents((record.BasicSalary + record.Allowances + record.Overtime) * 0.2)
+		services.CalculatePayroll(&record)
+		records = append(records, record)
+	}
+	return records
+}
+
+// insert writes rows, a pointer to a slice of models, in batches
+func (g *loadGenerator) insert(rows interface{}) error {
+	return g.tx.Omit(clause.Associations).CreateInBatches(rows, 500).Error
+}
+
+func onLeave(day time.Time, leaves []models.LeaveRequest) bool {
+	for _, leave := range leaves {
+		if leave.Status == "approved" && !day.Before(leave.StartDate) && !day.After(leave.EndDate) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
+
+func daysIn(month time.Time) int {
+	return month.AddDate(0, 1, -1).Day()
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
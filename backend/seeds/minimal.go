@@ -0,0 +1,49 @@
+package seeds
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedMinimal creates a single administrator, linked to an employee record in
+// Human Resources so they can approve requests. It is safe for production:
+// the password must be supplied and nothing else is created.
+func seedMinimal(tx *gorm.DB, opts Options) error {
+	if opts.AdminEmail == "" {
+		return errors.New("an admin email is required for the minimal profile")
+	}
+	if len(opts.AdminPassword) < 8 {
+		return errors.New("an admin password of at least 8 characters is required for the minimal profile")
+	}
+
+	department, err := ensureDepartment(tx, "Human Resources", "Manages employee relations and company policies")
+	if err != nil {
+		return err
+	}
+
+	employee, err := ensureEmployee(tx, models.Employee{
+		EmployeeCode: "ADMIN",
+		FirstName:    "System",
+		LastName:     "Administrator",
+		Email:        opts.AdminEmail,
+		HireDate:     time.Now(),
+		Position:     "System Administrator",
+		Status:       "active",
+		DepartmentID: department.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ensureUser(tx, models.User{
+		Email:      opts.AdminEmail,
+		FirstName:  employee.FirstName,
+		LastName:   employee.LastName,
+		Role:       "admin",
+		IsActive:   true,
+		EmployeeID: &employee.ID,
+	}, opts.AdminPassword)
+}
@@ -1,131 +1,123 @@
 package seeds
 
 import (
+	"errors"
+	"fmt"
 	"hrms-backend/models"
+	"sort"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func SeedDatabase(db *gorm.DB) error {
-	// Create departments
-	departments := []models.Department{
-		{Name: "Human Resources", Description: "Manages employee relations and company policies"},
-		{Name: "Engineering", Description: "Software development and technical operations"},
-		{Name: "Sales", Description: "Customer acquisition and revenue generation"},
-		{Name: "Marketing", Description: "Brand promotion and customer engagement"},
-		{Name: "Finance", Description: "Financial planning and accounting"},
-	}
+// Options configures a seeding run. Each profile reads only the fields it needs.
+type Options struct {
+	// AdminEmail and AdminPassword set the administrator created by the minimal profile
+	AdminEmail    string
+	AdminPassword string
 
-	for _, dept := range departments {
-		var existingDept models.Department
-		if err := db.Where("name = ?", dept.Name).First(&existingDept).Error; err == gorm.ErrRecordNotFound {
-			db.Create(&dept)
-		}
-	}
+	// Employees is the number of employees the load profile generates
+	Employees int
+	// Months is how many months of history the load profile generates
+	Months int
+	// Seed drives the load generator; the same seed and Until give the same data
+	Seed int64
+	// Until is the last day of generated history
+	Until time.Time
+	// Password, when set, gives every generated employee a login with this password
+	Password string
+}
 
-	// Reload departments with IDs
-	var createdDepts []models.Department
-	db.Find(&createdDepts)
+// profiles maps profile names to their seeders
+var profiles = map[string]func(db *gorm.DB, opts Options) error{
+	"minimal": seedMinimal,
+	"demo":    seedDemo,
+	"load":    seedLoad,
+}
 
-	// Create employees
-	employees := []models.Employee{
-		{
-			EmployeeCode: "EMP001",
-			FirstName:    "John",
-			LastName:     "Doe",
-			Email:        "admin@hrms.com",
-			Phone:        "+1234567890",
-			Address:      "123 Main St, City, State",
-			HireDate:     time.Now().AddDate(-2, 0, 0),
-			Salary:       90000,
-			Position:     "System Administrator",
-			Status:       "active",
-			DepartmentID: createdDepts[0].Model.ID, // HR
-		},
-		{
-			EmployeeCode: "EMP002",
-			FirstName:    "Jane",
-			LastName:     "Smith",
-			Email:        "manager@hrms.com",
-			Phone:        "+1234567891",
-			Address:      "456 Oak Ave, City, State",
-			HireDate:     time.Now().AddDate(-1, -6, 0),
-			Salary:       75000,
-			Position:     "Engineering Manager",
-			Status:       "active",
-			DepartmentID: createdDepts[1].Model.ID, // Engineering
-		},
-		{
-			EmployeeCode: "EMP003",
-			FirstName:    "Bob",
-			LastName:     "Johnson",
-			Email:        "employee@hrms.com",
-			Phone:        "+1234567892",
-			Address:      "789 Pine St, City, State",
-			HireDate:     time.Now().AddDate(0, -3, 0),
-			Salary:       60000,
-			Position:     "Software Developer",
-			Status:       "active",
-			DepartmentID: createdDepts[1].Model.ID, // Engineering
-		},
+// Profiles returns the names of the available seeding profiles
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	for _, emp := range employees {
-		var existingEmp models.Employee
-		if err := db.Where("email = ?", emp.Email).First(&existingEmp).Error; err == gorm.ErrRecordNotFound {
-			db.Create(&emp)
-		}
+// Run seeds the database with the named profile inside a single transaction,
+// so a failed run leaves nothing behind
+func Run(db *gorm.DB, profile string, opts Options) error {
+	seed, ok := profiles[profile]
+	if !ok {
+		return fmt.Errorf("unknown seed profile %q", profile)
 	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return seed(tx, opts)
+	})
+}
 
-	// Reload employees with IDs
-	var createdEmps []models.Employee
-	db.Find(&createdEmps)
+// ensureDepartment returns the department with the given name, creating it if needed
+func ensureDepartment(tx *gorm.DB, name, description string) (models.Department, error) {
+	department := models.Department{Name: name}
+	err := tx.Where(models.Department{Name: name}).
+		Attrs(models.Department{Description: description}).
+		FirstOrCreate(&department).Error
+	return department, err
+}
 
-	// Create users (matching the frontend demo users)
-	users := []models.User{
-		{
-			Email:      "admin@hrms.com",
-			Password:   "admin123",
-			FirstName:  "John",
-			LastName:   "Doe",
-			Role:       "admin",
-			IsActive:   true,
-			EmployeeID: &createdEmps[0].Model.ID,
-		},
-		{
-			Email:      "manager@hrms.com",
-			Password:   "manager123",
-			FirstName:  "Jane",
-			LastName:   "Smith",
-			Role:       "manager",
-			IsActive:   true,
-			EmployeeID: &createdEmps[1].Model.ID,
-		},
-		{
-			Email:      "employee@hrms.com",
-			Password:   "employee123",
-			FirstName:  "Bob",
-			LastName:   "Johnson",
-			Role:       "employee",
-			IsActive:   true,
-			EmployeeID: &createdEmps[2].Model.ID,
-		},
+// ensureEmployee returns the employee with employee's email, creating it and
+// its hire record if needed
+func ensureEmployee(tx *gorm.DB, employee models.Employee) (models.Employee, error) {
+	var existing models.Employee
+	err := tx.Where("email = ?", employee.Email).First(&existing).Error
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Employee{}, err
 	}
+	if err := tx.Create(&employee).Error; err != nil {
+		return models.Employee{}, err
+	}
+	hire := hireRecord(employee)
+	return employee, tx.Create(&hire).Error
+}
 
-	for _, user := range users {
-		var existingUser models.User
-		if err := db.Where("email = ?", user.Email).First(&existingUser).Error; err == gorm.ErrRecordNotFound {
-			// Hash password
-			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-			if err != nil {
-				return err
-			}
-			user.Password = string(hashedPassword)
-			db.Create(&user)
-		}
+// hireRecord is the employment history entry holding employee's starting job
+func hireRecord(employee models.Employee) models.EmploymentChange {
+	hireDate := time.Date(employee.HireDate.Year(), employee.HireDate.Month(), employee.HireDate.Day(), 0, 0, 0, 0, time.UTC)
+	return models.EmploymentChange{
+		EmployeeID:    employee.ID,
+		EffectiveDate: hireDate,
+		Kind:          "hire",
+		Status:        "applied",
+		Reason:        "Hired",
+		Position:      &employee.Position,
+		Salary:        &employee.Salary,
+		DepartmentID:  &employee.DepartmentID,
+		ManagerID:     employee.ManagerID,
+		AppliedAt:     &employee.CreatedAt,
 	}
+}
 
-	return nil
+// ensureUser creates the account unless one with the same email exists. The
+// password of an existing account is never changed.
+func ensureUser(tx *gorm.DB, user models.User, password string) error {
+	var existing models.User
+	err := tx.Where("email = ?", user.Email).First(&existing).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashed)
+	return tx.Create(&user).Error
 }
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"time"
+)
+
+// AttendanceInput holds the writable fields of an attendance record
+type AttendanceInput struct {
+	// EmployeeID is ignored unless the actor is HR; others log their own attendance
+	EmployeeID uint
+	Date       time.Time
+	CheckIn    *time.Time
+	CheckOut   *time.Time
+	Status     string
+	Comments   string
+}
+
+// attendanceWritableFields is the mass-assignment allowlist for attendance updates
+var attendanceWritableFields = []string{"date", "check_in", "check_out", "status", "comments", "working_hours"}
+
+// columns maps the input onto attendance columns, deriving the working hours
+// from the check-in and check-out times
+func (in AttendanceInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"date":          in.Date,
+		"check_in":      in.CheckIn,
+		"check_out":     in.CheckOut,
+		"status":        in.Status,
+		"comments":      in.Comments,
+		"working_hours": WorkingHours(in.CheckIn, in.CheckOut),
+	}
+}
+
+// WorkingHours returns the hours between check-in and check-out, or zero
+// while either time is missing
+func WorkingHours(checkIn, checkOut *time.Time) float64 {
+	if checkIn == nil || checkOut == nil {
+		return 0
+	}
+	return checkOut.Sub(*checkIn).Hours()
+}
+
+type AttendanceService struct {
+	repos *repositories.Repositories
+}
+
+func NewAttendanceService(repos *repositories.Repositories) *AttendanceService {
+	return &AttendanceService{repos: repos}
+}
+
+// List returns the attendance records visible to the actor
+func (s *AttendanceService) List(actor Actor) ([]repositories.AttendanceSummary, error) {
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Attendance.List(scope)
+}
+
+func (s *AttendanceService) Get(id uint) (*models.Attendance, error) {
+	attendance, err := s.repos.Attendance.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Attendance record not found")
+	}
+	return attendance, nil
+}
+
+// View returns a attendance record the actor may see: HR any, managers their
+// department's and employees their own
+func (s *AttendanceService) View(actor Actor, id uint) (*models.Attendance, error) {
+	attendance, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInScope(scope, attendance.Employee); err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// Log records attendance. HR may log for any employee; everyone else logs
+// their own attendance.
+func (s *AttendanceService) Log(actor Actor, input AttendanceInput) (*models.Attendance, error) {
+	if !actor.IsHR() {
+		employee, err := employeeOf(s.repos.Users, actor)
+		if err != nil {
+			return nil, err
+		}
+		input.EmployeeID = employee.ID
+	}
+	if input.EmployeeID == 0 {
+		return nil, newError(ErrInvalid, "employeeId is required")
+	}
+	if input.Status == "" {
+		input.Status = "present"
+	}
+
+	attendance := &models.Attendance{
+		EmployeeID:   input.EmployeeID,
+		Date:         input.Date,
+		CheckIn:      input.CheckIn,
+		CheckOut:     input.CheckOut,
+		Status:       input.Status,
+		WorkingHours: WorkingHours(input.CheckIn, input.CheckOut),
+		Comments:     input.Comments,
+	}
+	if err := s.repos.Attendance.Create(attendance); err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// Update applies input to attendance, which must be at the version the caller edited
+func (s *AttendanceService) Update(attendance *models.Attendance, input AttendanceInput) error {
+	err := s.repos.Attendance.Update(attendance, permitted(input.columns(), attendanceWritableFields))
+	return staleAs(err, "Attendance record has been modified by another request")
+}
+
+func (s *AttendanceService) Delete(id uint) error {
+	return s.repos.Attendance.Delete(id)
+}
+
+// DepartmentReport lists attendance for the actor's own department
+func (s *AttendanceService) DepartmentReport(actor Actor) ([]repositories.AttendanceReportRow, error) {
+	employee, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.Report(employee.DepartmentID)
+}
+
+// Report lists attendance for any department
+func (s *AttendanceService) Report(departmentID uint) ([]repositories.AttendanceReportRow, error) {
+	return s.repos.Attendance.DepartmentReport(departmentID)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/utils"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AuthService struct {
+	repos        *repositories.Repositories
+	jwtSecret    string
+	jwtExpiresIn time.Duration
+}
+
+func NewAuthService(repos *repositories.Repositories, jwtSecret string, jwtExpiresIn time.Duration) *AuthService {
+	return &AuthService{repos: repos, jwtSecret: jwtSecret, jwtExpiresIn: jwtExpiresIn}
+}
+
+// AccountRole returns the current role of the user and whether they may
+// still use the tokens issued to them; deleted and deactivated accounts may
+// not. The role is read from the account, so role changes apply at once.
+func (s *AuthService) AccountRole(userID uint) (string, bool, error) {
+	user, err := s.repos.Users.FindByID(userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return user.Role, user.IsActive, nil
+}
+
+// Login checks the credentials and returns a signed token for the user
+func (s *AuthService) Login(email, password string) (string, *models.User, error) {
+	user, err := s.repos.Users.FindByEmail(email)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return "", nil, newError(ErrUnauthorized, "Invalid credentials")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !user.IsActive {
+		return "", nil, newError(ErrUnauthorized, "Account is deactivated")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return "", nil, newError(ErrUnauthorized, "Invalid credentials")
+	}
+
+	token, err := utils.GenerateJWT(s.jwtSecret, s.jwtExpiresIn, user.ID, user.Email, user.Role)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	return token, user, nil
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"time"
+)
+
+// CertificationInput holds the editable fields of a certification.
+// DocumentID names one of the employee's documents as evidence.
+type CertificationInput struct {
+	SkillID    uint
+	Name       string
+	Issuer     string
+	IssuedAt   time.Time
+	ExpiresAt  *time.Time
+	DocumentID *uint
+}
+
+func (in CertificationInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"skill_id":    in.SkillID,
+		"name":        in.Name,
+		"issuer":      in.Issuer,
+		"issued_at":   dateOf(in.IssuedAt),
+		"expires_at":  optionalDateOf(in.ExpiresAt),
+		"document_id": in.DocumentID,
+	}
+}
+
+// optionalDateOf is dateOf for optional dates
+func optionalDateOf(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	date := dateOf(*t)
+	return &date
+}
+
+// Certification statuses, as shown in the skill matrix
+const (
+	CertificationValid    = "valid"
+	CertificationExpiring = "expiring"
+	CertificationExpired  = "expired"
+)
+
+// SkillMatrix shows which skills a department's active employees hold
+// certifications for. Skills is the whole catalog, so gaps show.
+type SkillMatrix struct {
+	DepartmentID uint
+	Skills       []models.Skill
+	Employees    []SkillMatrixRow
+}
+
+// SkillMatrixRow is one employee's certifications by skill ID; skills they
+// hold no certification for are missing
+type SkillMatrixRow struct {
+	EmployeeID uint
+	FirstName  string
+	LastName   string
+	Position   string
+	Skills     map[uint]SkillMatrixCell
+}
+
+// SkillMatrixCell is an employee's certification for a skill, the one that
+// lasts longest when they hold several
+type SkillMatrixCell struct {
+	CertificationID uint
+	Status          string
+	ExpiresAt       *time.Time
+}
+
+// CertificationService manages employees' certifications and warns their
+// holders and managers before they expire. HR keeps the records; employees
+// see their own and managers those of the employees reporting to them.
+type CertificationService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+	// warningDays is how long before expiry a certification counts as expiring
+	warningDays int
+}
+
+func NewCertificationService(repos *repositories.Repositories, uow repositories.UnitOfWork, warningDays int) *CertificationService {
+	return &CertificationService{repos: repos, uow: uow, warningDays: warningDays}
+}
+
+// status places a certification's expiry relative to today
+func (s *CertificationService) status(expiresAt *time.Time, today time.Time) string {
+	switch {
+	case expiresAt == nil:
+		return CertificationValid
+	case expiresAt.Before(today):
+		return CertificationExpired
+	case expiresAt.Before(today.AddDate(0, 0, s.warningDays+1)):
+		return CertificationExpiring
+	default:
+		return CertificationValid
+	}
+}
+
+// checkRead lets HR, the employee and the managers they report to, directly
+// or through others, read an employee's certifications
+func (s *CertificationService) checkRead(actor Actor, employeeID uint) error {
+	if _, err := s.repos.Employees.FindByID(employeeID); err != nil {
+		return notFoundAs(err, "Employee not found")
+	}
+	if actor.IsHR() {
+		return nil
+	}
+	own, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return err
+	}
+	if own.ID == employeeID {
+		return nil
+	}
+	if actor.Role == "manager" {
+		reports, err := reportsTo(s.repos.Employees, employeeID, own.ID)
+		if err != nil {
+			return err
+		}
+		if reports {
+			return nil
+		}
+	}
+	return newError(ErrForbidden, "You do not have access to this employee's certifications")
+}
+
+// validate checks the dates, skill and evidence of an employee's certification
+func (s *CertificationService) validate(employeeID uint, input CertificationInput) error {
+	if dateOf(input.IssuedAt).After(dateOf(time.Now())) {
+		return newError(ErrInvalid, "The issue date cannot be in the future")
+	}
+	if input.ExpiresAt != nil && !dateOf(*input.ExpiresAt).After(dateOf(input.IssuedAt)) {
+		return newError(ErrInvalid, "The expiry date must be after the issue date")
+	}
+	if _, err := s.repos.Skills.FindByID(input.SkillID); err != nil {
+		return notFoundAs(err, "Skill not found")
+	}
+	if input.DocumentID != nil {
+		document, err := s.repos.Documents.Find(*input.DocumentID)
+		if errors.Is(err, repositories.ErrNotFound) || (err == nil && document.EmployeeID != employeeID) {
+			return newError(ErrInvalid, "The evidence must be one of the employee's documents")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CertificationService) List(actor Actor, employeeID uint) ([]models.Certification, error) {
+	if err := s.checkRead(actor, employeeID); err != nil {
+		return nil, err
+	}
+	return s.repos.Certifications.ListByEmployee(employeeID)
+}
+
+// Get loads one of an employee's certifications for editing
+func (s *CertificationService) Get(employeeID, id uint) (*models.Certification, error) {
+	certification, err := s.repos.Certifications.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Certification not found")
+	}
+	if certification.EmployeeID != employeeID {
+		return nil, newError(ErrNotFound, "Certification not found")
+	}
+	return certification, nil
+}
+
+func (s *CertificationService) Create(employeeID uint, input CertificationInput) (*models.Certification, error) {
+	if _, err := s.repos.Employees.FindByID(employeeID); err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	if err := s.validate(employeeID, input); err != nil {
+		return nil, err
+	}
+
+	certification := &models.Certification{
+		EmployeeID: employeeID,
+		SkillID:    input.SkillID,
+		Name:       input.Name,
+		Issuer:     input.Issuer,
+		IssuedAt:   dateOf(input.IssuedAt),
+		ExpiresAt:  optionalDateOf(input.ExpiresAt),
+		DocumentID: input.DocumentID,
+	}
+	if err := s.repos.Certifications.Create(certification); err != nil {
+		return nil, err
+	}
+	return certification, nil
+}
+
+// Update applies input to certification, which must be at the version the
+// caller edited. A new expiry date, such as after a renewal, is warned about
+// again.
+func (s *CertificationService) Update(certification *models.Certification, input CertificationInput) error {
+	if err := s.validate(certification.EmployeeID, input); err != nil {
+		return err
+	}
+	columns := input.columns()
+	if !sameDate(certification.ExpiresAt, input.ExpiresAt) {
+		columns["warned_at"] = nil
+	}
+	err := s.repos.Certifications.Update(certification, columns)
+	return staleAs(err, "Certification has been modified by another request")
+}
+
+// sameDate reports whether two optional dates are the same day
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return dateOf(*a).Equal(dateOf(*b))
+}
+
+func (s *CertificationService) Delete(employeeID, id uint) error {
+	certification, err := s.Get(employeeID, id)
+	if err != nil {
+		return err
+	}
+	return s.repos.Certifications.Delete(certification.ID)
+}
+
+// Matrix builds the skill matrix of a department: HR sees any department and
+// managers their own
+func (s *CertificationService) Matrix(actor Actor, departmentID uint) (*SkillMatrix, error) {
+	if _, err := s.repos.Departments.FindByID(departmentID); err != nil {
+		return nil, notFoundAs(err, "Department not found")
+	}
+	if !actor.IsHR() {
+		own, err := employeeOf(s.repos.Users, actor)
+		if err != nil {
+			return nil, err
+		}
+		if own.DepartmentID != departmentID {
+			return nil, newError(ErrForbidden, "You can only see your own department's skill matrix")
+		}
+	}
+
+	skills, err := s.repos.Skills.List()
+	if err != nil {
+		return nil, err
+	}
+	employees, err := s.repos.Employees.ListActive(departmentID)
+	if err != nil {
+		return nil, err
+	}
+	certifications, err := s.repos.Certifications.ListByDepartment(departmentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildMatrix(departmentID, skills, employees, certifications, dateOf(time.Now())), nil
+}
+
+// buildMatrix places each certification in its holder's row, keeping the one
+// that lasts longest per skill
+func (s *CertificationService) buildMatrix(departmentID uint, skills []models.Skill, employees []models.Employee,
+	certifications []models.Certification, today time.Time) *SkillMatrix {
+	matrix := &SkillMatrix{DepartmentID: departmentID, Skills: skills, Employees: make([]SkillMatrixRow, len(employees))}
+	rows := make(map[uint]*SkillMatrixRow, len(employees))
+	for i, employee := range employees {
+		matrix.Employees[i] = SkillMatrixRow{
+			EmployeeID: employee.ID,
+			FirstName:  employee.FirstName,
+			LastName:   employee.LastName,
+			Position:   employee.Position,
+			Skills:     map[uint]SkillMatrixCell{},
+		}
+		rows[employee.ID] = &matrix.Employees[i]
+	}
+
+	for _, certification := range certifications {
+		row, ok := rows[certification.EmployeeID]
+		if !ok {
+			continue
+		}
+		if held, ok := row.Skills[certification.SkillID]; ok && !outlasts(certification.ExpiresAt, held.ExpiresAt) {
+			continue
+		}
+		row.Skills[certification.SkillID] = SkillMatrixCell{
+			CertificationID: certification.ID,
+			Status:          s.status(certification.ExpiresAt, today),
+			ExpiresAt:       certification.ExpiresAt,
+		}
+	}
+	return matrix
+}
+
+// outlasts reports whether expiry a is later than b, where nil never expires
+func outlasts(a, b *time.Time) bool {
+	if b == nil {
+		return false
+	}
+	return a == nil || a.After(*b)
+}
+
+// WarnExpiring warns the holders of certifications that expire within the
+// warning period of date, or have expired, and their managers. Each expiry
+// date is warned about once, however many servers run this. A certification
+// that cannot be warned about is reported and does not hold up the others.
+func (s *CertificationService) WarnExpiring(date time.Time) (int, error) {
+	due, err := s.repos.Certifications.DueWarnings(dateOf(date).AddDate(0, 0, s.warningDays+1))
+	if err != nil {
+		return 0, err
+	}
+
+	warned := 0
+	var failures []error
+	for _, certification := range due {
+		sent := false
+		err := s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+			marked, err := repos.Certifications.MarkWarned(certification.ID, time.Now())
+			if err != nil || !marked {
+				return err
+			}
+			sent = true
+			return notifyExpiry(repos, certification, dateOf(date))
+		})
+		if err != nil {
+			failures = append(failures, fmt.Errorf("certification %d: %w", certification.ID, err))
+			continue
+		}
+		if sent {
+			warned++
+		}
+	}
+	return warned, errors.Join(failures...)
+}
+
+// notifyExpiry writes the expiry warning for a certification to its holder's
+// account and to the account of their manager, or else of their
+// department's manager. A manager who has been deleted is not warned.
+func notifyExpiry(repos *repositories.Repositories, certification repositories.DueCertification, today time.Time) error {
+	employee, err := repos.Employees.FindByID(certification.EmployeeID)
+	if err != nil {
+		return err
+	}
+
+	expires := "expires"
+	if certification.ExpiresAt.Before(today) {
+		expires = "expired"
+	}
+	subject := fmt.Sprintf("certification %s (%s) %s on %s",
+		certification.Name, certification.SkillName, expires, certification.ExpiresAt.Format("2006-01-02"))
+	link := fmt.Sprintf("/api/v1/employees/%d/certifications", employee.ID)
+
+	notify := func(user *models.User, message string) error {
+		if user == nil {
+			return nil
+		}
+		return repos.Notifications.Create(&models.Notification{
+			UserID:  user.ID,
+			Kind:    "certification_expiring",
+			Message: message,
+			Link:    link,
+		})
+	}
+
+	if err := notify(employee.User, "Your "+subject); err != nil {
+		return err
+	}
+	managerID := employee.ManagerID
+	if managerID == nil && employee.Department.ManagerID != nil && *employee.Department.ManagerID != employee.ID {
+		managerID = employee.Department.ManagerID
+	}
+	if managerID == nil {
+		return nil
+	}
+	manager, err := repos.Employees.FindByID(*managerID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return notify(manager.User, fmt.Sprintf("%s %s's %s", employee.FirstName, employee.LastName, subject))
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"math"
+	"strings"
+	"time"
+)
+
+// ChecklistTemplateInput holds the editable fields of a checklist template.
+// Tasks replace the template's current tasks.
+type ChecklistTemplateInput struct {
+	Name         string
+	Kind         string
+	DepartmentID *uint
+	Position     string
+	Active       bool
+	Tasks        []ChecklistTemplateTaskInput
+}
+
+// ChecklistTemplateTaskInput holds one task of a template
+type ChecklistTemplateTaskInput struct {
+	Title         string
+	Description   string
+	Assignee      string
+	DueOffsetDays int
+}
+
+func (in ChecklistTemplateInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":          in.Name,
+		"kind":          in.Kind,
+		"department_id": in.DepartmentID,
+		"position":      in.Position,
+		"active":        in.Active,
+	}
+}
+
+func (in ChecklistTemplateInput) tasks() []models.ChecklistTemplateTask {
+	tasks := make([]models.ChecklistTemplateTask, len(in.Tasks))
+	for i, task := range in.Tasks {
+		tasks[i] = models.ChecklistTemplateTask{
+			SortOrder:     i,
+			Title:         task.Title,
+			Description:   task.Description,
+			Assignee:      task.Assignee,
+			DueOffsetDays: task.DueOffsetDays,
+		}
+	}
+	return tasks
+}
+
+// ChecklistTaskInput holds the editable fields of a checklist task. Only HR
+// may move the due date.
+type ChecklistTaskInput struct {
+	Status  string
+	Notes   string
+	DueDate time.Time
+}
+
+// ChecklistProgress summarises one of an employee's checklists
+type ChecklistProgress struct {
+	Kind    string
+	Total   int
+	Done    int
+	Skipped int
+	Overdue int
+	// Percent is the share of tasks done or skipped
+	Percent float64
+	Tasks   []models.ChecklistTask
+}
+
+// matchesTemplate reports whether a template applies to the employee
+func matchesTemplate(template models.ChecklistTemplate, employee *models.Employee) bool {
+	if template.DepartmentID != nil && *template.DepartmentID != employee.DepartmentID {
+		return false
+	}
+	return template.Position == "" || strings.EqualFold(template.Position, employee.Position)
+}
+
+// startChecklist creates the tasks of every active template of kind matching
+// the employee, due relative to anchor. Templates that already produced tasks
+// for the employee are skipped, so starting a checklist twice is harmless.
+// It returns the tasks it created.
+func startChecklist(repos *repositories.Repositories, employee *models.Employee, kind string, anchor time.Time) ([]models.ChecklistTask, error) {
+	templates, err := repos.Checklists.ListTemplates(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var managerUserID *uint
+	managerLoaded := false
+	assigneeFor := func(assignee string) (*uint, error) {
+		switch assignee {
+		case "employee":
+			if employee.User != nil {
+				return &employee.User.ID, nil
+			}
+		case "manager":
+			if !managerLoaded && employee.ManagerID != nil {
+				manager, err := repos.Employees.FindByID(*employee.ManagerID)
+				if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+					return nil, err
+				}
+				if manager != nil && manager.User != nil {
+					managerUserID = &manager.User.ID
+				}
+			}
+			managerLoaded = true
+			return managerUserID, nil
+		}
+		return nil, nil
+	}
+
+	created := []models.ChecklistTask{}
+	for _, template := range templates {
+		if !template.Active || !matchesTemplate(template, employee) {
+			continue
+		}
+		started, err := repos.Checklists.HasTasks(employee.ID, template.ID)
+		if err != nil {
+			return nil, err
+		}
+		if started {
+			continue
+		}
+
+		tasks := make([]models.ChecklistTask, 0, len(template.Tasks))
+		for _, task := range template.Tasks {
+			assigneeUserID, err := assigneeFor(task.Assignee)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, models.ChecklistTask{
+				EmployeeID:     employee.ID,
+				TemplateID:     template.ID,
+				Kind:           kind,
+				Title:          task.Title,
+				Description:    task.Description,
+				Assignee:       task.Assignee,
+				AssigneeUserID: assigneeUserID,
+				DueDate:        dateOf(anchor).AddDate(0, 0, task.DueOffsetDays),
+				Status:         "pending",
+			})
+		}
+		if err := repos.Checklists.CreateTasks(tasks); err != nil {
+			return nil, err
+		}
+		created = append(created, tasks...)
+	}
+	return created, nil
+}
+
+type ChecklistService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+}
+
+func NewChecklistService(repos *repositories.Repositories, uow repositories.UnitOfWork) *ChecklistService {
+	return &ChecklistService{repos: repos, uow: uow}
+}
+
+func (s *ChecklistService) ListTemplates(kind string) ([]models.ChecklistTemplate, error) {
+	return s.repos.Checklists.ListTemplates(kind)
+}
+
+func (s *ChecklistService) GetTemplate(id uint) (*models.ChecklistTemplate, error) {
+	template, err := s.repos.Checklists.FindTemplate(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Checklist template not found")
+	}
+	return template, nil
+}
+
+func (s *ChecklistService) CreateTemplate(input ChecklistTemplateInput) (*models.ChecklistTemplate, error) {
+	template := &models.ChecklistTemplate{
+		Name:         input.Name,
+		Kind:         input.Kind,
+		DepartmentID: input.DepartmentID,
+		Position:     input.Position,
+		Active:       input.Active,
+		Tasks:        input.tasks(),
+	}
+	if err := s.repos.Checklists.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// UpdateTemplate applies input to template, which must be at the version the
+// caller edited. Tasks already created for employees are left as they are.
+func (s *ChecklistService) UpdateTemplate(template *models.ChecklistTemplate, input ChecklistTemplateInput) error {
+	current := *template
+	err := s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+		updated := current
+		if err := repos.Checklists.UpdateTemplate(&updated, input.columns(), input.tasks()); err != nil {
+			return err
+		}
+		*template = updated
+		return nil
+	})
+	return staleAs(err, "Checklist template has been modified by another request")
+}
+
+// DeleteTemplate removes a template; tasks it created remain
+func (s *ChecklistService) DeleteTemplate(id uint) error {
+	if _, err := s.GetTemplate(id); err != nil {
+		return err
+	}
+	return s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+		return repos.Checklists.DeleteTemplate(id)
+	})
+}
+
+// Start creates an employee's checklist of kind from the current templates,
+// for templates added after the employee joined or left. Onboarding tasks
+// are due relative to the hire date and offboarding tasks relative to today.
+func (s *ChecklistService) Start(employeeID uint, kind string) ([]models.ChecklistTask, error) {
+	var created []models.ChecklistTask
+	err := s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+		employee, err := repos.Employees.FindByID(employeeID)
+		if err != nil {
+			return notFoundAs(err, "Employee not found")
+		}
+		anchor := employee.HireDate
+		if kind == "offboarding" {
+			anchor = time.Now()
+		}
+		created, err = startChecklist(repos, employee, kind, anchor)
+		return err
+	})
+	return created, err
+}
+
+// checkEmployeeAccess lets HR see every employee's checklists, managers their
+// department's and employees their own
+func (s *ChecklistService) checkEmployeeAccess(actor Actor, employeeID uint) error {
+	if actor.IsHR() {
+		return nil
+	}
+	own, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return err
+	}
+	if own.ID == employeeID {
+		return nil
+	}
+	if actor.Role == "manager" {
+		employee, err := s.repos.Employees.FindByID(employeeID)
+		if err != nil {
+			return notFoundAs(err, "Employee not found")
+		}
+		if employee.DepartmentID == own.DepartmentID {
+			return nil
+		}
+	}
+	return newError(ErrForbidden, "You do not have access to this employee's checklists")
+}
+
+// Progress summarises an employee's onboarding and offboarding checklists
+func (s *ChecklistService) Progress(actor Actor, employeeID uint) ([]ChecklistProgress, error) {
+	if _, err := s.repos.Employees.FindByID(employeeID); err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	if err := s.checkEmployeeAccess(actor, employeeID); err != nil {
+		return nil, err
+	}
+	tasks, err := s.repos.Checklists.ListTasks(repositories.ChecklistTaskFilter{EmployeeID: employeeID})
+	if err != nil {
+		return nil, err
+	}
+
+	today := dateOf(time.Now())
+	progress := []ChecklistProgress{}
+	for _, kind := range []string{"onboarding", "offboarding"} {
+		checklist := ChecklistProgress{Kind: kind, Tasks: []models.ChecklistTask{}}
+		for _, task := range tasks {
+			if task.Kind != kind {
+				continue
+			}
+			checklist.Total++
+			checklist.Tasks = append(checklist.Tasks, task)
+			switch {
+			case task.Status == "done":
+				checklist.Done++
+			case task.Status == "skipped":
+				checklist.Skipped++
+			case task.DueDate.Before(today):
+				checklist.Overdue++
+			}
+		}
+		if checklist.Total == 0 {
+			continue
+		}
+		finished := float64(checklist.Done+checklist.Skipped) / float64(checklist.Total)
+		checklist.Percent = math.Round(finished*1000) / 10
+		progress = append(progress, checklist)
+	}
+	return progress, nil
+}
+
+// ListTasks lists tasks matching filter. Only HR sees every task; everyone
+// else sees the tasks assigned to them.
+func (s *ChecklistService) ListTasks(actor Actor, filter repositories.ChecklistTaskFilter) ([]models.ChecklistTask, error) {
+	if !actor.IsHR() {
+		filter.AssigneeUserID = actor.UserID
+	}
+	return s.repos.Checklists.ListTasks(filter)
+}
+
+// GetTask loads a task the actor may work on: HR any task, everyone else the
+// tasks assigned to them
+func (s *ChecklistService) GetTask(actor Actor, id uint) (*models.ChecklistTask, error) {
+	task, err := s.repos.Checklists.FindTask(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Checklist task not found")
+	}
+	if !actor.IsHR() && (task.AssigneeUserID == nil || *task.AssigneeUserID != actor.UserID) {
+		return nil, newError(ErrForbidden, "This task is not assigned to you")
+	}
+	return task, nil
+}
+
+// UpdateTask applies input to task, which must be at the version the caller
+// edited. Completing or skipping a task records who did it and when.
+func (s *ChecklistService) UpdateTask(actor Actor, task *models.ChecklistTask, input ChecklistTaskInput) error {
+	columns := map[string]interface{}{
+		"status": input.Status,
+		"notes":  input.Notes,
+	}
+	if actor.IsHR() {
+		columns["due_date"] = dateOf(input.DueDate)
+	} else if !dateOf(input.DueDate).Equal(dateOf(task.DueDate)) {
+		return newError(ErrForbidden, "Only HR can change due dates")
+	}
+
+	if input.Status != task.Status {
+		if input.Status == "pending" {
+			columns["completed_at"] = nil
+			columns["completed_by"] = nil
+		} else {
+			columns["completed_at"] = time.Now()
+			columns["completed_by"] = actor.UserID
+		}
+	}
+
+	err := s.repos.Checklists.UpdateTask(task, columns)
+	return staleAs(err, "Checklist task has been modified by another request")
+}
+
+// Overdue lists pending tasks past their due date: every task for HR and the
+// tasks of their department's employees for managers
+func (s *ChecklistService) Overdue(actor Actor) ([]repositories.OverdueChecklistTask, error) {
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Checklists.Overdue(scope, dateOf(time.Now()))
+}
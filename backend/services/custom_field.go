@@ -0,0 +1,328 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// customFieldKeyPattern keeps custom field keys usable as JSON members and
+// query parameters
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CustomFieldInput holds the fields of a custom field definition. Entity,
+// Key and Type are fixed once the field exists, since stored values depend
+// on them.
+type CustomFieldInput struct {
+	Entity    string
+	Key       string
+	Label     string
+	Type      string
+	Required  bool
+	Options   []string
+	MaxLength *int
+	Pattern   string
+	Min       *float64
+	Max       *float64
+}
+
+func (in CustomFieldInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"label":      in.Label,
+		"required":   in.Required,
+		"options":    in.Options,
+		"max_length": in.MaxLength,
+		"pattern":    in.Pattern,
+		"min":        in.Min,
+		"max":        in.Max,
+	}
+}
+
+// validate checks that the rules suit a field of fieldType
+func (in CustomFieldInput) validate(fieldType string) error {
+	if fieldType == "enum" {
+		if len(in.Options) == 0 {
+			return newError(ErrInvalid, "Enum fields need at least one option")
+		}
+		seen := make(map[string]bool, len(in.Options))
+		for _, option := range in.Options {
+			if seen[option] {
+				return newError(ErrInvalid, fmt.Sprintf("Option %q is listed twice", option))
+			}
+			seen[option] = true
+		}
+	} else if len(in.Options) > 0 {
+		return newError(ErrInvalid, "Only enum fields take options")
+	}
+
+	if fieldType != "text" && (in.MaxLength != nil || in.Pattern != "") {
+		return newError(ErrInvalid, "Only text fields take a maxLength or pattern")
+	}
+	if in.Pattern != "" {
+		if _, err := regexp.Compile(in.Pattern); err != nil {
+			return newError(ErrInvalid, "pattern is not a valid regular expression")
+		}
+	}
+
+	if fieldType != "number" && (in.Min != nil || in.Max != nil) {
+		return newError(ErrInvalid, "Only number fields take a min or max")
+	}
+	if in.Min != nil && in.Max != nil && *in.Min > *in.Max {
+		return newError(ErrInvalid, "min must not be greater than max")
+	}
+	return nil
+}
+
+// CustomFieldService manages the custom fields administrators define for
+// employees and departments
+type CustomFieldService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+}
+
+func NewCustomFieldService(repos *repositories.Repositories, uow repositories.UnitOfWork) *CustomFieldService {
+	return &CustomFieldService{repos: repos, uow: uow}
+}
+
+// List lists the fields of entity, or every field when entity is empty
+func (s *CustomFieldService) List(entity string) ([]models.CustomField, error) {
+	return s.repos.CustomFields.List(entity)
+}
+
+func (s *CustomFieldService) Get(id uint) (*models.CustomField, error) {
+	field, err := s.repos.CustomFields.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Custom field not found")
+	}
+	return field, nil
+}
+
+func (s *CustomFieldService) Create(input CustomFieldInput) (*models.CustomField, error) {
+	if !customFieldKeyPattern.MatchString(input.Key) {
+		return nil, newError(ErrInvalid, "key must start with a letter and contain only lower-case letters, digits and '_'")
+	}
+	if err := input.validate(input.Type); err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.CustomFields.FindByKey(input.Entity, input.Key); err == nil {
+		return nil, newError(ErrConflict, "A custom field with this key already exists")
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	field := &models.CustomField{
+		Entity:    input.Entity,
+		Key:       input.Key,
+		Label:     input.Label,
+		Type:      input.Type,
+		Required:  input.Required,
+		Options:   input.Options,
+		MaxLength: input.MaxLength,
+		Pattern:   input.Pattern,
+		Min:       input.Min,
+		Max:       input.Max,
+	}
+	if err := s.repos.CustomFields.Create(field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// Update applies input to field, which must be at the version the caller
+// edited. Stored values are checked against the new rules when their record
+// is next saved.
+func (s *CustomFieldService) Update(field *models.CustomField, input CustomFieldInput) error {
+	if err := input.validate(field.Type); err != nil {
+		return err
+	}
+	err := s.repos.CustomFields.Update(field, input.columns())
+	return staleAs(err, "Custom field has been modified by another request")
+}
+
+// Delete removes a field together with its values
+func (s *CustomFieldService) Delete(id uint) error {
+	return s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+		field, err := repos.CustomFields.FindByID(id)
+		if err != nil {
+			return notFoundAs(err, "Custom field not found")
+		}
+		return repos.CustomFields.Delete(field)
+	})
+}
+
+// checkCustomValues checks the values of a new record against the fields
+// defined for entity and returns them as stored: text trimmed, numbers as
+// float64 and dates as 2006-01-02. Null and empty values are left out, so
+// required fields must have a value.
+func checkCustomValues(fields repositories.CustomFieldRepository, entity string, values models.CustomValues) (models.CustomValues, error) {
+	return checkCustom(fields, entity, values, func(models.CustomField) bool { return true })
+}
+
+// checkCustomUpdate is checkCustomValues for an update of a record holding
+// previous. A required field may not be cleared, but records saved before it
+// was added can be updated without giving it a value.
+func checkCustomUpdate(fields repositories.CustomFieldRepository, entity string, values, previous models.CustomValues) (models.CustomValues, error) {
+	return checkCustom(fields, entity, values, func(field models.CustomField) bool {
+		_, had := previous[field.Key]
+		return had
+	})
+}
+
+// checkCustom checks and normalizes values, insisting on the required fields
+// that enforce selects
+func checkCustom(fields repositories.CustomFieldRepository, entity string, values models.CustomValues, enforce func(models.CustomField) bool) (models.CustomValues, error) {
+	defined, err := fields.List(entity)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.CustomField, len(defined))
+	for _, field := range defined {
+		byKey[field.Key] = field
+	}
+
+	checked := models.CustomValues{}
+	for key, value := range values {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, newError(ErrInvalid, fmt.Sprintf("Unknown custom field %q", key))
+		}
+		if value == nil {
+			continue
+		}
+		normalized, err := checkCustomValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		if normalized != "" {
+			checked[key] = normalized
+		}
+	}
+
+	for _, field := range defined {
+		if _, ok := checked[field.Key]; field.Required && !ok && enforce(field) {
+			return nil, newError(ErrInvalid, fmt.Sprintf("Custom field %s is required", field.Key))
+		}
+	}
+	return checked, nil
+}
+
+// checkCustomValue validates one value, returning "" for an empty text value
+func checkCustomValue(field models.CustomField, value interface{}) (interface{}, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return newError(ErrInvalid, "Custom field "+field.Key+" "+fmt.Sprintf(format, args...))
+	}
+
+	if field.Type == "number" {
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case json.Number:
+			parsed, err := v.Float64()
+			if err != nil {
+				return nil, invalid("must be a number")
+			}
+			number = parsed
+		default:
+			return nil, invalid("must be a number")
+		}
+		if field.Min != nil && number < *field.Min {
+			return nil, invalid("must be at least %s", formatNumber(*field.Min))
+		}
+		if field.Max != nil && number > *field.Max {
+			return nil, invalid("must be at most %s", formatNumber(*field.Max))
+		}
+		return number, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, invalid("must be a string")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", nil
+	}
+
+	switch field.Type {
+	case "date":
+		date, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			return nil, invalid("must be a date such as 2024-06-30")
+		}
+		return date.Format("2006-01-02"), nil
+	case "enum":
+		for _, option := range field.Options {
+			if text == option {
+				return text, nil
+			}
+		}
+		return nil, invalid("must be one of %s", strings.Join(field.Options, ", "))
+	default:
+		if field.MaxLength != nil && utf8.RuneCountInString(text) > *field.MaxLength {
+			return nil, invalid("must be at most %d characters", *field.MaxLength)
+		}
+		if field.Pattern != "" {
+			pattern, err := regexp.Compile(`^(?:` + field.Pattern + `)$`)
+			if err != nil {
+				return nil, err
+			}
+			if !pattern.MatchString(text) {
+				return nil, invalid("does not match the required format")
+			}
+		}
+		return text, nil
+	}
+}
+
+// checkCustomFilters checks list filters against the fields defined for
+// entity and writes their values as stored values read as text
+func checkCustomFilters(fields repositories.CustomFieldRepository, entity string, filters map[string]string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	checked := make(map[string]string, len(filters))
+	for key, value := range filters {
+		field, err := fields.FindByKey(entity, key)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, newError(ErrInvalid, fmt.Sprintf("Unknown custom field %q", key))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch field.Type {
+		case "number":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, newError(ErrInvalid, fmt.Sprintf("Filter %s must be a number", key))
+			}
+			value = formatNumber(number)
+		case "date":
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return nil, newError(ErrInvalid, fmt.Sprintf("Filter %s must be a date such as 2024-06-30", key))
+			}
+			value = date.Format("2006-01-02")
+		}
+		checked[key] = value
+	}
+	return checked, nil
+}
+
+// formatNumber writes a number the way it is stored in JSON
+func formatNumber(number float64) string {
+	data, err := json.Marshal(number)
+	if err != nil {
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return string(data)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+)
+
+// DepartmentInput holds the writable fields of a department
+type DepartmentInput struct {
+	Name        string
+	Description string
+	ManagerID   *uint
+	// CustomFields holds values for the custom fields defined for departments
+	CustomFields models.CustomValues
+}
+
+// departmentWritableFields is the mass-assignment allowlist for department updates
+var departmentWritableFields = []string{"name", "description", "manager_id", "custom_fields"}
+
+func (in DepartmentInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":          in.Name,
+		"description":   in.Description,
+		"manager_id":    in.ManagerID,
+		"custom_fields": in.CustomFields,
+	}
+}
+
+type DepartmentService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+}
+
+func NewDepartmentService(repos *repositories.Repositories, uow repositories.UnitOfWork) *DepartmentService {
+	return &DepartmentService{repos: repos, uow: uow}
+}
+
+// List lists departments, only those whose custom values equal custom when set
+func (s *DepartmentService) List(custom map[string]string) ([]repositories.DepartmentSummary, error) {
+	filters, err := checkCustomFilters(s.repos.CustomFields, "department", custom)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Departments.List(filters)
+}
+
+// Summary loads a department with its head's name and headcount
+func (s *DepartmentService) Summary(id uint) (*repositories.DepartmentSummary, error) {
+	department, err := s.repos.Departments.Summary(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Department not found")
+	}
+	return department, nil
+}
+
+func (s *DepartmentService) Get(id uint) (*models.Department, error) {
+	department, err := s.repos.Departments.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Department not found")
+	}
+	return department, nil
+}
+
+func (s *DepartmentService) Create(input DepartmentInput) (*models.Department, error) {
+	custom, err := checkCustomValues(s.repos.CustomFields, "department", input.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	department := &models.Department{
+		Name:         input.Name,
+		Description:  input.Description,
+		ManagerID:    input.ManagerID,
+		CustomFields: custom,
+	}
+	if err := s.repos.Departments.Create(department); err != nil {
+		return nil, err
+	}
+	return department, nil
+}
+
+// Update applies input to department, which must be at the version the caller edited
+func (s *DepartmentService) Update(department *models.Department, input DepartmentInput) error {
+	custom, err := checkCustomUpdate(s.repos.CustomFields, "department", input.CustomFields, department.CustomFields)
+	if err != nil {
+		return err
+	}
+	input.CustomFields = custom
+	err = s.repos.Departments.Update(department, permitted(input.columns(), departmentWritableFields))
+	return staleAs(err, "Department has been modified by another request")
+}
+
+// Delete removes a department. A department that still has employees is only
+// deleted when reassignTo names another department to move them to, so no
+// employee is left pointing at a deleted department.
+func (s *DepartmentService) Delete(id uint, reassignTo *uint) error {
+	if reassignTo != nil && *reassignTo == id {
+		return newError(ErrInvalid, "Cannot reassign employees to the department being deleted")
+	}
+
+	// Serializable so an employee cannot join the department between the
+	// check and the delete
+	return s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+		if _, err := repos.Departments.FindByID(id); err != nil {
+			return notFoundAs(err, "Department not found")
+		}
+
+		headcount, err := repos.Departments.CountEmployees(id)
+		if err != nil {
+			return err
+		}
+		if headcount > 0 {
+			if reassignTo == nil {
+				return newError(ErrConflict, fmt.Sprintf(
+					"Department still has %d employees; reassign them before deleting it", headcount))
+			}
+			if _, err := repos.Departments.FindByID(*reassignTo); errors.Is(err, repositories.ErrNotFound) {
+				return newError(ErrInvalid, "reassignTo must be an existing department")
+			} else if err != nil {
+				return err
+			}
+			if err := repos.Employees.ReassignDepartment(id, *reassignTo); err != nil {
+				return err
+			}
+		}
+
+		return repos.Departments.Delete(id)
+	})
+}
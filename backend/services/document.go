@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/storage"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// sniffLength is how much of an upload is read to detect its type
+const sniffLength = 3072
+
+// documentTypes are the file types accepted as documents. Anything a browser
+// could render as a page, such as HTML or SVG, is deliberately left out.
+var documentTypes = []string{
+	"application/pdf",
+	"image/png",
+	"image/jpeg",
+	"image/webp",
+	"text/plain",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// DocumentUpload describes a file uploaded for an employee. Size is the
+// length the client declared; Content must hold exactly that many bytes.
+type DocumentUpload struct {
+	Name       string
+	Category   string
+	Visibility string
+	ExpiresAt  *time.Time
+	Size       int64
+	Content    io.Reader
+}
+
+// DocumentInput holds the editable fields of a document
+type DocumentInput struct {
+	Name       string
+	Category   string
+	Visibility string
+	ExpiresAt  *time.Time
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// acceptedType reports whether a sniffed type, or one it is a kind of, is
+// one of the documentTypes
+func acceptedType(detected *mimetype.MIME) bool {
+	for _, t := range documentTypes {
+		if detected.Is(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func newStorageKey(employeeID uint) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("employees/%d/%s", employeeID, hex.EncodeToString(random)), nil
+}
+
+type DocumentService struct {
+	repos   *repositories.Repositories
+	store   storage.Store
+	maxSize int64
+}
+
+func NewDocumentService(repos *repositories.Repositories, store storage.Store, maxSize int64) *DocumentService {
+	return &DocumentService{repos: repos, store: store, maxSize: maxSize}
+}
+
+// MaxSize is the largest document accepted, in bytes
+func (s *DocumentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// visibleTo returns the visibilities of an employee's documents the actor may
+// see, or nil for HR, who sees them all. Employees see their own documents
+// shared with them and managers also their department's documents shared
+// with managers.
+func (s *DocumentService) visibleTo(actor Actor, employee *models.Employee) ([]string, error) {
+	if actor.IsHR() {
+		return nil, nil
+	}
+	own, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role == "manager" && own.DepartmentID == employee.DepartmentID {
+		return []string{"manager", "employee"}, nil
+	}
+	if own.ID == employee.ID {
+		return []string{"employee"}, nil
+	}
+	return nil, newError(ErrForbidden, "You do not have access to this employee's documents")
+}
+
+// List lists the documents of an employee the actor may see
+func (s *DocumentService) List(actor Actor, employeeID uint) ([]models.Document, error) {
+	employee, err := s.repos.Employees.FindByID(employeeID)
+	if err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	visibilities, err := s.visibleTo(actor, employee)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Documents.ListByEmployee(employeeID, visibilities)
+}
+
+// Get loads a document the actor may see. Documents hidden from the actor are
+// reported as missing so their existence does not leak.
+func (s *DocumentService) Get(actor Actor, id uint) (*models.Document, error) {
+	document, err := s.repos.Documents.Find(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Document not found")
+	}
+	employee, err := s.repos.Employees.FindByID(document.EmployeeID)
+	if err != nil {
+		return nil, notFoundAs(err, "Document not found")
+	}
+	visibilities, err := s.visibleTo(actor, employee)
+	if errors.Is(err, ErrForbidden) {
+		return nil, newError(ErrNotFound, "Document not found")
+	} else if err != nil {
+		return nil, err
+	}
+	if visibilities == nil {
+		return document, nil
+	}
+	for _, visibility := range visibilities {
+		if visibility == document.Visibility {
+			return document, nil
+		}
+	}
+	return nil, newError(ErrNotFound, "Document not found")
+}
+
+// Open loads a document the actor may see and opens its contents. The caller
+// closes the reader.
+func (s *DocumentService) Open(ctx context.Context, actor Actor, id uint) (*models.Document, io.ReadCloser, error) {
+	document, err := s.Get(actor, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(ctx, document.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, newError(ErrNotFound, "Document contents are missing from storage")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return document, content, nil
+}
+
+// Upload stores a file against an employee. HR may upload for anyone;
+// employees upload for themselves, and their uploads are always visible to
+// them. The type is sniffed from the contents rather than trusted from the
+// client, and the SHA-256 checksum is recorded.
+func (s *DocumentService) Upload(ctx context.Context, actor Actor, employeeID uint, upload DocumentUpload) (*models.Document, error) {
+	employee, err := s.repos.Employees.FindByID(employeeID)
+	if err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	if !actor.IsHR() {
+		own, err := employeeOf(s.repos.Users, actor)
+		if err != nil {
+			return nil, err
+		}
+		if own.ID != employee.ID {
+			return nil, newError(ErrForbidden, "You can only upload your own documents")
+		}
+		upload.Visibility = "employee"
+	}
+	if upload.Size > s.maxSize {
+		return nil, newError(ErrTooLarge, fmt.Sprintf("Documents must be at most %d bytes", s.maxSize))
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, newError(ErrInvalid, "The file is empty")
+	}
+	detected := mimetype.Detect(head[:n])
+	if !acceptedType(detected) {
+		return nil, newError(ErrUnsupportedType, fmt.Sprintf("Files of type %s are not accepted", detected.String()))
+	}
+
+	key, err := newStorageKey(employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	counter := &countingWriter{}
+	content := io.TeeReader(io.MultiReader(bytes.NewReader(head[:n]), upload.Content), io.MultiWriter(hash, counter))
+	if err := s.store.Put(ctx, key, content, upload.Size, detected.String()); err != nil {
+		return nil, err
+	}
+
+	document := &models.Document{
+		EmployeeID:  employee.ID,
+		Category:    upload.Category,
+		Name:        upload.Name,
+		ContentType: detected.String(),
+		Size:        counter.n,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+		Visibility:  upload.Visibility,
+		ExpiresAt:   upload.ExpiresAt,
+		UploadedBy:  actor.UserID,
+	}
+	if counter.n != upload.Size {
+		err = newError(ErrInvalid, "The file is shorter than its declared size")
+	} else {
+		err = s.repos.Documents.Create(document)
+	}
+	if err != nil {
+		s.discard(key)
+		return nil, err
+	}
+	return document, nil
+}
+
+// discard deletes a stored object that no document refers to. A failure
+// only leaves an orphaned object behind, so it is logged rather than returned.
+func (s *DocumentService) discard(key string) {
+	if err := s.store.Delete(context.Background(), key); err != nil {
+		log.Printf("Failed to delete orphaned document %s: %v", key, err)
+	}
+}
+
+// Update applies input to document, which must be at the version the caller
+// edited. The contents cannot be changed; upload a new document instead.
+func (s *DocumentService) Update(document *models.Document, input DocumentInput) error {
+	err := s.repos.Documents.Update(document, map[string]interface{}{
+		"name":       input.Name,
+		"category":   input.Category,
+		"visibility": input.Visibility,
+		"expires_at": input.ExpiresAt,
+	})
+	return staleAs(err, "Document has been modified by another request")
+}
+
+// Delete removes a document the actor may see. HR may delete any document and
+// everyone else only the documents they uploaded.
+func (s *DocumentService) Delete(actor Actor, id uint) error {
+	document, err := s.Get(actor, id)
+	if err != nil {
+		return err
+	}
+	if !actor.IsHR() && document.UploadedBy != actor.UserID {
+		return newError(ErrForbidden, "You can only delete documents you uploaded")
+	}
+	if err := s.repos.Documents.Delete(document.ID); err != nil {
+		return err
+	}
+	s.discard(document.StorageKey)
+	return nil
+}
+
+// Expiring lists documents that expire within days, including those already
+// expired: every document for HR and their department's documents shared
+// with managers for managers
+func (s *DocumentService) Expiring(actor Actor, days int) ([]repositories.ExpiringDocument, error) {
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	var visibilities []string
+	switch {
+	case actor.Role == "manager":
+		visibilities = []string{"manager", "employee"}
+	case !actor.IsHR():
+		visibilities = []string{"employee"}
+	}
+	return s.repos.Documents.Expiring(scope, visibilities, dateOf(time.Now()).AddDate(0, 0, days+1))
+}
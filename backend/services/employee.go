@@ -0,0 +1,350 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/utils"
+	"time"
+)
+
+// EmployeeInput holds the writable fields of an employee
+type EmployeeInput struct {
+	EmployeeCode string
+	FirstName    string
+	LastName     string
+	Email        string
+	Phone        string
+	Address      string
+	DateOfBirth  *time.Time
+	HireDate     time.Time
+	Salary       float64
+	Position     string
+	Status       string
+	DepartmentID uint
+	ManagerID    *uint
+	// CustomFields holds values for the custom fields defined for employees
+	CustomFields models.CustomValues
+	// Account, when set on creation, also opens a login for the employee
+	Account *AccountInput
+}
+
+// AccountInput holds the login created together with a new employee. The
+// account takes the employee's email and name.
+type AccountInput struct {
+	Password string
+	Role     string
+}
+
+// employeeWritableFields is the mass-assignment allowlist for employee updates
+var employeeWritableFields = []string{
+	"employee_code", "first_name", "last_name", "email", "phone", "address",
+	"date_of_birth", "hire_date", "salary", "position", "status", "department_id", "manager_id",
+	"custom_fields",
+}
+
+func (in EmployeeInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"employee_code": in.EmployeeCode,
+		"first_name":    in.FirstName,
+		"last_name":     in.LastName,
+		"email":         in.Email,
+		"phone":         in.Phone,
+		"address":       in.Address,
+		"date_of_birth": in.DateOfBirth,
+		"hire_date":     in.HireDate,
+		"salary":        in.Salary,
+		"position":      in.Position,
+		"status":        in.Status,
+		"department_id": in.DepartmentID,
+		"manager_id":    in.ManagerID,
+		"custom_fields": in.CustomFields,
+	}
+}
+
+type EmployeeService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+	// codes generates the codes of employees created without one; when nil,
+	// every employee needs a code
+	codes *utils.CodePattern
+}
+
+func NewEmployeeService(repos *repositories.Repositories, uow repositories.UnitOfWork, codes *utils.CodePattern) *EmployeeService {
+	return &EmployeeService{repos: repos, uow: uow, codes: codes}
+}
+
+// CodeMismatch is an employee whose code the code pattern could not have
+// generated
+type CodeMismatch struct {
+	ID           uint
+	EmployeeCode string
+	Name         string
+}
+
+// CodePattern returns the pattern employee codes are generated from, or ""
+// when codes are entered by hand
+func (s *EmployeeService) CodePattern() string {
+	if s.codes == nil {
+		return ""
+	}
+	return s.codes.String()
+}
+
+// CodeMismatches lists the employees whose codes do not fit the code pattern,
+// such as codes entered by hand or before the pattern was set. It lists none
+// when there is no pattern.
+func (s *EmployeeService) CodeMismatches() ([]CodeMismatch, error) {
+	mismatches := []CodeMismatch{}
+	if s.codes == nil {
+		return mismatches, nil
+	}
+	employees, err := s.repos.Employees.List(nil)
+	if err != nil {
+		return nil, err
+	}
+	valid := s.codes.Regexp()
+	for _, employee := range employees {
+		if !valid.MatchString(employee.EmployeeCode) {
+			mismatches = append(mismatches, CodeMismatch{
+				ID:           employee.ID,
+				EmployeeCode: employee.EmployeeCode,
+				Name:         employee.FirstName + " " + employee.LastName,
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// List lists employees, only those whose custom values equal custom when set
+func (s *EmployeeService) List(custom map[string]string) ([]repositories.EmployeeSummary, error) {
+	filters, err := checkCustomFilters(s.repos.CustomFields, "employee", custom)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Employees.List(filters)
+}
+
+func (s *EmployeeService) Get(id uint) (*models.Employee, error) {
+	employee, err := s.repos.Employees.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	return employee, nil
+}
+
+// Create adds an employee and, when input.Account is set, their login
+// account. Both are written in one transaction so neither exists without the
+// other.
+func (s *EmployeeService) Create(actor Actor, input EmployeeInput) (*models.Employee, error) {
+	if input.Status == "" {
+		input.Status = "active"
+	}
+
+	// Hash before opening the transaction; bcrypt is deliberately slow
+	var password string
+	if input.Account != nil {
+		if err := checkRoleGrant(actor, input.Account.Role); err != nil {
+			return nil, err
+		}
+		hashed, err := hashPassword(input.Account.Password)
+		if err != nil {
+			return nil, err
+		}
+		password = hashed
+	}
+
+	var created *models.Employee
+	err := s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+		employee, err := createEmployee(repos, s.codes, input, password)
+		created = employee
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Import adds many employees, without accounts, in one transaction: either
+// every employee is created or none is. Errors name the employee code, or
+// the email of employees whose code is to be generated.
+func (s *EmployeeService) Import(inputs []EmployeeInput) ([]*models.Employee, error) {
+	var created []*models.Employee
+	err := s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+		created = created[:0]
+		for _, input := range inputs {
+			if input.Status == "" {
+				input.Status = "active"
+			}
+			input.Account = nil
+			employee, err := createEmployee(repos, s.codes, input, "")
+			if err != nil {
+				name := input.EmployeeCode
+				if name == "" {
+					name = input.Email
+				}
+				return fmt.Errorf("employee %s: %w", name, err)
+			}
+			created = append(created, employee)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// createEmployee checks the custom values, generates a code from codes when
+// input has none and writes an employee with their hire record and, when
+// input.Account is set, their account with the already hashed password, then
+// starts their onboarding checklist
+func createEmployee(repos *repositories.Repositories, codes *utils.CodePattern, input EmployeeInput, password string) (*models.Employee, error) {
+	if input.Status == "terminated" {
+		// A termination needs a date, reason and offboarding that creation skips
+		return nil, newError(ErrInvalid, "Employees cannot be created as terminated; terminate them through POST /employees/:id/terminate")
+	}
+	custom, err := checkCustomValues(repos.CustomFields, "employee", input.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	code := input.EmployeeCode
+	if code == "" {
+		if code, err = nextEmployeeCode(repos, codes, input); err != nil {
+			return nil, err
+		}
+	}
+	employee := &models.Employee{
+		EmployeeCode: code,
+		FirstName:    input.FirstName,
+		LastName:     input.LastName,
+		Email:        input.Email,
+		Phone:        input.Phone,
+		Address:      input.Address,
+		DateOfBirth:  input.DateOfBirth,
+		HireDate:     input.HireDate,
+		Salary:       input.Salary,
+		Position:     input.Position,
+		Status:       input.Status,
+		DepartmentID: input.DepartmentID,
+		ManagerID:    input.ManagerID,
+		CustomFields: custom,
+	}
+	if err := repos.Employees.Create(employee); err != nil {
+		return nil, err
+	}
+	if err := repos.Employment.Create(hireRecord(employee)); err != nil {
+		return nil, err
+	}
+	if input.Account != nil {
+		if employee, err = createAccount(repos, employee, input.Account, password); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := startChecklist(repos, employee, "onboarding", employee.HireDate); err != nil {
+		return nil, err
+	}
+	return employee, nil
+}
+
+// nextEmployeeCode generates the code of a new employee from codes, skipping
+// numbers already taken by codes entered by hand
+func nextEmployeeCode(repos *repositories.Repositories, codes *utils.CodePattern, input EmployeeInput) (string, error) {
+	if codes == nil {
+		return "", newError(ErrInvalid, "employeeCode is required")
+	}
+	department, err := repos.Departments.FindByID(input.DepartmentID)
+	if err != nil {
+		return "", notFoundAs(err, "Department not found")
+	}
+
+	values := utils.CodeValues{Department: department.Name, Date: input.HireDate}
+	scope := codes.Scope(values)
+	for {
+		seq, err := repos.CodeSequences.Next(scope)
+		if err != nil {
+			return "", err
+		}
+		code := codes.Code(values, seq)
+		taken, err := repos.Employees.CodeExists(code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+}
+
+// createAccount gives a new employee a user account and reloads the employee
+// to pick it up
+func createAccount(repos *repositories.Repositories, employee *models.Employee, account *AccountInput, password string) (*models.Employee, error) {
+	if _, err := repos.Users.FindByEmail(employee.Email); err == nil {
+		return nil, newError(ErrConflict, "A user account with this email already exists")
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	role := account.Role
+	if role == "" {
+		role = "employee"
+	}
+	user := &models.User{
+		Email:      employee.Email,
+		Password:   password,
+		FirstName:  employee.FirstName,
+		LastName:   employee.LastName,
+		Role:       role,
+		IsActive:   true,
+		EmployeeID: &employee.ID,
+	}
+	if err := repos.Users.Create(user); err != nil {
+		return nil, err
+	}
+
+	return repos.Employees.FindByID(employee.ID)
+}
+
+// Update applies input to employee, which must be at the version the caller
+// edited. A new manager must not report to the employee. Changes to the job
+// are recorded in the employment history as taking effect today. Employees
+// are terminated through TerminationService, never by an update.
+func (s *EmployeeService) Update(employee *models.Employee, input EmployeeInput) error {
+	if input.ManagerID != nil && *input.ManagerID == employee.ID {
+		return newError(ErrInvalid, "An employee cannot be their own manager")
+	}
+	if input.Status != employee.Status && (input.Status == "terminated" || employee.Status == "terminated") {
+		return newError(ErrInvalid, "Status cannot be changed to or from terminated by an update; terminate employees through POST /employees/:id/terminate")
+	}
+
+	before := *employee
+	err := s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+		if !sameManager(before.ManagerID, input.ManagerID) {
+			if err := checkReportingLine(repos.Employees, before.ID, input.ManagerID); err != nil {
+				return err
+			}
+		}
+		custom, err := checkCustomUpdate(repos.CustomFields, "employee", input.CustomFields, before.CustomFields)
+		if err != nil {
+			return err
+		}
+		input.CustomFields = custom
+		updated := before
+		if err := repos.Employees.Update(&updated, permitted(input.columns(), employeeWritableFields)); err != nil {
+			return err
+		}
+		if err := recordEdit(repos, &before, &updated); err != nil {
+			return err
+		}
+		*employee = updated
+		return nil
+	})
+	return staleAs(err, "Employee has been modified by another request")
+}
+
+func (s *EmployeeService) Delete(id uint) error {
+	return s.repos.Employees.Delete(id)
+}
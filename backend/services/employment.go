@@ -0,0 +1,313 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"time"
+)
+
+// EmploymentChangeInput holds a change to an employee's job, pay or reporting
+// line. Nil fields keep their current value.
+type EmploymentChangeInput struct {
+	EffectiveDate time.Time
+	Reason        string
+	Position      *string
+	Salary        *float64
+	DepartmentID  *uint
+	ManagerID     *uint
+	// ClearManager removes the employee's manager
+	ClearManager bool
+}
+
+// EmploymentSnapshot is an employee's job as it stood on a date
+type EmploymentSnapshot struct {
+	EmployeeID   uint
+	AsOf         time.Time
+	Position     string
+	Salary       float64
+	DepartmentID uint
+	ManagerID    *uint
+	// Since is the effective date of the latest change the snapshot includes
+	Since time.Time
+}
+
+// dateOf drops the time of day, keeping the calendar date of t
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// snapshotOf applies changes, in the order they take effect, on top of each
+// other. It returns nil when there are none, i.e. before the hire date.
+func snapshotOf(employeeID uint, asOf time.Time, changes []models.EmploymentChange) *EmploymentSnapshot {
+	if len(changes) == 0 {
+		return nil
+	}
+	snapshot := &EmploymentSnapshot{EmployeeID: employeeID, AsOf: asOf}
+	for _, change := range changes {
+		if change.Position != nil {
+			snapshot.Position = *change.Position
+		}
+		if change.Salary != nil {
+			snapshot.Salary = *change.Salary
+		}
+		if change.DepartmentID != nil {
+			snapshot.DepartmentID = *change.DepartmentID
+		}
+		if change.ManagerID != nil {
+			managerID := *change.ManagerID
+			snapshot.ManagerID = &managerID
+		}
+		if change.ClearManager {
+			snapshot.ManagerID = nil
+		}
+		snapshot.Since = change.EffectiveDate
+	}
+	return snapshot
+}
+
+// hireRecord is the history entry holding a new employee's starting job
+func hireRecord(employee *models.Employee) *models.EmploymentChange {
+	now := time.Now()
+	return &models.EmploymentChange{
+		EmployeeID:    employee.ID,
+		EffectiveDate: dateOf(employee.HireDate),
+		Kind:          "hire",
+		Status:        "applied",
+		Reason:        "Hired",
+		Position:      &employee.Position,
+		Salary:        &employee.Salary,
+		DepartmentID:  &employee.DepartmentID,
+		ManagerID:     employee.ManagerID,
+		AppliedAt:     &now,
+	}
+}
+
+// recordEdit keeps the history in step with a direct edit of the employee
+// record: job fields that changed are recorded as a change effective today,
+// and a new hire date moves the hire record
+func recordEdit(repos *repositories.Repositories, before, after *models.Employee) error {
+	if !dateOf(before.HireDate).Equal(dateOf(after.HireDate)) {
+		if err := repos.Employment.MoveHire(after.ID, dateOf(after.HireDate)); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	change := &models.EmploymentChange{
+		EmployeeID:    after.ID,
+		EffectiveDate: dateOf(now),
+		Kind:          "change",
+		Status:        "applied",
+		Reason:        "Edited on the employee record",
+		AppliedAt:     &now,
+	}
+	changed := false
+	if before.Position != after.Position {
+		change.Position, changed = &after.Position, true
+	}
+	if before.Salary != after.Salary {
+		change.Salary, changed = &after.Salary, true
+	}
+	if before.DepartmentID != after.DepartmentID {
+		change.DepartmentID, changed = &after.DepartmentID, true
+	}
+	switch {
+	case after.ManagerID == nil && before.ManagerID != nil:
+		change.ClearManager, changed = true, true
+	case !sameManager(before.ManagerID, after.ManagerID):
+		change.ManagerID, changed = after.ManagerID, true
+	}
+	if !changed {
+		return nil
+	}
+	return repos.Employment.Create(change)
+}
+
+// EmploymentService records and answers questions about employment history.
+// The employee record always holds the job as of today; changes dated in the
+// future are scheduled and written to it by ApplyDue once they take effect.
+type EmploymentService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+}
+
+func NewEmploymentService(repos *repositories.Repositories, uow repositories.UnitOfWork) *EmploymentService {
+	return &EmploymentService{repos: repos, uow: uow}
+}
+
+// History lists every recorded change of an employee, scheduled and
+// cancelled ones included
+func (s *EmploymentService) History(employeeID uint) ([]models.EmploymentChange, error) {
+	if _, err := s.repos.Employees.FindByID(employeeID); err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	return s.repos.Employment.ListByEmployee(employeeID)
+}
+
+// AsOf returns the employee's job on date, taking scheduled changes into
+// account for future dates
+func (s *EmploymentService) AsOf(employeeID uint, date time.Time) (*EmploymentSnapshot, error) {
+	if _, err := s.repos.Employees.FindByID(employeeID); err != nil {
+		return nil, notFoundAs(err, "Employee not found")
+	}
+	date = dateOf(date)
+	changes, err := s.repos.Employment.ListEffective(employeeID, date)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := snapshotOf(employeeID, date, changes)
+	if snapshot == nil {
+		return nil, newError(ErrNotFound, "The employee had not been hired by that date")
+	}
+	return snapshot, nil
+}
+
+// Record adds a change to an employee's history. A change effective today or
+// earlier is applied to the employee record at once; a later one is scheduled.
+func (s *EmploymentService) Record(actor Actor, employeeID uint, input EmploymentChangeInput) (*models.EmploymentChange, error) {
+	if input.Position == nil && input.Salary == nil && input.DepartmentID == nil && input.ManagerID == nil && !input.ClearManager {
+		return nil, newError(ErrInvalid, "A change must set a position, salary, department or manager")
+	}
+	if input.ManagerID != nil && input.ClearManager {
+		return nil, newError(ErrInvalid, "A change cannot both set and clear the manager")
+	}
+
+	effective := dateOf(input.EffectiveDate)
+	var recorded *models.EmploymentChange
+	err := s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+		employee, err := repos.Employees.FindByID(employeeID)
+		if err != nil {
+			return notFoundAs(err, "Employee not found")
+		}
+		if effective.Before(dateOf(employee.HireDate)) {
+			return newError(ErrInvalid, "A change cannot take effect before the hire date")
+		}
+		if err := checkReportingLine(repos.Employees, employeeID, input.ManagerID); err != nil {
+			return err
+		}
+
+		change := &models.EmploymentChange{
+			EmployeeID:    employeeID,
+			EffectiveDate: effective,
+			Kind:          "change",
+			Status:        "scheduled",
+			Reason:        input.Reason,
+			Position:      input.Position,
+			Salary:        input.Salary,
+			DepartmentID:  input.DepartmentID,
+			ManagerID:     input.ManagerID,
+			ClearManager:  input.ClearManager,
+		}
+		if actor.UserID != 0 {
+			recordedBy := actor.UserID
+			change.RecordedBy = &recordedBy
+		}
+		now := time.Now()
+		today := dateOf(now)
+		if !effective.After(today) {
+			change.Status, change.AppliedAt = "applied", &now
+		}
+		if err := repos.Employment.Create(change); err != nil {
+			return err
+		}
+		recorded = change
+
+		if change.Status == "scheduled" {
+			return nil
+		}
+		return syncEmployee(repos, employee, today)
+	})
+	if err != nil {
+		return nil, staleAs(err, "Employee has been modified by another request")
+	}
+	return recorded, nil
+}
+
+// Cancel withdraws a scheduled change before it takes effect
+func (s *EmploymentService) Cancel(employeeID, changeID uint) error {
+	change, err := s.repos.Employment.FindByID(changeID)
+	if err != nil {
+		return notFoundAs(err, "Employment change not found")
+	}
+	if change.EmployeeID != employeeID {
+		return newError(ErrNotFound, "Employment change not found")
+	}
+	if change.Status != "scheduled" {
+		return newError(ErrConflict, "Only scheduled changes can be cancelled")
+	}
+
+	err = s.repos.Employment.Cancel(changeID)
+	if errors.Is(err, repositories.ErrStale) {
+		return newError(ErrConflict, "Only scheduled changes can be cancelled")
+	}
+	return err
+}
+
+// ApplyDue writes scheduled changes taking effect on or before date to their
+// employees' records and returns how many employees were updated. Running it
+// again, or on several servers at once, applies nothing twice.
+func (s *EmploymentService) ApplyDue(date time.Time) (int, error) {
+	date = dateOf(date)
+	ids, err := s.repos.Employment.DueEmployeeIDs(date)
+	if err != nil {
+		return 0, err
+	}
+
+	var applied int
+	var failures []error
+	for _, id := range ids {
+		err := s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+			if err := repos.Employment.MarkApplied(id, date, time.Now()); err != nil {
+				return err
+			}
+			employee, err := repos.Employees.FindByID(id)
+			if errors.Is(err, repositories.ErrNotFound) {
+				// The employee was deleted; there is no record left to update
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return syncEmployee(repos, employee, date)
+		})
+		if err != nil {
+			failures = append(failures, fmt.Errorf("employee %d: %w", id, err))
+			continue
+		}
+		applied++
+	}
+	return applied, errors.Join(failures...)
+}
+
+// syncEmployee writes the job as of date from the history to the employee
+// record, leaving the record alone when it already matches
+func syncEmployee(repos *repositories.Repositories, employee *models.Employee, date time.Time) error {
+	changes, err := repos.Employment.ListEffective(employee.ID, date)
+	if err != nil {
+		return err
+	}
+	snapshot := snapshotOf(employee.ID, date, changes)
+	if snapshot == nil {
+		return nil
+	}
+
+	unchangedManager := sameManager(snapshot.ManagerID, employee.ManagerID)
+	if snapshot.Position == employee.Position && snapshot.Salary == employee.Salary &&
+		snapshot.DepartmentID == employee.DepartmentID && unchangedManager {
+		return nil
+	}
+	if !unchangedManager {
+		if err := checkReportingLine(repos.Employees, employee.ID, snapshot.ManagerID); err != nil {
+			return err
+		}
+	}
+	return repos.Employees.Update(employee, map[string]interface{}{
+		"position":      snapshot.Position,
+		"salary":        snapshot.Salary,
+		"department_id": snapshot.DepartmentID,
+		"manager_id":    snapshot.ManagerID,
+	})
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FeatureManagerLeaveApproval lets managers decide leave requests from their
+// own department instead of leaving every decision to HR
+const FeatureManagerLeaveApproval = "manager_leave_approval"
+
+// featureKeyPattern keeps flag keys short, lower-case and URL-safe
+var featureKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,99}$`)
+
+// FeatureFlagInput holds the editable fields of a feature flag
+type FeatureFlagInput struct {
+	Key           string
+	Description   string
+	Enabled       bool
+	Roles         []string
+	DepartmentIDs []uint
+	UserIDs       []uint
+}
+
+// columns maps the input onto feature flag columns. The key is fixed once the
+// flag exists, since code refers to it.
+func (in FeatureFlagInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"description":    in.Description,
+		"enabled":        in.Enabled,
+		"roles":          in.Roles,
+		"department_ids": in.DepartmentIDs,
+		"user_ids":       in.UserIDs,
+	}
+}
+
+// FeatureService manages feature flags and answers whether one is on for a
+// user. Flags are cached in memory: writes through this service apply at once,
+// and the cache is re-read after the refresh interval so changes made through
+// other server instances apply within it.
+type FeatureService struct {
+	repos   *repositories.Repositories
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	flags    map[string]models.FeatureFlag
+	loadedAt time.Time
+	// generation counts invalidations, so a reload that raced with a write
+	// does not cache what it read before the write
+	generation uint64
+}
+
+func NewFeatureService(repos *repositories.Repositories, refresh time.Duration) *FeatureService {
+	return &FeatureService{repos: repos, refresh: refresh}
+}
+
+// Enabled reports whether the flag named key is on for the actor. Unknown
+// flags are off.
+func (s *FeatureService) Enabled(actor Actor, key string) (bool, error) {
+	flags, err := s.snapshot()
+	if err != nil {
+		return false, err
+	}
+	flag, ok := flags[key]
+	if !ok {
+		return false, nil
+	}
+	return s.appliesTo(flag, actor)
+}
+
+// FeatureEnabled is Enabled for callers that only know the user ID and role,
+// such as middleware
+func (s *FeatureService) FeatureEnabled(userID uint, role, key string) (bool, error) {
+	return s.Enabled(Actor{UserID: userID, Role: role}, key)
+}
+
+// EnabledKeys lists the keys of every flag that is on for the actor
+func (s *FeatureService) EnabledKeys(actor Actor) ([]string, error) {
+	flags, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key, flag := range flags {
+		on, err := s.appliesTo(flag, actor)
+		if err != nil {
+			return nil, err
+		}
+		if on {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// appliesTo evaluates the flag's targeting for the actor. The actor's
+// department is only looked up when the flag targets departments.
+func (s *FeatureService) appliesTo(flag models.FeatureFlag, actor Actor) (bool, error) {
+	if !flag.Enabled {
+		return false, nil
+	}
+	if len(flag.Roles) == 0 && len(flag.DepartmentIDs) == 0 && len(flag.UserIDs) == 0 {
+		return true, nil
+	}
+	if slices.Contains(flag.Roles, actor.Role) || slices.Contains(flag.UserIDs, actor.UserID) {
+		return true, nil
+	}
+	if len(flag.DepartmentIDs) == 0 {
+		return false, nil
+	}
+
+	user, err := s.repos.Users.FindByID(actor.UserID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.Employee != nil && slices.Contains(flag.DepartmentIDs, user.Employee.DepartmentID), nil
+}
+
+// snapshot returns the cached flags by key, re-reading them once they are
+// older than the refresh interval
+func (s *FeatureService) snapshot() (map[string]models.FeatureFlag, error) {
+	s.mu.RLock()
+	flags, generation := s.flags, s.generation
+	fresh := flags != nil && time.Since(s.loadedAt) < s.refresh
+	s.mu.RUnlock()
+	if fresh {
+		return flags, nil
+	}
+
+	list, err := s.repos.FeatureFlags.List()
+	if err != nil {
+		return nil, err
+	}
+	flags = make(map[string]models.FeatureFlag, len(list))
+	for _, flag := range list {
+		flags[flag.Key] = flag
+	}
+
+	s.mu.Lock()
+	if s.generation == generation {
+		s.flags, s.loadedAt = flags, time.Now()
+	}
+	s.mu.Unlock()
+	return flags, nil
+}
+
+// invalidate drops the cache after a write so the change applies to the next check
+func (s *FeatureService) invalidate() {
+	s.mu.Lock()
+	s.flags = nil
+	s.generation++
+	s.mu.Unlock()
+}
+
+func (s *FeatureService) List() ([]models.FeatureFlag, error) {
+	return s.repos.FeatureFlags.List()
+}
+
+func (s *FeatureService) Get(key string) (*models.FeatureFlag, error) {
+	flag, err := s.repos.FeatureFlags.FindByKey(key)
+	if err != nil {
+		return nil, notFoundAs(err, "Feature flag not found")
+	}
+	return flag, nil
+}
+
+func (s *FeatureService) Create(input FeatureFlagInput) (*models.FeatureFlag, error) {
+	if !featureKeyPattern.MatchString(input.Key) {
+		return nil, newError(ErrInvalid, "key must start with a letter and contain only lower-case letters, digits, '_', '.' and '-'")
+	}
+	if _, err := s.repos.FeatureFlags.FindByKey(input.Key); err == nil {
+		return nil, newError(ErrConflict, "Feature flag already exists")
+	} else if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
+
+	flag := &models.FeatureFlag{
+		Key:           input.Key,
+		Description:   input.Description,
+		Enabled:       input.Enabled,
+		Roles:         input.Roles,
+		DepartmentIDs: input.DepartmentIDs,
+		UserIDs:       input.UserIDs,
+	}
+	if err := s.repos.FeatureFlags.Create(flag); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return flag, nil
+}
+
+// Update applies input to flag, which must be at the version the caller edited
+func (s *FeatureService) Update(flag *models.FeatureFlag, input FeatureFlagInput) error {
+	err := s.repos.FeatureFlags.Update(flag, input.columns())
+	if err != nil {
+		return staleAs(err, "Feature flag has been modified by another request")
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *FeatureService) Delete(key string) error {
+	flag, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+	if err := s.repos.FeatureFlags.Delete(flag.ID); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"time"
+)
+
+// LeaveInput holds the requester-editable fields of a leave request
+type LeaveInput struct {
+	// EmployeeID is ignored unless the actor is HR; others apply for themselves
+	EmployeeID uint
+	LeaveType  string
+	StartDate  time.Time
+	EndDate    time.Time
+	Reason     string
+}
+
+// LeaveDecision is an approver's decision on a pending leave request
+type LeaveDecision struct {
+	Status   string // approved or rejected
+	Comments string
+}
+
+// leaveWritableFields is the mass-assignment allowlist for leave request updates
+var leaveWritableFields = []string{"leave_type", "start_date", "end_date", "days", "reason"}
+
+// columns maps the input onto leave request columns, deriving the number of
+// days from the start and end dates
+func (in LeaveInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"leave_type": in.LeaveType,
+		"start_date": in.StartDate,
+		"end_date":   in.EndDate,
+		"days":       LeaveDays(in.StartDate, in.EndDate),
+		"reason":     in.Reason,
+	}
+}
+
+// LeaveDays returns the number of calendar days covered by a leave, inclusive
+func LeaveDays(start, end time.Time) int {
+	duration := end.Sub(start)
+	return int(duration.Hours()/24) + 1 // +1 to include both start and end days
+}
+
+type LeaveService struct {
+	repos    *repositories.Repositories
+	uow      repositories.UnitOfWork
+	features *FeatureService
+}
+
+func NewLeaveService(repos *repositories.Repositories, uow repositories.UnitOfWork, features *FeatureService) *LeaveService {
+	return &LeaveService{repos: repos, uow: uow, features: features}
+}
+
+// List returns the leave requests visible to the actor
+func (s *LeaveService) List(actor Actor) ([]repositories.LeaveSummary, error) {
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Leaves.List(scope)
+}
+
+func (s *LeaveService) Get(id uint) (*models.LeaveRequest, error) {
+	leave, err := s.repos.Leaves.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Leave request not found")
+	}
+	return leave, nil
+}
+
+// View returns a leave request the actor may see: HR any, managers their
+// department's and employees their own
+func (s *LeaveService) View(actor Actor, id uint) (*models.LeaveRequest, error) {
+	leave, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInScope(scope, leave.Employee); err != nil {
+		return nil, err
+	}
+	return leave, nil
+}
+
+// Apply submits a pending leave request. HR may apply on behalf of any
+// employee; everyone else applies for themselves.
+func (s *LeaveService) Apply(actor Actor, input LeaveInput) (*models.LeaveRequest, error) {
+	if !actor.IsHR() {
+		employee, err := employeeOf(s.repos.Users, actor)
+		if err != nil {
+			return nil, err
+		}
+		input.EmployeeID = employee.ID
+	}
+	if input.EmployeeID == 0 {
+		return nil, newError(ErrInvalid, "employeeId is required")
+	}
+
+	leave := &models.LeaveRequest{
+		EmployeeID: input.EmployeeID,
+		LeaveType:  input.LeaveType,
+		StartDate:  input.StartDate,
+		EndDate:    input.EndDate,
+		Days:       LeaveDays(input.StartDate, input.EndDate),
+		Reason:     input.Reason,
+		Status:     "pending",
+	}
+	if err := s.repos.Leaves.Create(leave); err != nil {
+		return nil, err
+	}
+	return leave, nil
+}
+
+// GetEditable returns a leave request the actor may change. HR may change any
+// request; everyone else only their own pending requests.
+func (s *LeaveService) GetEditable(actor Actor, id uint) (*models.LeaveRequest, error) {
+	leave, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOwnPending(actor, leave, "update"); err != nil {
+		return nil, err
+	}
+	return leave, nil
+}
+
+// Update applies input to leave, which must be at the version the caller edited
+func (s *LeaveService) Update(leave *models.LeaveRequest, input LeaveInput) error {
+	err := s.repos.Leaves.Update(leave, permitted(input.columns(), leaveWritableFields))
+	return staleAs(err, "Leave request has been modified by another request")
+}
+
+// Decide approves or rejects a pending leave request on behalf of the actor.
+// HR may decide any request. While the manager_leave_approval flag is on for
+// them, managers may decide requests from their own department other than
+// their own. The request is re-read and decided in one transaction, and the decision is
+// only written while the request is still at the version the approver saw,
+// so two approvers acting at the same time cannot both succeed.
+func (s *LeaveService) Decide(actor Actor, leave *models.LeaveRequest, decision LeaveDecision) error {
+	if decision.Status != "approved" && decision.Status != "rejected" {
+		return newError(ErrInvalid, "status must be approved or rejected")
+	}
+	if !actor.IsHR() {
+		allowed, err := s.features.Enabled(actor, FeatureManagerLeaveApproval)
+		if err != nil {
+			return err
+		}
+		if !allowed || actor.Role != "manager" {
+			return newError(ErrForbidden, "Only HR can approve or reject leave requests")
+		}
+	}
+
+	var decided *models.LeaveRequest
+	err := s.uow.Do(sql.LevelRepeatableRead, func(repos *repositories.Repositories) error {
+		current, err := repos.Leaves.FindByID(leave.ID)
+		if err != nil {
+			return notFoundAs(err, "Leave request not found")
+		}
+		if current.Status != "pending" {
+			return newError(ErrConflict, "Leave request has already been "+current.Status)
+		}
+		if current.Version != leave.Version {
+			return repositories.ErrStale
+		}
+
+		approver, err := employeeOf(repos.Users, actor)
+		if err != nil {
+			return err
+		}
+		if !actor.IsHR() {
+			if err := checkDepartmentApprover(repos, approver, current); err != nil {
+				return err
+			}
+		}
+
+		err = repos.Leaves.Update(current, map[string]interface{}{
+			"status":      decision.Status,
+			"comments":    decision.Comments,
+			"approved_by": approver.ID,
+			"approved_at": time.Now(),
+		})
+		decided = current
+		return err
+	})
+	if errors.Is(err, repositories.ErrStale) {
+		return newError(ErrConflict, "Leave request was processed by another request")
+	}
+	if err != nil {
+		return err
+	}
+
+	*leave = *decided
+	return nil
+}
+
+// checkDepartmentApprover allows a manager to decide a request from another
+// employee of their department
+func checkDepartmentApprover(repos *repositories.Repositories, approver *models.Employee, leave *models.LeaveRequest) error {
+	if leave.EmployeeID == approver.ID {
+		return newError(ErrForbidden, "You cannot decide your own leave requests")
+	}
+	employee, err := repos.Employees.FindByID(leave.EmployeeID)
+	if err != nil {
+		return notFoundAs(err, "Employee not found")
+	}
+	if employee.DepartmentID != approver.DepartmentID {
+		return newError(ErrForbidden, "You can only decide leave requests from your own department")
+	}
+	return nil
+}
+
+// Delete removes a leave request. HR may delete any request; everyone else
+// only their own pending requests.
+func (s *LeaveService) Delete(actor Actor, id uint) error {
+	leave, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwnPending(actor, leave, "delete"); err != nil {
+		return err
+	}
+	return s.repos.Leaves.Delete(leave.ID)
+}
+
+func (s *LeaveService) checkOwnPending(actor Actor, leave *models.LeaveRequest, action string) error {
+	if actor.IsHR() {
+		return nil
+	}
+
+	employee, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return err
+	}
+	if employee.ID != leave.EmployeeID {
+		return newError(ErrForbidden, "You can only "+action+" your own leave requests")
+	}
+	if leave.Status != "pending" {
+		return newError(ErrForbidden, "You can only "+action+" pending leave requests")
+	}
+	return nil
+}
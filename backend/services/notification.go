@@ -0,0 +1,37 @@
+package services
+
+import (
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"time"
+)
+
+// NotificationService gives users their notifications
+type NotificationService struct {
+	repos *repositories.Repositories
+}
+
+func NewNotificationService(repos *repositories.Repositories) *NotificationService {
+	return &NotificationService{repos: repos}
+}
+
+// List lists the actor's notifications, only unread ones when unread is set
+func (s *NotificationService) List(actor Actor, unread bool) ([]models.Notification, error) {
+	return s.repos.Notifications.ListByUser(actor.UserID, unread)
+}
+
+// MarkRead marks one of the actor's notifications read. Other users'
+// notifications are reported as missing.
+func (s *NotificationService) MarkRead(actor Actor, id uint) (*models.Notification, error) {
+	notification, err := s.repos.Notifications.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Notification not found")
+	}
+	if notification.UserID != actor.UserID {
+		return nil, newError(ErrNotFound, "Notification not found")
+	}
+	if err := s.repos.Notifications.MarkRead(notification, time.Now()); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"hrms-backend/repositories"
+)
+
+// OrgNode is an employee in the org chart together with their direct reports
+type OrgNode struct {
+	repositories.ReportingLine
+	Reports []*OrgNode
+}
+
+// OrgMember is an employee found by walking reporting lines. Depth is 1 for
+// direct reports or the direct manager and grows by one per level.
+type OrgMember struct {
+	repositories.ReportingLine
+	Depth int
+}
+
+// errReportingCycle is returned when a manager change would make an employee
+// report, directly or through others, to themselves
+var errReportingCycle = newError(ErrInvalid, "The new manager reports to this employee, which would create a reporting cycle")
+
+// sameManager reports whether two optional manager IDs name the same manager
+func sameManager(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// checkReportingLine refuses to make managerID the manager of employeeID when
+// managerID already reports to employeeID. Terminated and deleted managers
+// end the walk, as does a cycle already in the data.
+func checkReportingLine(employees repositories.EmployeeRepository, employeeID uint, managerID *uint) error {
+	if managerID == nil {
+		return nil
+	}
+	if *managerID == employeeID {
+		return newError(ErrInvalid, "An employee cannot be their own manager")
+	}
+
+	seen := map[uint]bool{}
+	for current := managerID; current != nil; {
+		if *current == employeeID {
+			return errReportingCycle
+		}
+		if seen[*current] {
+			return nil
+		}
+		seen[*current] = true
+
+		next, err := employees.ManagerOf(*current)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		current = next
+	}
+	return nil
+}
+
+// reportsTo reports whether an employee reports to managerID, directly or
+// through others. A deleted manager ends the walk, as does a cycle already in
+// the data.
+func reportsTo(employees repositories.EmployeeRepository, employeeID, managerID uint) (bool, error) {
+	seen := map[uint]bool{employeeID: true}
+	current, err := employees.ManagerOf(employeeID)
+	for err == nil && current != nil {
+		if *current == managerID {
+			return true, nil
+		}
+		if seen[*current] {
+			return false, nil
+		}
+		seen[*current] = true
+		current, err = employees.ManagerOf(*current)
+	}
+	if errors.Is(err, repositories.ErrNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+// OrgChartService answers questions about reporting lines. Terminated
+// employees are left out, so their reports appear at the top of the chart.
+type OrgChartService struct {
+	repos *repositories.Repositories
+}
+
+func NewOrgChartService(repos *repositories.Repositories) *OrgChartService {
+	return &OrgChartService{repos: repos}
+}
+
+// orgIndex holds the reporting lines by employee and by manager
+type orgIndex struct {
+	lines   []repositories.ReportingLine
+	byID    map[uint]repositories.ReportingLine
+	reports map[uint][]repositories.ReportingLine
+}
+
+func (s *OrgChartService) index() (*orgIndex, error) {
+	lines, err := s.repos.Employees.ReportingLines()
+	if err != nil {
+		return nil, err
+	}
+	index := &orgIndex{
+		lines:   lines,
+		byID:    make(map[uint]repositories.ReportingLine, len(lines)),
+		reports: map[uint][]repositories.ReportingLine{},
+	}
+	for _, line := range lines {
+		index.byID[line.ID] = line
+	}
+	for _, line := range lines {
+		// A report whose manager left the chart belongs at the top
+		if line.ManagerID != nil && index.has(*line.ManagerID) {
+			index.reports[*line.ManagerID] = append(index.reports[*line.ManagerID], line)
+		}
+	}
+	return index, nil
+}
+
+// has reports whether an employee is on the chart
+func (i *orgIndex) has(id uint) bool {
+	_, ok := i.byID[id]
+	return ok
+}
+
+// member looks up an employee who is on the chart
+func (i *orgIndex) member(id uint) (repositories.ReportingLine, error) {
+	line, ok := i.byID[id]
+	if !ok {
+		return line, newError(ErrNotFound, "Employee not found in the org chart")
+	}
+	return line, nil
+}
+
+// Reports lists an employee's direct reports or, when transitive is set,
+// everyone below them, level by level
+func (s *OrgChartService) Reports(employeeID uint, transitive bool) ([]OrgMember, error) {
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := index.member(employeeID); err != nil {
+		return nil, err
+	}
+
+	members := []OrgMember{}
+	seen := map[uint]bool{employeeID: true}
+	level := []uint{employeeID}
+	for depth := 1; len(level) > 0; depth++ {
+		var next []uint
+		for _, id := range level {
+			for _, report := range index.reports[id] {
+				if seen[report.ID] {
+					continue
+				}
+				seen[report.ID] = true
+				members = append(members, OrgMember{ReportingLine: report, Depth: depth})
+				next = append(next, report.ID)
+			}
+		}
+		if !transitive {
+			break
+		}
+		level = next
+	}
+	return members, nil
+}
+
+// Chain lists an employee's managers from their direct manager to the top
+func (s *OrgChartService) Chain(employeeID uint) ([]OrgMember, error) {
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	employee, err := index.member(employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []OrgMember{}
+	seen := map[uint]bool{employeeID: true}
+	for current := employee.ManagerID; current != nil && !seen[*current]; {
+		manager, ok := index.byID[*current]
+		if !ok {
+			break
+		}
+		seen[manager.ID] = true
+		chain = append(chain, OrgMember{ReportingLine: manager, Depth: len(chain) + 1})
+		current = manager.ManagerID
+	}
+	return chain, nil
+}
+
+// Chart builds the org chart as a forest with the employees who have no
+// manager at the roots, or the subtree under rootID when it is not 0.
+// Employees caught in a reporting cycle, which updates prevent but older data
+// may hold, are shown under the lowest ID of the cycle.
+func (s *OrgChartService) Chart(rootID uint) ([]*OrgNode, error) {
+	index, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+
+	placed := map[uint]bool{}
+	var build func(line repositories.ReportingLine) *OrgNode
+	build = func(line repositories.ReportingLine) *OrgNode {
+		placed[line.ID] = true
+		node := &OrgNode{ReportingLine: line, Reports: []*OrgNode{}}
+		for _, report := range index.reports[line.ID] {
+			if !placed[report.ID] {
+				node.Reports = append(node.Reports, build(report))
+			}
+		}
+		return node
+	}
+
+	if rootID != 0 {
+		root, err := index.member(rootID)
+		if err != nil {
+			return nil, err
+		}
+		return []*OrgNode{build(root)}, nil
+	}
+
+	roots := []*OrgNode{}
+	for _, line := range index.lines {
+		if line.ManagerID == nil || !index.has(*line.ManagerID) {
+			roots = append(roots, build(line))
+		}
+	}
+	for _, line := range index.lines {
+		if !placed[line.ID] {
+			roots = append(roots, build(line))
+		}
+	}
+	return roots, nil
+}
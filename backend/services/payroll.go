@@ -0,0 +1,240 @@
+package services
+
+import (
+	"database/sql"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"math"
+	"time"
+)
+
+// PayrollInput holds the writable fields of a payroll record. Gross and net
+// pay are always computed.
+type PayrollInput struct {
+	EmployeeID     uint // only used when creating a record
+	PayPeriodStart time.Time
+	PayPeriodEnd   time.Time
+	BasicSalary    float64
+	Allowances     float64
+	Deductions     float64
+	Overtime       float64
+	Tax            float64
+	Status         string
+}
+
+// payrollWritableFields is the mass-assignment allowlist for payroll updates.
+// Gross and net pay are included because they are recomputed server-side.
+var payrollWritableFields = []string{
+	"pay_period_start", "pay_period_end", "basic_salary", "allowances", "deductions",
+	"overtime", "tax", "gross_pay", "net_pay", "status", "processed_at", "paid_at",
+}
+
+// applyTo copies the input onto a copy of the record
+func (in PayrollInput) applyTo(record models.PayrollRecord) models.PayrollRecord {
+	record.PayPeriodStart = in.PayPeriodStart
+	record.PayPeriodEnd = in.PayPeriodEnd
+	record.BasicSalary = in.BasicSalary
+	record.Allowances = in.Allowances
+	record.Deductions = in.Deductions
+	record.Overtime = in.Overtime
+	record.Tax = in.Tax
+	record.Status = in.Status
+	return record
+}
+
+// CalculatePayroll fills in the derived pay totals and status timestamps
+func CalculatePayroll(record *models.PayrollRecord) {
+	record.GrossPay = record.BasicSalary + record.Allowances + record.Overtime
+	record.NetPay = record.GrossPay - record.Deductions - record.Tax
+
+	now := time.Now()
+	if (record.Status == "processed" || record.Status == "paid") && record.ProcessedAt == nil {
+		record.ProcessedAt = &now
+	}
+	if record.Status == "paid" && record.PaidAt == nil {
+		record.PaidAt = &now
+	}
+}
+
+// payrollColumns maps a payroll record onto its writable columns
+func payrollColumns(record models.PayrollRecord) map[string]interface{} {
+	return map[string]interface{}{
+		"pay_period_start": record.PayPeriodStart,
+		"pay_period_end":   record.PayPeriodEnd,
+		"basic_salary":     record.BasicSalary,
+		"allowances":       record.Allowances,
+		"deductions":       record.Deductions,
+		"overtime":         record.Overtime,
+		"tax":              record.Tax,
+		"gross_pay":        record.GrossPay,
+		"net_pay":          record.NetPay,
+		"status":           record.Status,
+		"processed_at":     record.ProcessedAt,
+		"paid_at":          record.PaidAt,
+	}
+}
+
+// PayrollRun summarises a pay period run
+type PayrollRun struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Created     []models.PayrollRecord
+	// Skipped counts employees who already had a record for the period
+	Skipped int
+}
+
+type PayrollService struct {
+	repos *repositories.Repositories
+	uow   repositories.UnitOfWork
+}
+
+func NewPayrollService(repos *repositories.Repositories, uow repositories.UnitOfWork) *PayrollService {
+	return &PayrollService{repos: repos, uow: uow}
+}
+
+// List returns the payroll records visible to the actor
+func (s *PayrollService) List(actor Actor) ([]models.PayrollRecord, error) {
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Payroll.List(repositories.PayrollFilter{Scope: scope})
+}
+
+func (s *PayrollService) Get(id uint) (*models.PayrollRecord, error) {
+	record, err := s.repos.Payroll.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Payroll record not found")
+	}
+	return record, nil
+}
+
+// View returns a payroll record the actor may see: HR any, managers their
+// department's and employees their own
+func (s *PayrollService) View(actor Actor, id uint) (*models.PayrollRecord, error) {
+	record, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := scopeFor(s.repos.Users, actor)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInScope(scope, record.Employee); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *PayrollService) Create(input PayrollInput) (*models.PayrollRecord, error) {
+	if input.Status == "" {
+		input.Status = "draft"
+	}
+
+	record := input.applyTo(models.PayrollRecord{EmployeeID: input.EmployeeID})
+	CalculatePayroll(&record)
+
+	if err := s.repos.Payroll.Create(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Update applies input to record, which must be at the version the caller
+// edited. Pay totals are recomputed from the merged record rather than from
+// the fields that changed.
+func (s *PayrollService) Update(record *models.PayrollRecord, input PayrollInput) error {
+	updated := input.applyTo(*record)
+	CalculatePayroll(&updated)
+
+	err := s.repos.Payroll.Update(record, permitted(payrollColumns(updated), payrollWritableFields))
+	return staleAs(err, "Payroll record has been modified by another request")
+}
+
+func (s *PayrollService) Delete(id uint) error {
+	return s.repos.Payroll.Delete(id)
+}
+
+// Report lists payroll records for a pay period month, optionally limited to
+// one department. A zero year and month include every period.
+func (s *PayrollService) Report(year, month int, departmentID uint) ([]models.PayrollRecord, error) {
+	filter := repositories.PayrollFilter{Scope: repositories.Scope{DepartmentID: departmentID}}
+	if year != 0 || month != 0 {
+		if month < 1 || month > 12 {
+			return nil, newError(ErrInvalid, "month must be a number between 1 and 12")
+		}
+		if year < 1 {
+			return nil, newError(ErrInvalid, "year must be a positive number")
+		}
+		filter.PeriodFrom = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		filter.PeriodTo = filter.PeriodFrom.AddDate(0, 1, 0)
+	}
+	return s.repos.Payroll.Report(filter)
+}
+
+// RunPeriod creates a draft payroll record for the month for every active
+// employee who has not left before it, optionally limited to one department,
+// with a twelfth of their annual salary as basic pay. HR completes
+// allowances, deductions and tax before processing. Employees who already
+// have a record starting in the month, such as a final settlement, are
+// skipped, so a run can be repeated after hiring.
+func (s *PayrollService) RunPeriod(year, month int, departmentID uint) (*PayrollRun, error) {
+	if month < 1 || month > 12 {
+		return nil, newError(ErrInvalid, "month must be a number between 1 and 12")
+	}
+	if year < 1 {
+		return nil, newError(ErrInvalid, "year must be a positive number")
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	run := &PayrollRun{PeriodStart: start, PeriodEnd: start.AddDate(0, 1, -1)}
+
+	err := s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+		run.Created, run.Skipped = nil, 0
+
+		employees, err := repos.Employees.ListActive(departmentID)
+		if err != nil {
+			return err
+		}
+		existing, err := repos.Payroll.List(repositories.PayrollFilter{
+			Scope:      repositories.Scope{DepartmentID: departmentID},
+			PeriodFrom: start,
+			PeriodTo:   start.AddDate(0, 1, 0),
+		})
+		if err != nil {
+			return err
+		}
+		paid := map[uint]bool{}
+		for _, record := range existing {
+			paid[record.EmployeeID] = true
+		}
+
+		for _, employee := range employees {
+			// Leavers are paid by their final settlement
+			if employee.TerminationDate != nil && employee.TerminationDate.Before(start) {
+				continue
+			}
+			if paid[employee.ID] {
+				run.Skipped++
+				continue
+			}
+			record := models.PayrollRecord{
+				EmployeeID:     employee.ID,
+				PayPeriodStart: run.PeriodStart,
+				PayPeriodEnd:   run.PeriodEnd,
+				BasicSalary:    math.Round(employee.Salary/12*100) / 100,
+				Status:         "draft",
+			}
+			CalculatePayroll(&record)
+			if err := repos.Payroll.Create(&record); err != nil {
+				return err
+			}
+			record.Employee = employee
+			run.Created = append(run.Created, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
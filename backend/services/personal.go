@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"time"
+)
+
+// EmergencyContactInput holds the editable fields of an emergency contact. A
+// zero Priority puts a new contact after the employee's existing ones.
+type EmergencyContactInput struct {
+	Name         string
+	Relationship string
+	Phone        string
+	Email        string
+	Priority     int
+}
+
+func (in EmergencyContactInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":         in.Name,
+		"relationship": in.Relationship,
+		"phone":        in.Phone,
+		"email":        in.Email,
+		"priority":     in.Priority,
+	}
+}
+
+// DependentInput holds the editable fields of a dependent
+type DependentInput struct {
+	FirstName    string
+	LastName     string
+	Relationship string
+	BirthDate    time.Time
+}
+
+func (in DependentInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"first_name":   in.FirstName,
+		"last_name":    in.LastName,
+		"relationship": in.Relationship,
+		"birth_date":   dateOf(in.BirthDate),
+	}
+}
+
+func (in DependentInput) validate() error {
+	if dateOf(in.BirthDate).After(dateOf(time.Now())) {
+		return newError(ErrInvalid, "The birth date cannot be in the future")
+	}
+	return nil
+}
+
+// PersonalService manages employees' emergency contacts and dependents.
+// Employees keep their own up to date and HR sees and edits everyone's.
+// Managers may read the emergency contacts of the employees reporting to
+// them, directly or through others, but not their dependents.
+type PersonalService struct {
+	repos *repositories.Repositories
+}
+
+func NewPersonalService(repos *repositories.Repositories) *PersonalService {
+	return &PersonalService{repos: repos}
+}
+
+// checkManage lets HR manage every employee's details and employees their own
+func (s *PersonalService) checkManage(actor Actor, employeeID uint) error {
+	if _, err := s.repos.Employees.FindByID(employeeID); err != nil {
+		return notFoundAs(err, "Employee not found")
+	}
+	if actor.IsHR() {
+		return nil
+	}
+	own, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return err
+	}
+	if own.ID != employeeID {
+		return newError(ErrForbidden, "You do not have access to this employee's personal details")
+	}
+	return nil
+}
+
+// checkContactAccess also lets managers read their reports' emergency contacts
+func (s *PersonalService) checkContactAccess(actor Actor, employeeID uint) error {
+	err := s.checkManage(actor, employeeID)
+	if err == nil || actor.Role != "manager" || !errors.Is(err, ErrForbidden) {
+		return err
+	}
+	own, err := employeeOf(s.repos.Users, actor)
+	if err != nil {
+		return err
+	}
+	reports, err := reportsTo(s.repos.Employees, employeeID, own.ID)
+	if err != nil {
+		return err
+	}
+	if !reports {
+		return newError(ErrForbidden, "You can only see the emergency contacts of employees who report to you")
+	}
+	return nil
+}
+
+func (s *PersonalService) ListEmergencyContacts(actor Actor, employeeID uint) ([]models.EmergencyContact, error) {
+	if err := s.checkContactAccess(actor, employeeID); err != nil {
+		return nil, err
+	}
+	return s.repos.EmergencyContacts.ListByEmployee(employeeID)
+}
+
+// GetEmergencyContact loads one of an employee's contacts for editing
+func (s *PersonalService) GetEmergencyContact(actor Actor, employeeID, id uint) (*models.EmergencyContact, error) {
+	if err := s.checkManage(actor, employeeID); err != nil {
+		return nil, err
+	}
+	contact, err := s.repos.EmergencyContacts.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Emergency contact not found")
+	}
+	if contact.EmployeeID != employeeID {
+		return nil, newError(ErrNotFound, "Emergency contact not found")
+	}
+	return contact, nil
+}
+
+func (s *PersonalService) CreateEmergencyContact(actor Actor, employeeID uint, input EmergencyContactInput) (*models.EmergencyContact, error) {
+	if err := s.checkManage(actor, employeeID); err != nil {
+		return nil, err
+	}
+	if input.Priority == 0 {
+		existing, err := s.repos.EmergencyContacts.ListByEmployee(employeeID)
+		if err != nil {
+			return nil, err
+		}
+		input.Priority = 1
+		if len(existing) > 0 {
+			input.Priority = existing[len(existing)-1].Priority + 1
+		}
+	}
+
+	contact := &models.EmergencyContact{
+		EmployeeID:   employeeID,
+		Name:         input.Name,
+		Relationship: input.Relationship,
+		Phone:        input.Phone,
+		Email:        input.Email,
+		Priority:     input.Priority,
+	}
+	if err := s.repos.EmergencyContacts.Create(contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// UpdateEmergencyContact applies input to contact, which must be at the
+// version the caller edited
+func (s *PersonalService) UpdateEmergencyContact(contact *models.EmergencyContact, input EmergencyContactInput) error {
+	if input.Priority < 1 {
+		return newError(ErrInvalid, "Priority must be at least 1")
+	}
+	err := s.repos.EmergencyContacts.Update(contact, input.columns())
+	return staleAs(err, "Emergency contact has been modified by another request")
+}
+
+func (s *PersonalService) DeleteEmergencyContact(actor Actor, employeeID, id uint) error {
+	contact, err := s.GetEmergencyContact(actor, employeeID, id)
+	if err != nil {
+		return err
+	}
+	return s.repos.EmergencyContacts.Delete(contact.ID)
+}
+
+func (s *PersonalService) ListDependents(actor Actor, employeeID uint) ([]models.Dependent, error) {
+	if err := s.checkManage(actor, employeeID); err != nil {
+		return nil, err
+	}
+	return s.repos.Dependents.ListByEmployee(employeeID)
+}
+
+// GetDependent loads one of an employee's dependents for editing
+func (s *PersonalService) GetDependent(actor Actor, employeeID, id uint) (*models.Dependent, error) {
+	if err := s.checkManage(actor, employeeID); err != nil {
+		return nil, err
+	}
+	dependent, err := s.repos.Dependents.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Dependent not found")
+	}
+	if dependent.EmployeeID != employeeID {
+		return nil, newError(ErrNotFound, "Dependent not found")
+	}
+	return dependent, nil
+}
+
+func (s *PersonalService) CreateDependent(actor Actor, employeeID uint, input DependentInput) (*models.Dependent, error) {
+	if err := s.checkManage(actor, employeeID); err != nil {
+		return nil, err
+	}
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	dependent := &models.Dependent{
+		EmployeeID:   employeeID,
+		FirstName:    input.FirstName,
+		LastName:     input.LastName,
+		Relationship: input.Relationship,
+		BirthDate:    dateOf(input.BirthDate),
+	}
+	if err := s.repos.Dependents.Create(dependent); err != nil {
+		return nil, err
+	}
+	return dependent, nil
+}
+
+// UpdateDependent applies input to dependent, which must be at the version
+// the caller edited
+func (s *PersonalService) UpdateDependent(dependent *models.Dependent, input DependentInput) error {
+	if err := input.validate(); err != nil {
+		return err
+	}
+	err := s.repos.Dependents.Update(dependent, input.columns())
+	return staleAs(err, "Dependent has been modified by another request")
+}
+
+func (s *PersonalService) DeleteDependent(actor Actor, employeeID, id uint) error {
+	dependent, err := s.GetDependent(actor, employeeID, id)
+	if err != nil {
+		return err
+	}
+	return s.repos.Dependents.Delete(dependent.ID)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+)
+
+var (
+	ErrInvalid      = errors.New("invalid request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	// ErrStale means the record changed since the version the caller edited
+	ErrStale = errors.New("stale version")
+	// ErrTooLarge and ErrUnsupportedType reject uploaded files
+	ErrTooLarge        = errors.New("too large")
+	ErrUnsupportedType = errors.New("unsupported type")
+)
+
+// Error is a domain error whose message is safe to show to API clients. Its
+// kind is one of the sentinel errors above and is matched with errors.Is.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.Kind }
+
+func newError(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Actor is the authenticated user a service call is made on behalf of
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+// IsHR reports whether the actor may act on every employee's records
+func (a Actor) IsHR() bool {
+	return a.Role == "hr" || a.Role == "admin"
+}
+
+// notFoundAs replaces a repository miss with a domain error carrying message
+func notFoundAs(err error, message string) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return newError(ErrNotFound, message)
+	}
+	return err
+}
+
+// staleAs replaces a lost optimistic-locking race with a domain error
+func staleAs(err error, message string) error {
+	if errors.Is(err, repositories.ErrStale) {
+		return newError(ErrStale, message)
+	}
+	return err
+}
+
+// employeeOf returns the employee record linked to the actor's account
+func employeeOf(users repositories.UserRepository, actor Actor) (*models.Employee, error) {
+	user, err := users.FindByID(actor.UserID)
+	if err != nil {
+		return nil, notFoundAs(err, "User not found")
+	}
+	if user.Employee == nil {
+		return nil, newError(ErrForbidden, "User must be associated with an employee record")
+	}
+	return user.Employee, nil
+}
+
+// scopeFor limits lists to what the actor may see: HR sees everything,
+// managers their department and employees their own records
+func scopeFor(users repositories.UserRepository, actor Actor) (repositories.Scope, error) {
+	switch actor.Role {
+	case "hr", "admin":
+		return repositories.Scope{}, nil
+	case "manager":
+		employee, err := employeeOf(users, actor)
+		if err != nil {
+			return repositories.Scope{}, err
+		}
+		return repositories.Scope{DepartmentID: employee.DepartmentID}, nil
+	case "employee":
+		employee, err := employeeOf(users, actor)
+		if err != nil {
+			return repositories.Scope{}, err
+		}
+		return repositories.Scope{EmployeeID: employee.ID}, nil
+	default:
+		return repositories.Scope{}, newError(ErrForbidden, "Invalid user role")
+	}
+}
+
+// checkInScope refuses the records of employees outside scope, which the
+// actor could not see in a list either
+func checkInScope(scope repositories.Scope, employee models.Employee) error {
+	if scope.EmployeeID != 0 && employee.ID != scope.EmployeeID ||
+		scope.DepartmentID != 0 && employee.DepartmentID != scope.DepartmentID {
+		return newError(ErrForbidden, "Access denied")
+	}
+	return nil
+}
+
+// permitted drops every column that is not on the allowlist so that request
+// payloads can never write protected fields such as status or computed totals.
+func permitted(updates map[string]interface{}, allowed []string) map[string]interface{} {
+	result := make(map[string]interface{}, len(updates))
+	for _, column := range allowed {
+		if value, ok := updates[column]; ok {
+			result[column] = value
+		}
+	}
+	return result
+}
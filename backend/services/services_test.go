@@ -0,0 +1,1031 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"hrms-backend/utils"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeUsers is an in-memory UserRepository. Methods the tests do not need
+// panic through the embedded nil interface.
+type fakeUsers struct {
+	repositories.UserRepository
+	users map[uint]*models.User
+}
+
+func (f *fakeUsers) FindByID(id uint) (*models.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// fakeLeaves is an in-memory LeaveRepository that enforces record versions
+type fakeLeaves struct {
+	repositories.LeaveRepository
+	leaves map[uint]*models.LeaveRequest
+	nextID uint
+}
+
+func (f *fakeLeaves) FindByID(id uint) (*models.LeaveRequest, error) {
+	leave, ok := f.leaves[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *leave
+	return &copied, nil
+}
+
+func (f *fakeLeaves) Create(leave *models.LeaveRequest) error {
+	f.nextID++
+	leave.ID = f.nextID
+	leave.Version = 1
+	copied := *leave
+	f.leaves[leave.ID] = &copied
+	return nil
+}
+
+func (f *fakeLeaves) Update(leave *models.LeaveRequest, columns map[string]interface{}) error {
+	stored, ok := f.leaves[leave.ID]
+	if !ok {
+		return repositories.ErrNotFound
+	}
+	if stored.Version != leave.Version {
+		return repositories.ErrStale
+	}
+	if status, ok := columns["status"].(string); ok {
+		stored.Status = status
+	}
+	if approver, ok := columns["approved_by"].(uint); ok {
+		stored.ApprovedBy = &approver
+	}
+	stored.Version++
+	*leave = *stored
+	return nil
+}
+
+// fakeUnitOfWork runs fn directly against the in-memory repositories
+type fakeUnitOfWork struct {
+	repos *repositories.Repositories
+}
+
+func (u fakeUnitOfWork) Do(_ sql.IsolationLevel, fn func(repos *repositories.Repositories) error) error {
+	return fn(u.repos)
+}
+
+const (
+	hrUserID       = 1
+	employeeUserID = 2
+	hrEmployeeID   = 10
+	employeeID     = 20
+)
+
+func newLeaveFixture() (*LeaveService, *fakeLeaves) {
+	leaves := &fakeLeaves{leaves: map[uint]*models.LeaveRequest{}}
+	users := &fakeUsers{users: map[uint]*models.User{
+		hrUserID:       {Model: gorm.Model{ID: hrUserID}, Role: "hr", Employee: &models.Employee{Model: gorm.Model{ID: hrEmployeeID}}},
+		employeeUserID: {Model: gorm.Model{ID: employeeUserID}, Role: "employee", Employee: &models.Employee{Model: gorm.Model{ID: employeeID}}},
+	}}
+	repos := &repositories.Repositories{Users: users, Leaves: leaves}
+	return NewLeaveService(repos, fakeUnitOfWork{repos}, nil), leaves
+}
+
+func TestApplyUsesCallersOwnEmployeeRecord(t *testing.T) {
+	service, _ := newLeaveFixture()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	leave, err := service.Apply(Actor{UserID: employeeUserID, Role: "employee"}, LeaveInput{
+		EmployeeID: 999, // ignored for non-HR callers
+		LeaveType:  "annual",
+		StartDate:  start,
+		EndDate:    start.AddDate(0, 0, 2),
+	})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if leave.EmployeeID != employeeID {
+		t.Errorf("EmployeeID = %d, want %d", leave.EmployeeID, employeeID)
+	}
+	if leave.Days != 3 {
+		t.Errorf("Days = %d, want 3", leave.Days)
+	}
+	if leave.Status != "pending" {
+		t.Errorf("Status = %q, want pending", leave.Status)
+	}
+}
+
+func TestDecideApprovesPendingRequest(t *testing.T) {
+	service, leaves := newLeaveFixture()
+	hr := Actor{UserID: hrUserID, Role: "hr"}
+	created, err := service.Apply(hr, LeaveInput{EmployeeID: employeeID, LeaveType: "sick"})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if err := service.Decide(hr, created, LeaveDecision{Status: "approved"}); err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	stored := leaves.leaves[created.ID]
+	if stored.Status != "approved" {
+		t.Errorf("Status = %q, want approved", stored.Status)
+	}
+	if stored.ApprovedBy == nil || *stored.ApprovedBy != hrEmployeeID {
+		t.Errorf("ApprovedBy = %v, want %d", stored.ApprovedBy, hrEmployeeID)
+	}
+
+	err = service.Decide(hr, created, LeaveDecision{Status: "rejected"})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("deciding an approved request: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestDecideLosingRaceIsConflict(t *testing.T) {
+	service, _ := newLeaveFixture()
+	hr := Actor{UserID: hrUserID, Role: "hr"}
+	created, err := service.Apply(hr, LeaveInput{EmployeeID: employeeID, LeaveType: "sick"})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	first, _ := service.Get(created.ID)
+	second, _ := service.Get(created.ID)
+	if err := service.Decide(hr, first, LeaveDecision{Status: "approved"}); err != nil {
+		t.Fatalf("first Decide: %v", err)
+	}
+	err = service.Decide(hr, second, LeaveDecision{Status: "rejected"})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("second Decide: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestDecideRejectsUnknownStatus(t *testing.T) {
+	service, _ := newLeaveFixture()
+	err := service.Decide(Actor{UserID: hrUserID, Role: "hr"}, &models.LeaveRequest{Status: "pending"}, LeaveDecision{Status: "maybe"})
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestEmployeeCannotDeleteOthersLeave(t *testing.T) {
+	service, leaves := newLeaveFixture()
+	leaves.leaves[5] = &models.LeaveRequest{Model: gorm.Model{ID: 5}, EmployeeID: hrEmployeeID, Status: "pending"}
+
+	err := service.Delete(Actor{UserID: employeeUserID, Role: "employee"}, 5)
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestHRCannotGrantAdmin(t *testing.T) {
+	service := NewUserService(&repositories.Repositories{})
+	hr := Actor{UserID: hrUserID, Role: "hr"}
+	user := &models.User{Email: "hr@hrms.com", Role: "hr", IsActive: true}
+
+	err := service.Update(hr, user, UserInput{Email: user.Email, Role: "admin", IsActive: true})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("promoting: err = %v, want ErrForbidden", err)
+	}
+	if _, err := service.Create(hr, NewUserInput{Email: "new@hrms.com", Password: "secret123", Role: "admin"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("creating: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestHRCannotTouchAdminAccounts(t *testing.T) {
+	admin := &models.User{Model: gorm.Model{ID: 9}, Email: "admin@hrms.com", Role: "admin", IsActive: true}
+	users := &fakeUsers{users: map[uint]*models.User{9: admin}}
+	service := NewUserService(&repositories.Repositories{Users: users})
+	hr := Actor{UserID: hrUserID, Role: "hr"}
+
+	edits := map[string]UserInput{
+		"password":   {Email: admin.Email, Role: "admin", IsActive: true, Password: "taken-over"},
+		"email":      {Email: "mine@hrms.com", Role: "admin", IsActive: true},
+		"deactivate": {Email: admin.Email, Role: "admin", IsActive: false},
+	}
+	for name, input := range edits {
+		if err := service.Update(hr, admin, input); !errors.Is(err, ErrForbidden) {
+			t.Errorf("%s: err = %v, want ErrForbidden", name, err)
+		}
+	}
+	if err := service.Delete(hr, admin.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("delete: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestUpdateCannotTerminateOrReinstate(t *testing.T) {
+	service := NewEmployeeService(&repositories.Repositories{}, nil, nil)
+	for _, test := range []struct{ from, to string }{{"active", "terminated"}, {"terminated", "active"}} {
+		employee := &models.Employee{Model: gorm.Model{ID: 4}, Status: test.from}
+		err := service.Update(employee, EmployeeInput{Status: test.to})
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s to %s: err = %v, want ErrInvalid", test.from, test.to, err)
+		}
+	}
+}
+
+func TestCannotCreateTerminatedEmployees(t *testing.T) {
+	repos := &repositories.Repositories{}
+	service := NewEmployeeService(repos, fakeUnitOfWork{repos}, nil)
+	input := EmployeeInput{FirstName: "Ada", LastName: "Byron", Email: "ada@example.com", Status: "terminated"}
+
+	if _, err := service.Create(Actor{UserID: 1, Role: "hr"}, input); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Create: err = %v, want ErrInvalid", err)
+	}
+	if _, err := service.Import([]EmployeeInput{input}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Import: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestCheckInScope(t *testing.T) {
+	employee := models.Employee{Model: gorm.Model{ID: 4}, DepartmentID: 2}
+	tests := []struct {
+		scope repositories.Scope
+		ok    bool
+	}{
+		{repositories.Scope{}, true},
+		{repositories.Scope{DepartmentID: 2}, true},
+		{repositories.Scope{DepartmentID: 3}, false},
+		{repositories.Scope{EmployeeID: 4}, true},
+		{repositories.Scope{EmployeeID: 5}, false},
+	}
+	for _, test := range tests {
+		if err := checkInScope(test.scope, employee); (err == nil) != test.ok {
+			t.Errorf("checkInScope(%+v) = %v, want ok %v", test.scope, err, test.ok)
+		}
+	}
+}
+
+func TestCalculatePayroll(t *testing.T) {
+	record := models.PayrollRecord{
+		BasicSalary: 5000,
+		Allowances:  500,
+		Overtime:    250,
+		Deductions:  300,
+		Tax:         900,
+		Status:      "paid",
+	}
+	CalculatePayroll(&record)
+
+	if record.GrossPay != 5750 {
+		t.Errorf("GrossPay = %v, want 5750", record.GrossPay)
+	}
+	if record.NetPay != 4550 {
+		t.Errorf("NetPay = %v, want 4550", record.NetPay)
+	}
+	if record.ProcessedAt == nil || record.PaidAt == nil {
+		t.Error("paid records should be stamped as processed and paid")
+	}
+}
+
+func TestWorkingHours(t *testing.T) {
+	checkIn := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	checkOut := checkIn.Add(8*time.Hour + 30*time.Minute)
+
+	if got := WorkingHours(&checkIn, &checkOut); got != 8.5 {
+		t.Errorf("WorkingHours = %v, want 8.5", got)
+	}
+	if got := WorkingHours(&checkIn, nil); got != 0 {
+		t.Errorf("WorkingHours without check-out = %v, want 0", got)
+	}
+}
+
+// fakeDepartments is an in-memory DepartmentRepository whose headcounts come
+// from the employees of fakeEmployees
+type fakeDepartments struct {
+	repositories.DepartmentRepository
+	departments map[uint]*models.Department
+	employees   *fakeEmployees
+}
+
+func (f *fakeDepartments) FindByID(id uint) (*models.Department, error) {
+	department, ok := f.departments[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *department
+	return &copied, nil
+}
+
+func (f *fakeDepartments) CountEmployees(id uint) (int64, error) {
+	var count int64
+	for _, employee := range f.employees.employees {
+		if employee.DepartmentID == id {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeDepartments) Delete(id uint) error {
+	delete(f.departments, id)
+	return nil
+}
+
+type fakeEmployees struct {
+	repositories.EmployeeRepository
+	employees map[uint]*models.Employee
+}
+
+func (f *fakeEmployees) ReassignDepartment(fromID, toID uint) error {
+	for _, employee := range f.employees {
+		if employee.DepartmentID == fromID {
+			employee.DepartmentID = toID
+		}
+	}
+	return nil
+}
+
+func newDepartmentFixture() (*DepartmentService, *fakeDepartments) {
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		1: {Model: gorm.Model{ID: 1}, DepartmentID: 1},
+		2: {Model: gorm.Model{ID: 2}, DepartmentID: 1},
+	}}
+	departments := &fakeDepartments{
+		departments: map[uint]*models.Department{1: {Model: gorm.Model{ID: 1}}, 2: {Model: gorm.Model{ID: 2}}},
+		employees:   employees,
+	}
+	repos := &repositories.Repositories{Departments: departments, Employees: employees}
+	return NewDepartmentService(repos, fakeUnitOfWork{repos}), departments
+}
+
+func TestDeleteDepartmentWithEmployeesIsConflict(t *testing.T) {
+	service, departments := newDepartmentFixture()
+
+	err := service.Delete(1, nil)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	if _, ok := departments.departments[1]; !ok {
+		t.Error("department was deleted despite the conflict")
+	}
+}
+
+func TestDeleteDepartmentReassignsEmployees(t *testing.T) {
+	service, departments := newDepartmentFixture()
+	target := uint(2)
+
+	if err := service.Delete(1, &target); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := departments.departments[1]; ok {
+		t.Error("department 1 still exists")
+	}
+	for id, employee := range departments.employees.employees {
+		if employee.DepartmentID != target {
+			t.Errorf("employee %d is in department %d, want %d", id, employee.DepartmentID, target)
+		}
+	}
+
+	missing := uint(99)
+	if err := service.Delete(2, &missing); !errors.Is(err, ErrInvalid) {
+		t.Errorf("reassigning to a missing department: err = %v, want ErrInvalid", err)
+	}
+}
+
+func (f *fakeEmployees) FindByID(id uint) (*models.Employee, error) {
+	employee, ok := f.employees[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *employee
+	return &copied, nil
+}
+
+// fakeFeatureFlags is an in-memory FeatureFlagRepository
+type fakeFeatureFlags struct {
+	repositories.FeatureFlagRepository
+	flags []models.FeatureFlag
+}
+
+func (f *fakeFeatureFlags) List() ([]models.FeatureFlag, error) {
+	return append([]models.FeatureFlag(nil), f.flags...), nil
+}
+
+func (f *fakeFeatureFlags) FindByKey(key string) (*models.FeatureFlag, error) {
+	for _, flag := range f.flags {
+		if flag.Key == key {
+			return &flag, nil
+		}
+	}
+	return nil, repositories.ErrNotFound
+}
+
+func (f *fakeFeatureFlags) Create(flag *models.FeatureFlag) error {
+	flag.ID = uint(len(f.flags) + 1)
+	f.flags = append(f.flags, *flag)
+	return nil
+}
+
+const (
+	managerUserID     = 3
+	managerEmployeeID = 30
+	otherEmployeeID   = 40
+)
+
+// newManagerApprovalFixture has a manager and an employee in department 1
+// and another employee in department 2
+func newManagerApprovalFixture() (*LeaveService, *FeatureService) {
+	manager := &models.Employee{Model: gorm.Model{ID: managerEmployeeID}, DepartmentID: 1}
+	users := &fakeUsers{users: map[uint]*models.User{
+		managerUserID: {Model: gorm.Model{ID: managerUserID}, Role: "manager", Employee: manager},
+	}}
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		managerEmployeeID: manager,
+		employeeID:        {Model: gorm.Model{ID: employeeID}, DepartmentID: 1},
+		otherEmployeeID:   {Model: gorm.Model{ID: otherEmployeeID}, DepartmentID: 2},
+	}}
+	repos := &repositories.Repositories{
+		Users:        users,
+		Employees:    employees,
+		Leaves:       &fakeLeaves{leaves: map[uint]*models.LeaveRequest{}},
+		FeatureFlags: &fakeFeatureFlags{},
+	}
+	// A long refresh interval shows that writes apply without waiting for it
+	features := NewFeatureService(repos, time.Hour)
+	return NewLeaveService(repos, fakeUnitOfWork{repos}, features), features
+}
+
+func TestManagerLeaveApprovalFollowsFeatureFlag(t *testing.T) {
+	service, features := newManagerApprovalFixture()
+	manager := Actor{UserID: managerUserID, Role: "manager"}
+	hr := Actor{UserID: hrUserID, Role: "hr"}
+	apply := func(employee uint) *models.LeaveRequest {
+		start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+		leave, err := service.Apply(hr, LeaveInput{EmployeeID: employee, LeaveType: "annual", StartDate: start, EndDate: start})
+		if err != nil {
+			t.Fatalf("Apply: %v", err)
+		}
+		return leave
+	}
+
+	if err := service.Decide(manager, apply(employeeID), LeaveDecision{Status: "approved"}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Decide with the flag off: err = %v, want ErrForbidden", err)
+	}
+
+	_, err := features.Create(FeatureFlagInput{Key: FeatureManagerLeaveApproval, Enabled: true, DepartmentIDs: []uint{1}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if err := service.Decide(manager, apply(employeeID), LeaveDecision{Status: "approved"}); err != nil {
+		t.Errorf("Decide for own department: %v", err)
+	}
+	if err := service.Decide(manager, apply(otherEmployeeID), LeaveDecision{Status: "approved"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Decide for another department: err = %v, want ErrForbidden", err)
+	}
+	if err := service.Decide(manager, apply(managerEmployeeID), LeaveDecision{Status: "approved"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Decide for own request: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestFeatureTargeting(t *testing.T) {
+	_, features := newManagerApprovalFixture()
+	_, err := features.Create(FeatureFlagInput{Key: "payroll.rules-v2", Enabled: true, Roles: []string{"hr"}, UserIDs: []uint{employeeUserID}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		actor Actor
+		want  bool
+	}{
+		{Actor{UserID: hrUserID, Role: "hr"}, true},
+		{Actor{UserID: employeeUserID, Role: "employee"}, true},
+		{Actor{UserID: managerUserID, Role: "manager"}, false},
+	}
+	for _, test := range tests {
+		got, err := features.Enabled(test.actor, "payroll.rules-v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("Enabled for %s = %v, want %v", test.actor.Role, got, test.want)
+		}
+	}
+}
+
+func TestSnapshotAppliesChangesInOrder(t *testing.T) {
+	date := func(month int) time.Time { return time.Date(2024, time.Month(month), 1, 0, 0, 0, 0, time.UTC) }
+	position, salary, department, manager := "Engineer", 60000.0, uint(2), uint(7)
+	promoted, raise := "Senior Engineer", 72000.0
+	changes := []models.EmploymentChange{
+		{EffectiveDate: date(1), Kind: "hire", Position: &position, Salary: &salary, DepartmentID: &department, ManagerID: &manager},
+		// A back-dated raise only changes the salary, so the later promotion keeps it
+		{EffectiveDate: date(3), Salary: &raise},
+		{EffectiveDate: date(6), Position: &promoted, ClearManager: true},
+	}
+
+	if got := snapshotOf(1, date(1), nil); got != nil {
+		t.Errorf("snapshot before hire = %+v, want nil", got)
+	}
+
+	got := snapshotOf(1, date(4), changes[:2])
+	if got.Position != position || got.Salary != raise || got.ManagerID == nil || *got.ManagerID != manager || !got.Since.Equal(date(3)) {
+		t.Errorf("snapshot in April = %+v", got)
+	}
+
+	got = snapshotOf(1, date(7), changes)
+	if got.Position != promoted || got.Salary != raise || got.DepartmentID != department || got.ManagerID != nil {
+		t.Errorf("snapshot in July = %+v", got)
+	}
+}
+
+func (f *fakeEmployees) ManagerOf(id uint) (*uint, error) {
+	employee, ok := f.employees[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return employee.ManagerID, nil
+}
+
+func (f *fakeEmployees) ReportingLines() ([]repositories.ReportingLine, error) {
+	var lines []repositories.ReportingLine
+	for id := uint(1); id <= uint(len(f.employees)); id++ {
+		if employee, ok := f.employees[id]; ok && employee.Status != "terminated" {
+			lines = append(lines, repositories.ReportingLine{ID: id, ManagerID: employee.ManagerID})
+		}
+	}
+	return lines, nil
+}
+
+// newOrgFixture builds 1 <- 2 <- 3 <- 4 with 5 also reporting to 2 and 6
+// reporting to the terminated 7
+func newOrgFixture() (*OrgChartService, *fakeEmployees) {
+	manager := func(id uint) *uint { return &id }
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		1: {Model: gorm.Model{ID: 1}},
+		2: {Model: gorm.Model{ID: 2}, ManagerID: manager(1)},
+		3: {Model: gorm.Model{ID: 3}, ManagerID: manager(2)},
+		4: {Model: gorm.Model{ID: 4}, ManagerID: manager(3)},
+		5: {Model: gorm.Model{ID: 5}, ManagerID: manager(2)},
+		6: {Model: gorm.Model{ID: 6}, ManagerID: manager(7)},
+		7: {Model: gorm.Model{ID: 7}, Status: "terminated"},
+	}}
+	return NewOrgChartService(&repositories.Repositories{Employees: employees}), employees
+}
+
+func TestCheckReportingLineRejectsCycles(t *testing.T) {
+	_, employees := newOrgFixture()
+	id := func(id uint) *uint { return &id }
+
+	if err := checkReportingLine(employees, 2, id(4)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("making 4 the manager of 2: err = %v, want ErrInvalid", err)
+	}
+	if err := checkReportingLine(employees, 1, id(1)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("making 1 their own manager: err = %v, want ErrInvalid", err)
+	}
+	if err := checkReportingLine(employees, 4, id(5)); err != nil {
+		t.Errorf("moving 4 under 5: %v", err)
+	}
+	if err := checkReportingLine(employees, 1, nil); err != nil {
+		t.Errorf("clearing the manager: %v", err)
+	}
+}
+
+func TestReportsTo(t *testing.T) {
+	_, employees := newOrgFixture()
+	tests := []struct {
+		employee, manager uint
+		want              bool
+	}{
+		{4, 3, true},
+		{4, 1, true},
+		{5, 3, false},
+		{2, 4, false},
+		{1, 1, false},
+	}
+	for _, test := range tests {
+		got, err := reportsTo(employees, test.employee, test.manager)
+		if err != nil || got != test.want {
+			t.Errorf("reportsTo(%d, %d) = %v, %v; want %v", test.employee, test.manager, got, err, test.want)
+		}
+	}
+}
+
+func TestCheckCustomValue(t *testing.T) {
+	maxLength, low, high := 8, 1.0, 100.0
+	fields := map[string]models.CustomField{
+		"text":   {Key: "text", Type: "text", MaxLength: &maxLength, Pattern: "[A-Z]{2}[0-9]+"},
+		"number": {Key: "number", Type: "number", Min: &low, Max: &high},
+		"date":   {Key: "date", Type: "date"},
+		"enum":   {Key: "enum", Type: "enum", Options: []string{"S", "M"}},
+	}
+	tests := []struct {
+		field string
+		value interface{}
+		want  interface{}
+		ok    bool
+	}{
+		{"text", " AB123 ", "AB123", true},
+		{"text", "AB1234567", nil, false},
+		{"text", "xAB123", nil, false},
+		{"text", "  ", "", true},
+		{"number", 42.0, 42.0, true},
+		{"number", json.Number("7"), 7.0, true},
+		{"number", "42", nil, false},
+		{"number", 101.0, nil, false},
+		{"date", "2024-06-30", "2024-06-30", true},
+		{"date", "30/06/2024", nil, false},
+		{"enum", "M", "M", true},
+		{"enum", "XL", nil, false},
+	}
+	for _, test := range tests {
+		got, err := checkCustomValue(fields[test.field], test.value)
+		if (err == nil) != test.ok || (test.ok && got != test.want) {
+			t.Errorf("checkCustomValue(%s, %#v) = %#v, %v; want %#v", test.field, test.value, got, err, test.want)
+		}
+	}
+}
+
+// fakeCustomFields is an in-memory CustomFieldRepository
+type fakeCustomFields struct {
+	repositories.CustomFieldRepository
+	fields []models.CustomField
+}
+
+func (f *fakeCustomFields) List(entity string) ([]models.CustomField, error) {
+	return f.fields, nil
+}
+
+func TestRequiredCustomFieldOnUpdate(t *testing.T) {
+	fields := &fakeCustomFields{fields: []models.CustomField{
+		{Entity: "employee", Key: "badge", Type: "text", Required: true},
+	}}
+
+	if _, err := checkCustomValues(fields, "employee", models.CustomValues{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("creating without the field: err = %v, want ErrInvalid", err)
+	}
+	// A record saved before the field was added
+	if _, err := checkCustomUpdate(fields, "employee", nil, nil); err != nil {
+		t.Errorf("updating an older record: err = %v, want nil", err)
+	}
+	previous := models.CustomValues{"badge": "B-12"}
+	if _, err := checkCustomUpdate(fields, "employee", models.CustomValues{"badge": nil}, previous); !errors.Is(err, ErrInvalid) {
+		t.Errorf("clearing the field: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestOrgChart(t *testing.T) {
+	service, _ := newOrgFixture()
+	ids := func(members []OrgMember) []uint {
+		var ids []uint
+		for _, member := range members {
+			ids = append(ids, member.ID)
+		}
+		return ids
+	}
+
+	direct, _ := service.Reports(2, false)
+	if got := ids(direct); !slices.Equal(got, []uint{3, 5}) {
+		t.Errorf("direct reports of 2 = %v, want [3 5]", got)
+	}
+	all, _ := service.Reports(1, true)
+	if got := ids(all); !slices.Equal(got, []uint{2, 3, 5, 4}) || all[3].Depth != 3 {
+		t.Errorf("all reports of 1 = %v, want [2 3 5 4] with 4 at depth 3", got)
+	}
+	chain, _ := service.Chain(4)
+	if got := ids(chain); !slices.Equal(got, []uint{3, 2, 1}) {
+		t.Errorf("chain of 4 = %v, want [3 2 1]", got)
+	}
+
+	roots, err := service.Chart(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 6's manager was terminated, so 6 is a root of their own
+	if len(roots) != 2 || roots[0].ID != 1 || roots[1].ID != 6 || len(roots[0].Reports[0].Reports) != 2 {
+		t.Errorf("unexpected chart shape")
+	}
+}
+
+// fakeChecklists is an in-memory ChecklistRepository
+type fakeChecklists struct {
+	repositories.ChecklistRepository
+	templates []models.ChecklistTemplate
+	tasks     []models.ChecklistTask
+}
+
+func (f *fakeChecklists) ListTemplates(kind string) ([]models.ChecklistTemplate, error) {
+	var templates []models.ChecklistTemplate
+	for _, template := range f.templates {
+		if template.Kind == kind {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+
+func (f *fakeChecklists) HasTasks(employeeID, templateID uint) (bool, error) {
+	for _, task := range f.tasks {
+		if task.EmployeeID == employeeID && task.TemplateID == templateID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeChecklists) CreateTasks(tasks []models.ChecklistTask) error {
+	f.tasks = append(f.tasks, tasks...)
+	return nil
+}
+
+func TestStartChecklistMatchesTemplatesOnce(t *testing.T) {
+	sales := uint(2)
+	checklists := &fakeChecklists{templates: []models.ChecklistTemplate{
+		{ID: 1, Kind: "onboarding", Active: true, Tasks: []models.ChecklistTemplateTask{
+			{Title: "Laptop", Assignee: "hr", DueOffsetDays: -2},
+			{Title: "Welcome lunch", Assignee: "manager", DueOffsetDays: 1},
+			{Title: "Read handbook", Assignee: "employee", DueOffsetDays: 7},
+		}},
+		{ID: 2, Kind: "onboarding", Active: true, DepartmentID: &sales, Tasks: []models.ChecklistTemplateTask{{Title: "CRM access", Assignee: "hr"}}},
+		{ID: 3, Kind: "onboarding", Active: true, Position: "software engineer", Tasks: []models.ChecklistTemplateTask{{Title: "Repo access", Assignee: "hr"}}},
+		{ID: 4, Kind: "onboarding", Active: false, Tasks: []models.ChecklistTemplateTask{{Title: "Retired", Assignee: "hr"}}},
+		{ID: 5, Kind: "offboarding", Active: true, Tasks: []models.ChecklistTemplateTask{{Title: "Return laptop", Assignee: "employee"}}},
+	}}
+	managerID := uint(1)
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		1: {Model: gorm.Model{ID: 1}, User: &models.User{Model: gorm.Model{ID: 100}}},
+	}}
+	repos := &repositories.Repositories{Employees: employees, Checklists: checklists}
+
+	hired := time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC)
+	employee := &models.Employee{Model: gorm.Model{ID: 2}, DepartmentID: 1, Position: "Software Engineer",
+		HireDate: hired, ManagerID: &managerID, User: &models.User{Model: gorm.Model{ID: 200}}}
+
+	created, err := startChecklist(repos, employee, "onboarding", hired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, task := range created {
+		titles = append(titles, task.Title)
+	}
+	if !slices.Equal(titles, []string{"Laptop", "Welcome lunch", "Read handbook", "Repo access"}) {
+		t.Fatalf("created %v", titles)
+	}
+
+	if due := created[0].DueDate; !due.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("laptop due %v, want 2024-03-02", due)
+	}
+	if created[0].AssigneeUserID != nil {
+		t.Errorf("hr task assigned to user %d, want nobody", *created[0].AssigneeUserID)
+	}
+	if user := created[1].AssigneeUserID; user == nil || *user != 100 {
+		t.Errorf("manager task assigned to %v, want user 100", user)
+	}
+	if user := created[2].AssigneeUserID; user == nil || *user != 200 {
+		t.Errorf("employee task assigned to %v, want user 200", user)
+	}
+
+	again, err := startChecklist(repos, employee, "onboarding", hired)
+	if err != nil || len(again) != 0 {
+		t.Errorf("starting again created %d tasks (err %v), want none", len(again), err)
+	}
+}
+
+func TestFinalPay(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+	employee := models.Employee{HireDate: time.Date(2020, 5, 1, 9, 0, 0, 0, time.UTC), Salary: 52000}
+	leaves := []models.LeaveRequest{
+		{LeaveType: "annual", Status: "approved", StartDate: day(2, 3), EndDate: day(2, 5)},
+		{LeaveType: "annual", Status: "approved", StartDate: day(6, 13), EndDate: day(6, 20)}, // 3 days before leaving
+		{LeaveType: "sick", Status: "approved", StartDate: day(3, 10), EndDate: day(3, 11)},
+		{LeaveType: "annual", Status: "rejected", StartDate: day(1, 10), EndDate: day(1, 20)},
+	}
+
+	// 15 of 30 days in June worked; 20 days a year earned over 166 of 365 days
+	pay := finalPay(employee, day(6, 15), 20, leaves)
+	if !pay.PeriodStart.Equal(day(6, 1)) || !pay.PeriodEnd.Equal(day(6, 15)) {
+		t.Errorf("period %v - %v, want June 1-15", pay.PeriodStart, pay.PeriodEnd)
+	}
+	if pay.ProratedSalary != 2166.67 {
+		t.Errorf("prorated salary = %v, want 2166.67", pay.ProratedSalary)
+	}
+	if pay.UnusedLeaveDays != 3.1 {
+		t.Errorf("unused leave = %v days, want 3.1 (9.1 earned - 6 taken)", pay.UnusedLeaveDays)
+	}
+	if pay.LeaveEncashment != 620 {
+		t.Errorf("encashment = %v, want 620 (3.1 days at 200)", pay.LeaveEncashment)
+	}
+
+	// Hired mid-month and leaving before earning the leave already taken
+	employee.HireDate = day(3, 16)
+	pay = finalPay(employee, day(3, 31), 20, leaves)
+	if pay.ProratedSalary != 2236.56 || pay.UnusedLeaveDays != 0 {
+		t.Errorf("late hire: salary %v, unused %v; want 2236.56 and 0", pay.ProratedSalary, pay.UnusedLeaveDays)
+	}
+}
+
+type fakeCodeSequences struct {
+	repositories.CodeSequenceRepository
+	values map[string]uint64
+}
+
+func (f *fakeCodeSequences) Next(scope string) (uint64, error) {
+	f.values[scope]++
+	return f.values[scope], nil
+}
+
+func (f *fakeEmployees) CodeExists(code string) (bool, error) {
+	for _, employee := range f.employees {
+		if employee.EmployeeCode == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestNextEmployeeCode(t *testing.T) {
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		1: {Model: gorm.Model{ID: 1}, EmployeeCode: "HR-2024-0002"},
+	}}
+	repos := &repositories.Repositories{
+		Employees: employees,
+		Departments: &fakeDepartments{departments: map[uint]*models.Department{
+			1: {Model: gorm.Model{ID: 1}, Name: "Human Resources"},
+			2: {Model: gorm.Model{ID: 2}, Name: "Engineering"},
+		}},
+		CodeSequences: &fakeCodeSequences{values: map[string]uint64{}},
+	}
+	codes := utils.MustParseCodePattern("{DEPT}-{YYYY}-{SEQ:4}")
+	hired := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// HR-2024-0002 was entered by hand, so the sequence skips it
+	for _, test := range []struct {
+		department uint
+		hired      time.Time
+		want       string
+	}{
+		{1, hired, "HR-2024-0001"},
+		{1, hired, "HR-2024-0003"},
+		{2, hired, "ENG-2024-0001"},
+		{1, hired.AddDate(1, 0, 0), "HR-2025-0001"},
+	} {
+		code, err := nextEmployeeCode(repos, codes, EmployeeInput{DepartmentID: test.department, HireDate: test.hired})
+		if err != nil || code != test.want {
+			t.Errorf("department %d hired %s: got %q, %v, want %q", test.department, test.hired.Format("2006"), code, err, test.want)
+		}
+		if !codes.Regexp().MatchString(code) {
+			t.Errorf("pattern does not match its own code %q", code)
+		}
+	}
+
+	if _, err := nextEmployeeCode(repos, nil, EmployeeInput{DepartmentID: 1}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("without a pattern: err = %v, want ErrInvalid", err)
+	}
+	if codes.Regexp().MatchString("EMP001") {
+		t.Error("pattern matches EMP001")
+	}
+}
+
+func TestSkillMatrix(t *testing.T) {
+	today := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	date := func(days int) *time.Time {
+		d := today.AddDate(0, 0, days)
+		return &d
+	}
+	service := &CertificationService{warningDays: 30}
+	employees := []models.Employee{
+		{Model: gorm.Model{ID: 1}, FirstName: "Ann", LastName: "Lee"},
+		{Model: gorm.Model{ID: 2}, FirstName: "Bob", LastName: "Ray"},
+	}
+	certifications := []models.Certification{
+		{ID: 1, EmployeeID: 1, SkillID: 10, ExpiresAt: date(-1)},
+		{ID: 2, EmployeeID: 1, SkillID: 10, ExpiresAt: date(30)},
+		{ID: 3, EmployeeID: 1, SkillID: 11},
+		{ID: 4, EmployeeID: 2, SkillID: 10, ExpiresAt: date(-5)},
+		{ID: 5, EmployeeID: 2, SkillID: 11, ExpiresAt: date(31)},
+		// Not in the department's rows
+		{ID: 6, EmployeeID: 3, SkillID: 10},
+	}
+
+	matrix := service.buildMatrix(7, nil, employees, certifications, today)
+	want := map[uint]map[uint]SkillMatrixCell{
+		1: {10: {CertificationID: 2, Status: CertificationExpiring, ExpiresAt: date(30)}, 11: {CertificationID: 3, Status: CertificationValid}},
+		2: {10: {CertificationID: 4, Status: CertificationExpired, ExpiresAt: date(-5)}, 11: {CertificationID: 5, Status: CertificationValid, ExpiresAt: date(31)}},
+	}
+	if len(matrix.Employees) != 2 {
+		t.Fatalf("got %d rows, want 2", len(matrix.Employees))
+	}
+	for _, row := range matrix.Employees {
+		if len(row.Skills) != len(want[row.EmployeeID]) {
+			t.Errorf("employee %d: got %d skills, want %d", row.EmployeeID, len(row.Skills), len(want[row.EmployeeID]))
+		}
+		for skillID, cell := range want[row.EmployeeID] {
+			got := row.Skills[skillID]
+			if got.CertificationID != cell.CertificationID || got.Status != cell.Status || !sameDate(got.ExpiresAt, cell.ExpiresAt) {
+				t.Errorf("employee %d skill %d: got %+v, want %+v", row.EmployeeID, skillID, got, cell)
+			}
+		}
+	}
+}
+
+// fakeCertifications is an in-memory CertificationRepository for expiry
+// warnings
+type fakeCertifications struct {
+	repositories.CertificationRepository
+	due    []repositories.DueCertification
+	warned map[uint]bool
+}
+
+func (f *fakeCertifications) DueWarnings(date time.Time) ([]repositories.DueCertification, error) {
+	var due []repositories.DueCertification
+	for _, certification := range f.due {
+		if certification.ExpiresAt.Before(date) && !f.warned[certification.ID] {
+			due = append(due, certification)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeCertifications) MarkWarned(id uint, _ time.Time) (bool, error) {
+	if f.warned[id] {
+		return false, nil
+	}
+	f.warned[id] = true
+	return true, nil
+}
+
+type fakeNotifications struct {
+	repositories.NotificationRepository
+	sent []models.Notification
+}
+
+func (f *fakeNotifications) Create(notification *models.Notification) error {
+	f.sent = append(f.sent, *notification)
+	return nil
+}
+
+func TestWarnExpiringWarnsHolderAndManagerOnce(t *testing.T) {
+	managerID := uint(1)
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		1: {Model: gorm.Model{ID: 1}, FirstName: "Mia", User: &models.User{Model: gorm.Model{ID: 100}}},
+		2: {Model: gorm.Model{ID: 2}, FirstName: "Ann", LastName: "Lee", ManagerID: &managerID, User: &models.User{Model: gorm.Model{ID: 200}}},
+	}}
+	today := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	certifications := &fakeCertifications{warned: map[uint]bool{}, due: []repositories.DueCertification{
+		{ID: 1, EmployeeID: 2, SkillName: "First aid", Name: "First Aid at Work", ExpiresAt: today.AddDate(0, 0, 30)},
+		{ID: 2, EmployeeID: 2, SkillName: "Forklift", Name: "Forklift licence", ExpiresAt: today.AddDate(0, 0, 31)},
+	}}
+	notifications := &fakeNotifications{}
+	repos := &repositories.Repositories{Employees: employees, Certifications: certifications, Notifications: notifications}
+	service := NewCertificationService(repos, fakeUnitOfWork{repos}, 30)
+
+	warned, err := service.WarnExpiring(today)
+	if err != nil || warned != 1 {
+		t.Fatalf("WarnExpiring = %d, %v, want 1", warned, err)
+	}
+	if len(notifications.sent) != 2 {
+		t.Fatalf("sent %d notifications, want 2", len(notifications.sent))
+	}
+	if got := notifications.sent[0]; got.UserID != 200 || got.Message != "Your certification First Aid at Work (First aid) expires on 2024-07-01" {
+		t.Errorf("holder got %+v", got)
+	}
+	if got := notifications.sent[1]; got.UserID != 100 || got.Message != "Ann Lee's certification First Aid at Work (First aid) expires on 2024-07-01" {
+		t.Errorf("manager got %+v", got)
+	}
+
+	if warned, err := service.WarnExpiring(today); err != nil || warned != 0 || len(notifications.sent) != 2 {
+		t.Errorf("second run warned %d (%v), sent %d notifications in all", warned, err, len(notifications.sent))
+	}
+}
+
+func TestWarnExpiringContinuesPastFailures(t *testing.T) {
+	deletedManagerID := uint(9)
+	employees := &fakeEmployees{employees: map[uint]*models.Employee{
+		1: {Model: gorm.Model{ID: 1}, FirstName: "Mia", User: &models.User{Model: gorm.Model{ID: 100}}},
+		2: {Model: gorm.Model{ID: 2}, FirstName: "Ann", ManagerID: &deletedManagerID, User: &models.User{Model: gorm.Model{ID: 200}}},
+	}}
+	today := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	certifications := &fakeCertifications{warned: map[uint]bool{}, due: []repositories.DueCertification{
+		{ID: 1, EmployeeID: 2, SkillName: "First aid", Name: "First Aid at Work", ExpiresAt: today},
+		{ID: 2, EmployeeID: 7, SkillName: "First aid", Name: "First Aid at Work", ExpiresAt: today},
+		{ID: 3, EmployeeID: 1, SkillName: "Forklift", Name: "Forklift licence", ExpiresAt: today},
+	}}
+	notifications := &fakeNotifications{}
+	repos := &repositories.Repositories{Employees: employees, Certifications: certifications, Notifications: notifications}
+	service := NewCertificationService(repos, fakeUnitOfWork{repos}, 30)
+
+	// Ann's manager was deleted, so only Ann is warned; the holder of
+	// certification 2 is missing, which must not stop certification 3
+	warned, err := service.WarnExpiring(today)
+	if warned != 2 || err == nil {
+		t.Fatalf("WarnExpiring = %d, %v, want 2 and an error for certification 2", warned, err)
+	}
+	var users []uint
+	for _, notification := range notifications.sent {
+		users = append(users, notification.UserID)
+	}
+	if !slices.Equal(users, []uint{200, 100}) {
+		t.Errorf("notified users %v, want [200 100]", users)
+	}
+}
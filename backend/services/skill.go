@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"strings"
+)
+
+// SkillInput holds the editable fields of a catalog skill
+type SkillInput struct {
+	Name        string
+	Category    string
+	Description string
+}
+
+func (in SkillInput) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        in.Name,
+		"category":    in.Category,
+		"description": in.Description,
+	}
+}
+
+// SkillService manages the skills catalog that certifications refer to
+type SkillService struct {
+	repos *repositories.Repositories
+}
+
+func NewSkillService(repos *repositories.Repositories) *SkillService {
+	return &SkillService{repos: repos}
+}
+
+func (s *SkillService) List() ([]models.Skill, error) {
+	return s.repos.Skills.List()
+}
+
+func (s *SkillService) Get(id uint) (*models.Skill, error) {
+	skill, err := s.repos.Skills.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "Skill not found")
+	}
+	return skill, nil
+}
+
+// checkName rejects a name another skill already has
+func (s *SkillService) checkName(name string, id uint) error {
+	existing, err := s.repos.Skills.FindByName(name)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != id {
+		return newError(ErrConflict, "A skill with this name already exists")
+	}
+	return nil
+}
+
+func (s *SkillService) Create(input SkillInput) (*models.Skill, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if err := s.checkName(input.Name, 0); err != nil {
+		return nil, err
+	}
+	skill := &models.Skill{
+		Name:        input.Name,
+		Category:    input.Category,
+		Description: input.Description,
+	}
+	if err := s.repos.Skills.Create(skill); err != nil {
+		return nil, err
+	}
+	return skill, nil
+}
+
+// Update applies input to skill, which must be at the version the caller
+// edited
+func (s *SkillService) Update(skill *models.Skill, input SkillInput) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if err := s.checkName(input.Name, skill.ID); err != nil {
+		return err
+	}
+	err := s.repos.Skills.Update(skill, input.columns())
+	return staleAs(err, "Skill has been modified by another request")
+}
+
+// Delete removes a skill nobody holds a certification for
+func (s *SkillService) Delete(id uint) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	held, err := s.repos.Certifications.CountBySkill(id)
+	if err != nil {
+		return err
+	}
+	if held > 0 {
+		return newError(ErrConflict, "Employees hold certifications for this skill; delete those first")
+	}
+	return s.repos.Skills.Delete(id)
+}
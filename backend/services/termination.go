@@ -0,0 +1,265 @@
+package services
+
+import (
+	"database/sql"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+	"math"
+	"time"
+)
+
+// workingDaysPerYear turns an annual salary into the day rate paid for each
+// unused leave day
+const workingDaysPerYear = 260
+
+// TerminationInput describes an employee's departure
+type TerminationInput struct {
+	LastWorkingDay time.Time
+	Reason         string
+}
+
+// FinalPay is what an employee is owed when they leave: their salary for the
+// days worked in the final month and their unused annual leave
+type FinalPay struct {
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	ProratedSalary  float64
+	UnusedLeaveDays float64
+	LeaveEncashment float64
+}
+
+// Termination reports what terminating an employee did
+type Termination struct {
+	Employee   *models.Employee
+	Settlement *models.PayrollRecord
+	FinalPay   FinalPay
+	// CancelledLeave lists the leave requests starting after the last working
+	// day and ShortenedLeave those that now end on it
+	CancelledLeave     []uint
+	ShortenedLeave     []uint
+	ChecklistTasks     int
+	AccountDeactivated bool
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// finalPay works out an employee's final pay. The final month is paid for
+// each calendar day up to and including lastDay. Annual leave is earned pro
+// rata over the days employed in the year of lastDay, and what approved annual
+// leave in that year has not used up is paid at the day rate.
+func finalPay(employee models.Employee, lastDay time.Time, annualLeaveDays int, leaves []models.LeaveRequest) FinalPay {
+	lastDay = dateOf(lastDay)
+	hired := dateOf(employee.HireDate)
+
+	monthStart := time.Date(lastDay.Year(), lastDay.Month(), 1, 0, 0, 0, 0, time.UTC)
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+	worked := LeaveDays(laterOf(monthStart, hired), lastDay)
+	pay := FinalPay{
+		PeriodStart:    monthStart,
+		PeriodEnd:      lastDay,
+		ProratedSalary: roundCents(employee.Salary / 12 * float64(worked) / float64(daysInMonth)),
+	}
+
+	yearStart := time.Date(lastDay.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	daysInYear := LeaveDays(yearStart, yearStart.AddDate(1, 0, -1))
+	earned := float64(annualLeaveDays) * float64(LeaveDays(laterOf(yearStart, hired), lastDay)) / float64(daysInYear)
+
+	taken := 0
+	for _, leave := range leaves {
+		if leave.Status != "approved" || leave.LeaveType != "annual" {
+			continue
+		}
+		start := laterOf(dateOf(leave.StartDate), yearStart)
+		end := dateOf(leave.EndDate)
+		if end.After(lastDay) {
+			end = lastDay
+		}
+		if !end.Before(start) {
+			taken += LeaveDays(start, end)
+		}
+	}
+
+	pay.UnusedLeaveDays = math.Max(0, roundCents(earned-float64(taken)))
+	pay.LeaveEncashment = roundCents(pay.UnusedLeaveDays * employee.Salary / workingDaysPerYear)
+	return pay
+}
+
+// completeTermination marks an employee terminated and deactivates their
+// account. It reports whether there was an active account to deactivate.
+func completeTermination(repos *repositories.Repositories, employee *models.Employee) (bool, error) {
+	if employee.Status != "terminated" {
+		if err := repos.Employees.Update(employee, map[string]interface{}{"status": "terminated"}); err != nil {
+			return false, err
+		}
+	}
+	if employee.User == nil || !employee.User.IsActive {
+		return false, nil
+	}
+	if err := repos.Users.Update(employee.User, map[string]interface{}{"is_active": false}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type TerminationService struct {
+	repos           *repositories.Repositories
+	uow             repositories.UnitOfWork
+	annualLeaveDays int
+}
+
+func NewTerminationService(repos *repositories.Repositories, uow repositories.UnitOfWork, annualLeaveDays int) *TerminationService {
+	return &TerminationService{repos: repos, uow: uow, annualLeaveDays: annualLeaveDays}
+}
+
+// Terminate records that an employee leaves after their last working day.
+// Pending and approved leave past that day is cancelled or cut short, the
+// final pay is drafted as a payroll record for the final month, replacing any
+// draft from that month on, and the offboarding checklist is started with its
+// tasks due relative to the last working day. Once the day is over the
+// employee is terminated and their account deactivated: at once for a day in
+// the past, otherwise by CompleteDue.
+func (s *TerminationService) Terminate(employeeID uint, input TerminationInput) (*Termination, error) {
+	lastDay := dateOf(input.LastWorkingDay)
+
+	var result *Termination
+	err := s.uow.Do(sql.LevelSerializable, func(repos *repositories.Repositories) error {
+		result = &Termination{CancelledLeave: []uint{}, ShortenedLeave: []uint{}}
+
+		employee, err := repos.Employees.FindByID(employeeID)
+		if err != nil {
+			return notFoundAs(err, "Employee not found")
+		}
+		if employee.Status == "terminated" || employee.TerminationDate != nil {
+			return newError(ErrConflict, "Employee has already been terminated")
+		}
+		if lastDay.Before(dateOf(employee.HireDate)) {
+			return newError(ErrInvalid, "The last working day cannot be before the hire date")
+		}
+
+		yearStart := time.Date(lastDay.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		leaves, err := repos.Leaves.ListByEmployee(employee.ID, yearStart)
+		if err != nil {
+			return err
+		}
+		for i := range leaves {
+			leave := &leaves[i]
+			if (leave.Status != "pending" && leave.Status != "approved") || !dateOf(leave.EndDate).After(lastDay) {
+				continue
+			}
+			if dateOf(leave.StartDate).After(lastDay) {
+				err = repos.Leaves.Update(leave, map[string]interface{}{
+					"status":   "cancelled",
+					"comments": "Cancelled: employment ends " + lastDay.Format("2006-01-02"),
+				})
+				result.CancelledLeave = append(result.CancelledLeave, leave.ID)
+			} else {
+				err = repos.Leaves.Update(leave, map[string]interface{}{
+					"end_date": lastDay,
+					"days":     LeaveDays(leave.StartDate, lastDay),
+				})
+				result.ShortenedLeave = append(result.ShortenedLeave, leave.ID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		pay := finalPay(*employee, lastDay, s.annualLeaveDays, leaves)
+		result.FinalPay = pay
+
+		// Drafts were made before the departure was known. Basic pay already
+		// processed for the final month is deducted from the settlement.
+		existing, err := repos.Payroll.List(repositories.PayrollFilter{
+			Scope:      repositories.Scope{EmployeeID: employee.ID},
+			PeriodFrom: pay.PeriodStart,
+		})
+		if err != nil {
+			return err
+		}
+		alreadyPaid := 0.0
+		for _, record := range existing {
+			if record.Status == "draft" {
+				if err := repos.Payroll.Delete(record.ID); err != nil {
+					return err
+				}
+			} else if record.PayPeriodStart.Before(pay.PeriodStart.AddDate(0, 1, 0)) {
+				alreadyPaid += record.BasicSalary
+			}
+		}
+		settlement := &models.PayrollRecord{
+			EmployeeID:     employee.ID,
+			PayPeriodStart: pay.PeriodStart,
+			PayPeriodEnd:   pay.PeriodEnd,
+			BasicSalary:    pay.ProratedSalary,
+			Allowances:     pay.LeaveEncashment,
+			Deductions:     roundCents(alreadyPaid),
+			Status:         "draft",
+		}
+		CalculatePayroll(settlement)
+		if err := repos.Payroll.Create(settlement); err != nil {
+			return err
+		}
+
+		err = repos.Employees.Update(employee, map[string]interface{}{
+			"termination_date":   lastDay,
+			"termination_reason": input.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		if lastDay.Before(dateOf(time.Now())) {
+			if result.AccountDeactivated, err = completeTermination(repos, employee); err != nil {
+				return err
+			}
+		}
+		settlement.Employee = *employee
+
+		tasks, err := startChecklist(repos, employee, "offboarding", lastDay)
+		if err != nil {
+			return err
+		}
+		result.ChecklistTasks = len(tasks)
+		result.Employee = employee
+		result.Settlement = settlement
+		return nil
+	})
+	if err != nil {
+		return nil, staleAs(err, "Employee has been modified by another request")
+	}
+	return result, nil
+}
+
+// CompleteDue terminates the employees whose last working day is before date
+// and deactivates their accounts. It returns how many it terminated.
+func (s *TerminationService) CompleteDue(date time.Time) (int, error) {
+	ids, err := s.repos.Employees.DueTerminationIDs(dateOf(date))
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, id := range ids {
+		err := s.uow.Do(sql.LevelReadCommitted, func(repos *repositories.Repositories) error {
+			employee, err := repos.Employees.FindByID(id)
+			if err != nil {
+				return err
+			}
+			_, err = completeTermination(repos, employee)
+			return err
+		})
+		if err != nil {
+			return completed, err
+		}
+		completed++
+	}
+	return completed, nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"hrms-backend/models"
+	"hrms-backend/repositories"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NewUserInput holds the fields HR sets when creating an account
+type NewUserInput struct {
+	Email      string
+	Password   string
+	FirstName  string
+	LastName   string
+	Role       string
+	IsActive   *bool
+	EmployeeID *uint
+}
+
+// UserInput holds the writable fields of an account. Password is only changed
+// when non-empty.
+type UserInput struct {
+	Email      string
+	Password   string
+	FirstName  string
+	LastName   string
+	Role       string
+	IsActive   bool
+	EmployeeID *uint
+}
+
+// ProfileInput holds the fields users may change on their own account. Empty
+// fields are left unchanged.
+type ProfileInput struct {
+	FirstName string
+	LastName  string
+	Password  string
+}
+
+// userSelfWritableFields is the allowlist for users editing their own account
+var userSelfWritableFields = []string{"first_name", "last_name", "password"}
+
+// userAdminWritableFields is the allowlist for HR editing any account
+var userAdminWritableFields = []string{"email", "first_name", "last_name", "password", "role", "is_active", "employee_id"}
+
+func (in UserInput) columns() map[string]interface{} {
+	columns := map[string]interface{}{
+		"email":       in.Email,
+		"first_name":  in.FirstName,
+		"last_name":   in.LastName,
+		"role":        in.Role,
+		"is_active":   in.IsActive,
+		"employee_id": in.EmployeeID,
+	}
+	if in.Password != "" {
+		columns["password"] = in.Password
+	}
+	return columns
+}
+
+// checkRoleGrant keeps admin rights in admins' hands: only an admin may open
+// an admin account
+func checkRoleGrant(actor Actor, role string) error {
+	if role == "admin" && actor.Role != "admin" {
+		return newError(ErrForbidden, "Only admins may create admin accounts")
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+type UserService struct {
+	repos *repositories.Repositories
+}
+
+func NewUserService(repos *repositories.Repositories) *UserService {
+	return &UserService{repos: repos}
+}
+
+func (s *UserService) List() ([]models.User, error) {
+	return s.repos.Users.List()
+}
+
+func (s *UserService) Get(id uint) (*models.User, error) {
+	user, err := s.repos.Users.FindByID(id)
+	if err != nil {
+		return nil, notFoundAs(err, "User not found")
+	}
+	return user, nil
+}
+
+// GetByEmail looks up a user by login email
+func (s *UserService) GetByEmail(email string) (*models.User, error) {
+	user, err := s.repos.Users.FindByEmail(email)
+	if err != nil {
+		return nil, notFoundAs(err, "User not found")
+	}
+	return user, nil
+}
+
+func (s *UserService) Create(actor Actor, input NewUserInput) (*models.User, error) {
+	if err := checkRoleGrant(actor, input.Role); err != nil {
+		return nil, err
+	}
+	hashed, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:      input.Email,
+		Password:   hashed,
+		FirstName:  input.FirstName,
+		LastName:   input.LastName,
+		Role:       input.Role,
+		IsActive:   true,
+		EmployeeID: input.EmployeeID,
+	}
+	if user.Role == "" {
+		user.Role = "employee"
+	}
+	if input.IsActive != nil {
+		user.IsActive = *input.IsActive
+	}
+
+	if err := s.repos.Users.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Update applies input to user, which must be at the version the caller
+// edited. Only HR may change email, activation or the linked employee, and
+// only an admin may change a role or edit an admin's account.
+func (s *UserService) Update(actor Actor, user *models.User, input UserInput) error {
+	if user.Role == "admin" && actor.Role != "admin" {
+		return newError(ErrForbidden, "Only admins may edit admin accounts")
+	}
+	if input.Role != user.Role && actor.Role != "admin" {
+		return newError(ErrForbidden, "Only admins may change roles")
+	}
+	if input.Password != "" {
+		hashed, err := hashPassword(input.Password)
+		if err != nil {
+			return err
+		}
+		input.Password = hashed
+	}
+
+	allowed := userSelfWritableFields
+	if actor.IsHR() {
+		allowed = userAdminWritableFields
+	}
+
+	err := s.repos.Users.Update(user, permitted(input.columns(), allowed))
+	return staleAs(err, "User has been modified by another request")
+}
+
+// UpdateProfile applies the non-empty fields of input to the user's own account
+func (s *UserService) UpdateProfile(user *models.User, input ProfileInput) error {
+	columns := map[string]interface{}{}
+	if input.FirstName != "" {
+		columns["first_name"] = input.FirstName
+	}
+	if input.LastName != "" {
+		columns["last_name"] = input.LastName
+	}
+	if input.Password != "" {
+		hashed, err := hashPassword(input.Password)
+		if err != nil {
+			return err
+		}
+		columns["password"] = hashed
+	}
+
+	err := s.repos.Users.Update(user, permitted(columns, userSelfWritableFields))
+	return staleAs(err, "User has been modified by another request")
+}
+
+// Delete removes an account. Only an admin may delete an admin's account.
+func (s *UserService) Delete(actor Actor, id uint) error {
+	user, err := s.repos.Users.FindByID(id)
+	if err != nil {
+		return notFoundAs(err, "User not found")
+	}
+	if user.Role == "admin" && actor.Role != "admin" {
+		return newError(ErrForbidden, "Only admins may delete admin accounts")
+	}
+	return s.repos.Users.Delete(id)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"hrms-backend/config"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps the contents of uploaded files. Keys are slash-separated paths
+// such as employees/7/3f2a; metadata belongs in the database.
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any object there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object under key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store selected by cfg.StorageBackend. Nothing is contacted
+// or created until the store is first used.
+func New(cfg *config.Config) Store {
+	if cfg.StorageBackend == "s3" {
+		return &S3Store{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretKey,
+			PathStyle:       cfg.S3PathStyle,
+			Client:          &http.Client{Timeout: 5 * time.Minute},
+		}
+	}
+	return NewLocalStore(cfg.StoragePath)
+}
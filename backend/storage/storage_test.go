@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO-style stand-in that keeps objects in memory and rejects
+// requests whose SigV4 signature does not match
+type fakeS3 struct {
+	accessKey, secret, region string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	var credential, signed, sig string
+	for _, part := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signed = value
+		case "Signature":
+			sig = value
+		}
+	}
+	if !strings.HasPrefix(credential, f.accessKey+"/") ||
+		sig != signature(r, f.secret, f.region, strings.Split(signed, ";")) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// exerciseStore runs a store through a put, get and delete
+func exerciseStore(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+	content := []byte("%PDF-1.4 employment contract")
+
+	if err := store.Put(ctx, "employees/7/contract", bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatal(err)
+	}
+	object, err := store.Get(ctx, "employees/7/contract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(object)
+	object.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("got %q, want %q", got, content)
+	}
+
+	if err := store.Delete(ctx, "employees/7/contract"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "employees/7/contract"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "employees/7/contract"); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+	exerciseStore(t, store)
+
+	for _, key := range []string{"", "../outside", "employees/../../outside", `employees\7`} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("key %q was accepted", key)
+		}
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{accessKey: "minio", secret: "minio-secret", region: "us-east-1", objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := &S3Store{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "hrms-documents",
+		AccessKeyID:     "minio",
+		SecretAccessKey: "minio-secret",
+		PathStyle:       true,
+		Client:          server.Client(),
+	}
+	exerciseStore(t, store)
+
+	store.SecretAccessKey = "wrong"
+	err := store.Put(context.Background(), "employees/7/id", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("wrong secret: got %v", err)
+	}
+}
+
+// TestSignature checks the GET Object example from the AWS SigV4 documentation
+func TestSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://examplebucket.s3.amazonaws.com/test.txt", nil)
+	req.Header.Set("Range", "bytes=0-9")
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	req.Header.Set("X-Amz-Date", time.Date(2013, 5, 24, 0, 0, 0, 0, time.UTC).Format(amzDateFormat))
+
+	got := signature(req, "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY", "us-east-1",
+		[]string{"host", "range", "x-amz-content-sha256", "x-amz-date"})
+	want := "f0e8bdb87c964420e857bd35b5d6ed310bd44f0170aba48dd91039c6036bdb41"
+	if got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CodePattern generates codes such as employee codes from a template like
+// {DEPT}-{YYYY}-{SEQ:4}. Placeholders are:
+//
+//	{DEPT}   the department's abbreviation, see DepartmentAbbreviation
+//	{YYYY}   the four-digit year of the reference date
+//	{YY}     its last two digits
+//	{MM}     the two-digit month
+//	{SEQ:n}  a sequence number padded with zeros to n digits ({SEQ} is {SEQ:4})
+//
+// Anything else is copied as is and may only use letters, digits and - _ . /
+// The pattern must contain {SEQ} exactly once. Codes that only differ in their
+// sequence number share a sequence, so {DEPT}-{YYYY}-{SEQ:4} numbers each
+// department's codes from 1 again every year.
+type CodePattern struct {
+	source string
+	parts  []codePart
+}
+
+// codePart is a literal, when token is empty, or a placeholder
+type codePart struct {
+	literal string
+	token   string
+	width   int
+}
+
+// CodeValues are what a code is generated for
+type CodeValues struct {
+	Department string
+	Date       time.Time
+}
+
+var (
+	codeToken   = regexp.MustCompile(`\{([A-Z]+)(?::([0-9]+))?\}`)
+	codeLiteral = regexp.MustCompile(`^[A-Za-z0-9._/-]*$`)
+	// nonAlphanumeric splits department names into words
+	nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// ParseCodePattern parses a pattern. An empty pattern returns nil, meaning
+// codes are not generated.
+func ParseCodePattern(source string) (*CodePattern, error) {
+	if source == "" {
+		return nil, nil
+	}
+
+	pattern := &CodePattern{source: source}
+	sequences := 0
+	rest := source
+	for rest != "" {
+		loc := codeToken.FindStringSubmatchIndex(rest)
+		literal := rest
+		if loc != nil {
+			literal = rest[:loc[0]]
+		}
+		if !codeLiteral.MatchString(literal) {
+			return nil, fmt.Errorf("%q may only contain placeholders, letters, digits and - _ . /", source)
+		}
+		if literal != "" {
+			pattern.parts = append(pattern.parts, codePart{literal: literal})
+		}
+		if loc == nil {
+			break
+		}
+
+		part := codePart{token: rest[loc[2]:loc[3]]}
+		hasWidth := loc[4] >= 0
+		switch part.token {
+		case "SEQ":
+			sequences++
+			part.width = 4
+			if hasWidth {
+				width, _ := strconv.Atoi(rest[loc[4]:loc[5]])
+				if width < 1 || width > 12 {
+					return nil, fmt.Errorf("%q: {SEQ:n} takes 1 to 12 digits", source)
+				}
+				part.width = width
+			}
+		case "DEPT", "YYYY", "YY", "MM":
+			if hasWidth {
+				return nil, fmt.Errorf("%q: only {SEQ} takes a width", source)
+			}
+		default:
+			return nil, fmt.Errorf("%q: unknown placeholder {%s}", source, part.token)
+		}
+		pattern.parts = append(pattern.parts, part)
+		rest = rest[loc[1]:]
+	}
+
+	if sequences != 1 {
+		return nil, fmt.Errorf("%q must contain {SEQ} exactly once", source)
+	}
+	return pattern, nil
+}
+
+// MustParseCodePattern is ParseCodePattern for patterns already validated
+func MustParseCodePattern(source string) *CodePattern {
+	pattern, err := ParseCodePattern(source)
+	if err != nil {
+		panic(err)
+	}
+	return pattern
+}
+
+func (p *CodePattern) String() string { return p.source }
+
+// Scope returns the code with its sequence number left as the {SEQ}
+// placeholder. Codes with the same scope share a sequence.
+func (p *CodePattern) Scope(values CodeValues) string {
+	return p.render(values, func(part codePart) string {
+		return fmt.Sprintf("{SEQ:%d}", part.width)
+	})
+}
+
+// Code returns the code with sequence number seq
+func (p *CodePattern) Code(values CodeValues, seq uint64) string {
+	return p.render(values, func(part codePart) string {
+		return fmt.Sprintf("%0*d", part.width, seq)
+	})
+}
+
+func (p *CodePattern) render(values CodeValues, sequence func(codePart) string) string {
+	var code strings.Builder
+	for _, part := range p.parts {
+		switch part.token {
+		case "":
+			code.WriteString(part.literal)
+		case "DEPT":
+			code.WriteString(DepartmentAbbreviation(values.Department))
+		case "YYYY":
+			code.WriteString(values.Date.Format("2006"))
+		case "YY":
+			code.WriteString(values.Date.Format("06"))
+		case "MM":
+			code.WriteString(values.Date.Format("01"))
+		case "SEQ":
+			code.WriteString(sequence(part))
+		}
+	}
+	return code.String()
+}
+
+// Regexp matches every code the pattern can generate, whatever the
+// department and date
+func (p *CodePattern) Regexp() *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, part := range p.parts {
+		switch part.token {
+		case "":
+			expr.WriteString(regexp.QuoteMeta(part.literal))
+		case "DEPT":
+			expr.WriteString("[A-Z0-9]{1,4}")
+		case "YYYY":
+			expr.WriteString("[0-9]{4}")
+		case "YY", "MM":
+			expr.WriteString("[0-9]{2}")
+		case "SEQ":
+			fmt.Fprintf(&expr, "[0-9]{%d,}", part.width)
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// DepartmentAbbreviation shortens a department name for codes: the initials
+// of a name of several words, up to four, or else its first three letters,
+// in upper case. "Human Resources" becomes HR and "Engineering" ENG.
+func DepartmentAbbreviation(name string) string {
+	words := strings.Fields(nonAlphanumeric.ReplaceAllString(name, " "))
+	var abbreviation string
+	switch {
+	case len(words) == 0:
+		return "X"
+	case len(words) == 1:
+		abbreviation = words[0]
+		if len(abbreviation) > 3 {
+			abbreviation = abbreviation[:3]
+		}
+	default:
+		for _, word := range words {
+			if len(abbreviation) == 4 {
+				break
+			}
+			abbreviation += word[:1]
+		}
+	}
+	return strings.ToUpper(abbreviation)
+}
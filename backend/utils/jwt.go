@@ -1,19 +1,19 @@
 package utils
 
 import (
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWT(userID uint, email, role string) (string, error) {
+// GenerateJWT signs a token for the user that expires after expiresIn
+func GenerateJWT(secret string, expiresIn time.Duration, userID uint, email, role string) (string, error) {
 	// Create token claims
 	claims := jwt.MapClaims{
 		"sub":   userID,
 		"email": email,
 		"role":  role,
-		"exp":   time.Now().Add(24 * time.Hour).Unix(),
+		"exp":   time.Now().Add(expiresIn).Unix(),
 		"iat":   time.Now().Unix(),
 	}
 
@@ -21,10 +21,5 @@ func GenerateJWT(userID uint, email, role string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign token with secret
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-super-secret-jwt-key"
-	}
-
 	return token.SignedString([]byte(secret))
 }
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := map[string]struct {
+		doc, patch, want string
+	}{
+		"null clears a member":        {`{"a":"b","c":"d"}`, `{"a":null}`, `{"c":"d"}`},
+		"zero values are kept":        {`{"a":5,"b":"x","c":true}`, `{"a":0,"b":"","c":false}`, `{"a":0,"b":"","c":false}`},
+		"members are added":           {`{"a":"b"}`, `{"c":"d"}`, `{"a":"b","c":"d"}`},
+		"nested objects are merged":   {`{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"x","d":null}}`, `{"a":{"b":"x"}}`},
+		"arrays are replaced":         {`{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		"object replaces scalar":      {`{"a":"b"}`, `{"a":{"c":null,"d":1}}`, `{"a":{"d":1}}`},
+		"non-object patch replaces":   {`{"a":"b"}`, `["c"]`, `["c"]`},
+		"null patch replaces":         {`{"a":"b"}`, `null`, `null`},
+		"empty target":                {``, `{"a":1}`, `{"a":1}`},
+		"large integers survive":      {`{"id":9007199254740993}`, `{"n":1}`, `{"id":9007199254740993,"n":1}`},
+		"empty patch changes nothing": {`{"a":"b"}`, `{}`, `{"a":"b"}`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := MergePatch([]byte(test.doc), []byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotValue, wantValue interface{}
+			if err := decodeJSON(got, &gotValue); err != nil {
+				t.Fatal(err)
+			}
+			if err := decodeJSON([]byte(test.want), &wantValue); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("MergePatch(%s, %s) = %s, want %s", test.doc, test.patch, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergePatchRejectsInvalidJSON(t *testing.T) {
+	if _, err := MergePatch([]byte(`{"a":1}`), []byte(`{"a":`)); err == nil {
+		t.Error("invalid patch was accepted")
+	}
+	if _, err := MergePatch([]byte(`{"a":`), []byte(`{}`)); err == nil {
+		t.Error("invalid target was accepted")
+	}
+}